@@ -0,0 +1,154 @@
+package main
+
+import (
+	"slices"
+	"time"
+
+	"github.com/MoraGames/clockyuwu/config"
+	"github.com/MoraGames/clockyuwu/events"
+	"github.com/MoraGames/clockyuwu/history"
+	"github.com/MoraGames/clockyuwu/pkg/cleanup"
+	"github.com/MoraGames/clockyuwu/pkg/types"
+	"github.com/MoraGames/clockyuwu/structs"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// AchievementCheck is a claim (already counted in the user stats and timeline) checked against the rules of the achievements
+type AchievementCheck struct {
+	Claim    Claim
+	Location *time.Location
+	Events   *events.EventsData
+	Event    *events.Event
+	Won      bool
+	Effects  []*structs.Effect
+}
+
+// AchievementRules are the rules the achievements of the configuration can use, by name
+var AchievementRules = map[string]func(check AchievementCheck, value int) bool{
+	"set": func(check AchievementCheck, value int) bool {
+		if !check.Won {
+			return false
+		}
+		// The events of the set are the ones of the day of the claim
+		date := check.Claim.SentAt.In(check.Location)
+		for _, set := range events.Sets {
+			if !slices.Contains(check.Events.Stats.EnabledSets, set.Name) || !set.Applies(check.Event.SetContext(date)) {
+				continue
+			}
+			// Every enabled event of the set must have been won by the user
+			completed := true
+			for _, event := range check.Events.Map {
+				if event.Enabled && set.Applies(event.SetContext(date)) && (event.Activation == nil || event.Activation.ActivatedBy == nil || event.Activation.ActivatedBy.TelegramID != check.Claim.UserID) {
+					completed = false
+					break
+				}
+			}
+			if completed {
+				return true
+			}
+		}
+		return false
+	},
+	"streak": func(check AchievementCheck, value int) bool {
+		if Timeline == nil {
+			return false
+		}
+		claims, err := Timeline.Claims(check.Claim.UserID)
+		return err == nil && history.Streak(claims, check.Claim.SentAt.In(check.Location).Format(history.DateFormat)) >= value
+	},
+	"last_second": func(check AchievementCheck, value int) bool {
+		return check.Claim.SentAt.Second() == 59
+	},
+	"survivor": func(check AchievementCheck, value int) bool {
+		if !check.Won {
+			return false
+		}
+		for _, effect := range check.Effects {
+			if effect.Key == "*" && effect.Value == value {
+				return true
+			}
+		}
+		return false
+	},
+}
+
+// Unlock the achievements whose rules are satisfied by the claim, giving their rewards and announcing them in the chat
+func CheckAchievements(check AchievementCheck, utils types.Utils, data types.Data) {
+	user, ok := Users[check.Claim.UserID]
+	if !ok {
+		return
+	}
+
+	for _, achievement := range utils.Config.Achievements {
+		rule, ok := AchievementRules[achievement.Rule]
+		if !ok {
+			utils.Logger.WithFields(logrus.Fields{
+				"achievement": achievement.Key,
+				"rule":        achievement.Rule,
+			}).Warn("Achievement rule not found")
+			continue
+		}
+		if _, unlocked := user.Achievements[achievement.Key]; unlocked || !rule(check, achievement.Value) {
+			continue
+		}
+		user.Unlock(achievement.Key, check.Claim.ReceivedAt)
+
+		// Give the reward, if it's a user-scoped effect
+		reward := structs.Effects[achievement.Reward]
+		if reward != nil && reward.Scope == "User" {
+			user.AddEffect(reward)
+		}
+
+		// The announcement is kept in the chat, even if the replies to the claims are deleted
+		settings := GetSettings(check.Claim.ChatID, utils)
+		text := Translate(check.Claim.ChatID, check.Claim.UserID, "achievement.unlocked", map[string]any{
+			"User":        check.Claim.UserName,
+			"Name":        achievement.Name,
+			"Description": achievement.Description,
+			"Reward":      rewardName(reward, settings),
+		}, utils)
+		if message, err := cleanup.Persistent(data.Bot).Send(tgbotapi.NewMessage(check.Claim.ChatID, text)); err != nil {
+			utils.Logger.WithFields(logrus.Fields{
+				"err": err,
+				"msg": message,
+			}).Error("Error while sending message")
+		}
+
+		utils.Logger.WithFields(logrus.Fields{
+			"user":        check.Claim.UserName,
+			"achievement": achievement.Key,
+		}).Info("Achievement unlocked")
+	}
+}
+
+// AchievementStatus is an achievement of the configuration, as it's shown to a user
+type AchievementStatus struct {
+	config.Achievement
+	Unlocked   bool
+	UnlockedAt string
+	RewardName string
+}
+
+// Get the achievements of the configuration, with the ones unlocked by the user (the dates are in the timezone of the chat)
+func UserAchievements(u *structs.User, settings types.Settings, utils types.Utils) ([]AchievementStatus, int) {
+	statuses := make([]AchievementStatus, 0, len(utils.Config.Achievements))
+	unlockedNum := 0
+	for _, achievement := range utils.Config.Achievements {
+		status := AchievementStatus{Achievement: achievement, RewardName: rewardName(structs.Effects[achievement.Reward], settings)}
+		if at, ok := u.Achievements[achievement.Key]; ok {
+			status.Unlocked, status.UnlockedAt = true, at.In(settings.Location()).Format(history.DateFormat)
+			unlockedNum++
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, unlockedNum
+}
+
+// Name of the reward, if it's a user-scoped effect and the effects are revealed
+func rewardName(reward *structs.Effect, settings types.Settings) string {
+	if reward == nil || reward.Scope != "User" || !settings.RevealEffects {
+		return ""
+	}
+	return reward.Name
+}
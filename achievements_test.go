@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MoraGames/clockyuwu/config"
+	"github.com/MoraGames/clockyuwu/pkg/types"
+	"github.com/MoraGames/clockyuwu/structs"
+)
+
+func Test_AchievementRules(t *testing.T) {
+	at := time.Date(2024, 3, 31, 12, 34, 59, 0, time.UTC)
+	minus3 := &structs.Effect{Name: "Mul -3", Scope: "Event", Key: "*", Value: -3}
+	tests := []struct {
+		rule  string
+		value int
+		check AchievementCheck
+		ok    bool
+	}{
+		{"last_second", 0, AchievementCheck{Claim: Claim{SentAt: at}}, true},
+		{"last_second", 0, AchievementCheck{Claim: Claim{SentAt: at.Add(-time.Second)}}, false},
+		{"survivor", -3, AchievementCheck{Won: true, Effects: []*structs.Effect{structs.DoublePositivePoints, minus3}}, true},
+		{"survivor", -3, AchievementCheck{Won: false, Effects: []*structs.Effect{minus3}}, false},
+		{"survivor", -3, AchievementCheck{Won: true, Effects: []*structs.Effect{structs.DoublePositivePoints}}, false},
+	}
+	for i, test := range tests {
+		if ok := AchievementRules[test.rule](test.check, test.value); ok != test.ok {
+			t.Errorf("Check %v of the rule %q should be %v, got %v", i, test.rule, test.ok, ok)
+		}
+	}
+}
+
+func Test_CheckAchievements(t *testing.T) {
+	at := time.Date(2024, 3, 31, 23, 34, 59, 0, time.UTC)
+	conf := &config.Config{Settings: config.Settings{Timezone: "Europe/Rome", RevealEffects: true}}
+	conf.Achievements = config.Achievements{
+		{Key: "last_second", Name: "Al pelo", Rule: "last_second", Reward: structs.TrophyBonus.Name},
+		{Key: "survivor", Name: "Sopravvissuto", Rule: "survivor", Value: -3},
+	}
+	utils := testUtils(conf, at)
+	resetState(utils)
+	Users[testAlice.ID] = structs.NewUser(testAlice.ID, testAlice.UserName)
+	sender := &recordingSender{}
+	check := AchievementCheck{Claim: testClaim(-1, testAlice, "23:34", at, 0), Location: time.UTC, Won: true}
+
+	// The achievement is unlocked and announced once, with its reward
+	CheckAchievements(check, utils, types.Data{Bot: sender})
+	CheckAchievements(check, utils, types.Data{Bot: sender})
+	alice := Users[testAlice.ID]
+	if len(alice.Achievements) != 1 || len(sender.sent) != 1 {
+		t.Fatalf("Only the last second achievement should be unlocked and announced once, got %v and %v messages", alice.Achievements, len(sender.sent))
+	}
+	if len(alice.Effects) != 1 || alice.Effects[0] != structs.TrophyBonus {
+		t.Errorf("The reward should be given, got %v", alice.Effects)
+	}
+
+	// The unlock date is in the timezone of the chat
+	statuses, unlocked := UserAchievements(alice, GetSettings(-1, utils), utils)
+	if unlocked != 1 || len(statuses) != 2 || !statuses[0].Unlocked || statuses[0].UnlockedAt != "2024-04-01" || statuses[0].RewardName != structs.TrophyBonus.Name || statuses[1].Unlocked {
+		t.Errorf("Unexpected achievements: %+v", statuses)
+	}
+}
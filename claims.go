@@ -0,0 +1,142 @@
+package main
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/MoraGames/clockyuwu/config"
+	"github.com/MoraGames/clockyuwu/events"
+	"github.com/MoraGames/clockyuwu/pkg/types"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	colonClaim   = regexp.MustCompile(`^\d{2}:\d{2}(:\d{2})?$`)
+	dotClaim     = regexp.MustCompile(`^\d{2}\.\d{2}(\.\d{2})?$`)
+	compactClaim = regexp.MustCompile(`^(\d{4}|\d{6})$`)
+)
+
+// ClaimFormats are the formats of the claims the configuration can accept, by name.
+// Each one matches a claim (with or without the seconds) and returns it in the "15:04" (or "15:04:05") form.
+var ClaimFormats = map[string]func(text string) (string, bool){
+	"colon": func(text string) (string, bool) {
+		return text, colonClaim.MatchString(text)
+	},
+	"dot": func(text string) (string, bool) {
+		return strings.ReplaceAll(text, ".", ":"), dotClaim.MatchString(text)
+	},
+	"compact": func(text string) (string, bool) {
+		if !compactClaim.MatchString(text) {
+			return "", false
+		}
+		if len(text) == 4 {
+			return text[:2] + ":" + text[2:], true
+		}
+		return text[:2] + ":" + text[2:4] + ":" + text[4:], true
+	},
+}
+
+// Normalise the text of a message to the form of the events ("15:04" or "15:04:05"), reporting if it's a claim in one of the accepted formats
+func NormaliseClaim(text string, conf config.Claims) (string, bool) {
+	text = strings.TrimSpace(text)
+	if conf.Emoji {
+		text = strings.TrimSpace(NormaliseEmoji(text))
+	}
+
+	// Without formats in the configuration only the original one is accepted
+	formats := conf.Formats
+	if len(formats) == 0 {
+		formats = []string{"colon"}
+	}
+	for name, format := range ClaimFormats {
+		if !slices.Contains(formats, name) {
+			continue
+		}
+		if claim, ok := format(text); ok {
+			return claim, true
+		}
+	}
+	return "", false
+}
+
+// Replace the full-width and the keycap emoji digits with the plain ones, removing the other emoji
+func NormaliseEmoji(text string) string {
+	var normalised strings.Builder
+	for _, r := range text {
+		switch {
+		case r >= '０' && r <= '９':
+			normalised.WriteRune('0' + r - '０')
+		case r == '：':
+			normalised.WriteRune(':')
+		case r == '．':
+			normalised.WriteRune('.')
+		// The keycaps are a plain digit followed by the variation selector and the combining keycap
+		case r == '\uFE0F' || r == '\u20E3' || r == '\u200D':
+		case unicode.Is(unicode.So, r) || unicode.Is(unicode.Sk, r) || (r >= 0x1F3FB && r <= 0x1F3FF):
+		default:
+			normalised.WriteRune(r)
+		}
+	}
+	return normalised.String()
+}
+
+// Reply to a claim of an event that isn't in play when the claim is sent: the one that just ended ("too late") or that is about to start ("false start").
+// It reports if the claim is a near miss.
+func RejectNearMiss(claim Claim, location *time.Location, layout string, utils types.Utils, data types.Data) bool {
+	if !utils.Config.Claims.NearMisses {
+		return false
+	}
+
+	// The events at exact seconds last a second, the others a minute
+	precision := time.Minute
+	if layout == "15:04:05" {
+		precision = time.Second
+	}
+	start := claim.SentAt.In(location).Truncate(precision)
+
+	key, vars := "", map[string]any{"EventName": claim.Text}
+	switch claim.Text {
+	case start.Add(-precision).Format(layout):
+		key, vars["Late"] = "claim.too_late", claim.ReceivedAt.Sub(start).Seconds()
+	case start.Add(precision).Format(layout):
+		key = "claim.false_start"
+	default:
+		return false
+	}
+	// The chats that have never played have no events to miss
+	ed, ok := events.Events[claim.ChatID]
+	if !ok {
+		return false
+	}
+	if event, ok := ed.Map[claim.Text]; !ok || !event.Enabled {
+		return false
+	}
+
+	SendNearMiss(claim, key, vars, utils, data)
+	return true
+}
+
+// Reply to the claim with the near miss message
+func SendNearMiss(claim Claim, key string, vars map[string]any, utils types.Utils, data types.Data) {
+	// Delete the claim after a while, if enabled
+	Cleanup.TrackClaim(claim.ChatID, claim.MessageID)
+
+	msg := tgbotapi.NewMessage(claim.ChatID, Translate(claim.ChatID, claim.UserID, key, vars, utils))
+	msg.ReplyToMessageID = claim.MessageID
+	if message, err := data.Bot.Send(msg); err != nil {
+		utils.Logger.WithFields(logrus.Fields{
+			"err": err,
+			"msg": message,
+		}).Error("Error while sending message")
+	}
+
+	utils.Logger.WithFields(logrus.Fields{
+		"evnt": claim.Text,
+		"user": claim.UserName,
+		"miss": key,
+	}).Debug("Near miss rejected")
+}
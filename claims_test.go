@@ -0,0 +1,158 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MoraGames/clockyuwu/config"
+	"github.com/MoraGames/clockyuwu/events"
+	"github.com/MoraGames/clockyuwu/pkg/types"
+	"github.com/MoraGames/clockyuwu/structs"
+)
+
+func Test_NormaliseClaim(t *testing.T) {
+	all := config.Claims{Formats: []string{"colon", "dot", "compact"}, Emoji: true}
+	tests := []struct {
+		text  string
+		conf  config.Claims
+		claim string
+		ok    bool
+	}{
+		{"12:34", config.Claims{}, "12:34", true},
+		{" 12:34\n", config.Claims{}, "12:34", true},
+		{"12.34", config.Claims{}, "", false},
+		{"12.34", all, "12:34", true},
+		{"12.34.56", all, "12:34:56", true},
+		{"1234", all, "12:34", true},
+		{"123456", all, "12:34:56", true},
+		{"12345", all, "", false},
+		{"12:34.56", all, "", false},
+		{"１２：３４", all, "12:34", true},
+		{"1️⃣2️⃣:3️⃣4️⃣", all, "12:34", true},
+		{"12:34 ⏰", all, "12:34", true},
+		{"12:34 ⏰", config.Claims{}, "", false},
+		{"１２：３４", config.Claims{Formats: []string{"colon"}}, "", false},
+		{"12:34 ok", all, "", false},
+	}
+	for _, test := range tests {
+		if claim, ok := NormaliseClaim(test.text, test.conf); claim != test.claim || ok != test.ok {
+			t.Errorf("NormaliseClaim(%q, %+v) should be (%q, %v), got (%q, %v)", test.text, test.conf, test.claim, test.ok, claim, ok)
+		}
+	}
+}
+
+func Test_RejectNearMiss(t *testing.T) {
+	utils := testUtils(&config.Config{Settings: config.Settings{Timezone: "UTC"}, Claims: config.Claims{NearMisses: true}}, time.Now())
+	resetState(utils)
+	ed := testEvents(-1, 3, "12:34", "13:31", "11:11:11")
+	ed.Map["13:31"].Enabled = false
+	at := func(hour, minute, second int) time.Time {
+		return time.Date(2024, 3, 31, hour, minute, second, 0, time.UTC)
+	}
+
+	tests := []struct {
+		text   string
+		layout string
+		sentAt time.Time
+		key    string
+		vars   map[string]any
+	}{
+		{"12:34", "15:04", at(12, 33, 59), "claim.false_start", map[string]any{"EventName": "12:34"}},
+		{"12:34", "15:04", at(12, 35, 0), "claim.too_late", map[string]any{"EventName": "12:34", "Late": 1.5}},
+		{"11:11:11", "15:04:05", at(11, 11, 12), "claim.too_late", map[string]any{"EventName": "11:11:11", "Late": 1.5}},
+		// The claims far from the event, or of the disabled ones, aren't near misses
+		{"12:34", "15:04", at(12, 40, 0), "", nil},
+		{"13:31", "15:04", at(13, 32, 0), "", nil},
+	}
+	for _, test := range tests {
+		sender := &recordingSender{}
+		claim := testClaim(-1, testBob, test.text, test.sentAt, 1500*time.Millisecond)
+		if ok := RejectNearMiss(claim, time.UTC, test.layout, utils, types.Data{Bot: sender}); ok != (test.key != "") {
+			t.Errorf("The claim of %v sent at %v should be a near miss %v, got %v", test.text, test.sentAt.Format("15:04:05"), test.key != "", ok)
+			continue
+		}
+		if texts := sender.texts(); test.key != "" && (len(texts) != 1 || texts[0] != Translate(-1, testBob.ID, test.key, test.vars, utils)) {
+			t.Errorf("The near miss of %v sent at %v should be answered with %q, got %q", test.text, test.sentAt.Format("15:04:05"), test.key, texts)
+		}
+	}
+}
+
+func Test_RejectNearMiss_NoEvents(t *testing.T) {
+	utils := types.Utils{Config: &config.Config{Claims: config.Claims{NearMisses: true}}}
+	sent := time.Date(2024, 3, 31, 12, 35, 1, 0, time.UTC)
+
+	// The near miss of a chat that has never played is ignored, without creating its events
+	if RejectNearMiss(Claim{ChatID: -42, Text: "12:34", SentAt: sent, ReceivedAt: sent}, time.UTC, "15:04", utils, types.Data{}) {
+		t.Error("A chat without events should have no near misses")
+	}
+	if _, ok := events.Events[-42]; ok {
+		t.Error("The near miss should not create the events of the chat")
+	}
+}
+
+func Test_ManageClaim_ChatTimezone(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skip("Timezone database not available:", err)
+	}
+	inTempDir(t)
+	at := time.Date(2024, 3, 31, 12, 34, 2, 0, tokyo)
+	utils := testUtils(&config.Config{Settings: config.Settings{Timezone: "UTC"}}, at)
+	resetState(utils)
+	timezone := "Asia/Tokyo"
+	Chats[-1] = &structs.Chat{TelegramID: -1, Settings: structs.ChatSettings{Timezone: &timezone}}
+	event := testEvents(-1, 2, "12:34").Map["12:34"]
+	sender := &recordingSender{}
+
+	// The claim at 12:34 UTC is not on the wall clock of the chat
+	ManageClaim(testClaim(-1, testBob, "12:34", time.Date(2024, 3, 31, 12, 34, 2, 0, time.UTC), time.Second), utils, types.Data{Bot: sender})
+	if event.Activation != nil {
+		t.Fatal("The claim should be matched against the wall clock of the chat")
+	}
+
+	// The claim at 12:34 in Tokyo is, whatever the zone of the bot
+	ManageClaim(testClaim(-1, testAlice, "12:34", at, time.Second), utils, types.Data{Bot: sender})
+	if event.Activation == nil || event.Activation.ActivatedBy.TelegramID != testAlice.ID {
+		t.Fatalf("The event should be activated by alice, got %+v", event.Activation)
+	}
+	if alice := Users[testAlice.ID]; alice.TotalPoints != 2 || alice.TotalEventWins != 1 {
+		t.Errorf("Unexpected alice stats: %+v", alice)
+	}
+	// The delay is counted from the minute of the chat
+	expected := Translate(-1, testAlice.ID, "claim.activated", map[string]any{"EventName": event.DisplayName, "User": testAlice.UserName, "Points": 2, "Effects": "", "Delay": 3.0}, utils)
+	if texts := sender.texts(); len(texts) != 1 || texts[0] != expected {
+		t.Errorf("Only alice should get the activation message %q, got %q", expected, texts)
+	}
+}
+
+func Test_ManageClaim_HardMode(t *testing.T) {
+	inTempDir(t)
+	at := time.Date(2024, 3, 31, 11, 11, 11, 0, time.UTC)
+	conf := &config.Config{Settings: config.Settings{Timezone: "UTC"}}
+	conf.Game.HardMode, conf.Game.HardModeWindow = true, 2*time.Second
+	utils := testUtils(conf, at)
+	resetState(utils)
+	event := testEvents(-1, 5, "11:11:11").Map["11:11:11"]
+	sender := &recordingSender{}
+
+	// Alice claims in the second, Bob is received out of the window
+	ManageClaim(testClaim(-1, testAlice, "11:11:11", at, 300*time.Millisecond), utils, types.Data{Bot: sender})
+	ManageClaim(testClaim(-1, testBob, "11:11:11", at, 3*time.Second), utils, types.Data{Bot: sender})
+	if event.Activation == nil || event.Activation.ActivatedBy.TelegramID != testAlice.ID || Users[testAlice.ID].TotalPoints != 5 {
+		t.Fatalf("Alice should win the event at the exact second, got %+v", event.Activation)
+	}
+	if _, ok := Users[testBob.ID]; ok || len(sender.sent) != 1 {
+		t.Errorf("The claim out of the window should be ignored, got %v messages", len(sender.sent))
+	}
+
+	// With the near misses the claim out of the window is answered
+	utils.Config.Claims.NearMisses = true
+	ManageClaim(testClaim(-1, testBob, "11:11:11", at, 3*time.Second), utils, types.Data{Bot: sender})
+	expected := Translate(-1, testBob.ID, "claim.too_late", map[string]any{"EventName": "11:11:11", "Late": 1.0}, utils)
+	if texts := sender.texts(); len(texts) != 2 || texts[1] != expected {
+		t.Errorf("The claim out of the window should be answered with %q, got %q", expected, texts)
+	}
+	if _, ok := Users[testBob.ID]; ok {
+		t.Error("Bob should not partecipate out of the window")
+	}
+}
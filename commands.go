@@ -4,33 +4,82 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"sort"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/MoraGames/clockyuwu/events"
+	"github.com/MoraGames/clockyuwu/history"
+	"github.com/MoraGames/clockyuwu/pkg/cleanup"
+	"github.com/MoraGames/clockyuwu/pkg/outbox"
 	"github.com/MoraGames/clockyuwu/pkg/types"
+	"github.com/MoraGames/clockyuwu/ranking"
 	"github.com/MoraGames/clockyuwu/structs"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/sirupsen/logrus"
 )
 
-// Rank is the type used for manage /ranking sorting
-type Rank struct {
-	Username       string
-	Points         int
-	Partecipations int
-}
-
 // switch for all the commands that the bot can receive
 func manageCommands(update tgbotapi.Update, utils types.Utils, data types.Data, curTime time.Time, eventKey string) {
 	switch update.Message.Command() {
+	case "achievements":
+		/*
+			Description:
+				Show the achievements, with the ones unlocked by the user who sent the command or by the user specified in the command arguments.
+
+			Forms:
+				/achievements
+				/achievements [user]
+		*/
+		// Split the command arguments
+		cmdArgs := strings.Fields(update.Message.CommandArguments())
+		if len(cmdArgs) > 1 {
+			// Respond with a message indicating that the command arguments are wrong
+			cmdSyntax := "/achievements [user]"
+			SendWrongCommandSyntaxMessage(cmdSyntax, update, data, utils)
+			// Log the command failed execution
+			FinalCommandLog("Wrong command syntax", update, utils)
+			break
+		}
+		if len(utils.Config.Achievements) == 0 {
+			SendMessage(tgbotapi.NewMessage(update.Message.Chat.ID, TranslateUpdate(update, "achievements.none", nil, utils)), update, data, utils)
+			// Log the command failed execution
+			FinalCommandLog("No achievements configured", update, utils)
+			break
+		}
+		// Get and check if the user exists (the users who have never participated have no achievements)
+		u, username := Users[update.Message.From.ID], update.Message.From.UserName
+		if len(cmdArgs) == 1 {
+			username, u = cmdArgs[0], nil
+			for _, user := range Users {
+				if user.UserName == username {
+					u = user
+				}
+			}
+			if u == nil {
+				// Respond with a message indicating that the user does not exist
+				SendEntityNotFoundMessage("entity.user", username, update, data, utils)
+				// Log the command failed execution
+				FinalCommandLog("User not found", update, utils)
+				break
+			}
+		}
+		if u == nil {
+			u = structs.NewUser(update.Message.From.ID, username)
+		}
+		// Send the message with the achievements of the user
+		statuses, unlockedNum := UserAchievements(u, GetSettings(update.Message.Chat.ID, utils), utils)
+		text := TranslateUpdate(update, "achievements", map[string]any{"UserName": username, "Achievements": statuses, "Unlocked": unlockedNum}, utils)
+		SendMessage(tgbotapi.NewMessage(update.Message.Chat.ID, text), update, data, utils)
+		// Log the command executed successfully
+		FinalCommandLog("User achievements sent", update, utils)
+		SuccessResponseLog(update, utils)
 	case "check":
 		// Check actual event infos
 		if !isAdmin(update.Message.From, utils) {
 			// Respond and log with a message indicating that the user is not authorized to use this command
-			msg := tgbotapi.NewMessage(update.Message.Chat.ID, "Non sei autorizzato ad usare questo comando")
+			msg := tgbotapi.NewMessage(update.Message.Chat.ID, TranslateUpdate(update, "unauthorized", nil, utils))
 			msg.ReplyToMessageID = update.Message.MessageID
 			message, error := data.Bot.Send(msg)
 			if error != nil {
@@ -49,7 +98,7 @@ func manageCommands(update tgbotapi.Update, utils types.Utils, data types.Data,
 
 			if len(cmdArgs) != 1 {
 				// Respond with a message indicating that the command arguments are wrong
-				msg := tgbotapi.NewMessage(update.Message.Chat.ID, "Il comando è /check <events|users|logs>")
+				msg := tgbotapi.NewMessage(update.Message.Chat.ID, TranslateUpdate(update, "wrong_syntax", map[string]any{"Syntax": "/check <events|users|logs|outbox>"}, utils))
 				msg.ReplyToMessageID = update.Message.MessageID
 				message, error := data.Bot.Send(msg)
 				if error != nil {
@@ -76,7 +125,7 @@ func manageCommands(update tgbotapi.Update, utils types.Utils, data types.Data,
 
 					// Respond with command executed successfully
 					msg := tgbotapi.NewDocument(update.Message.Chat.ID, tgbotapi.FileBytes{Name: "log.txt", Bytes: logTxt})
-					msg.Caption = "Log controllati. Ecco lo stato attuale:\n\n"
+					msg.Caption = TranslateUpdate(update, "check.logs", nil, utils)
 					msg.ReplyToMessageID = update.Message.MessageID
 					message, error := data.Bot.Send(msg)
 					if error != nil {
@@ -99,7 +148,7 @@ func manageCommands(update tgbotapi.Update, utils types.Utils, data types.Data,
 
 					// Respond with command executed successfully
 					msg := tgbotapi.NewDocument(update.Message.Chat.ID, tgbotapi.FileBytes{Name: "users.json", Bytes: usersJson})
-					msg.Caption = "Log controllati. Ecco lo stato attuale:\n\n"
+					msg.Caption = TranslateUpdate(update, "check.users", nil, utils)
 					msg.ReplyToMessageID = update.Message.MessageID
 					message, error := data.Bot.Send(msg)
 					if error != nil {
@@ -124,7 +173,7 @@ func manageCommands(update tgbotapi.Update, utils types.Utils, data types.Data,
 
 					// Respond with command executed successfully
 					msg := tgbotapi.NewDocument(update.Message.Chat.ID, tgbotapi.FileBytes{Name: "events.json", Bytes: eventsJson})
-					msg.Caption = "Eventi controllati. Ecco lo stato attuale:\n\n"
+					msg.Caption = TranslateUpdate(update, "check.events", nil, utils)
 					msg.ReplyToMessageID = update.Message.MessageID
 					message, error := data.Bot.Send(msg)
 					if error != nil {
@@ -136,9 +185,41 @@ func manageCommands(update tgbotapi.Update, utils types.Utils, data types.Data,
 
 					// Log the /check command sent
 					utils.Logger.Debug("Events checked")
+				case "outbox":
+					// Check the metrics of the messages sent
+					text := TranslateUpdate(update, "check.outbox_disabled", nil, utils)
+					if ob, ok := cleanup.Persistent(data.Bot).(*outbox.Outbox); ok {
+						metrics := ob.Metrics()
+						averageDelay := time.Duration(0)
+						if metrics.Delayed != 0 {
+							averageDelay = metrics.TotalDelay / time.Duration(metrics.Delayed)
+						}
+						text = TranslateUpdate(update, "check.outbox", map[string]any{
+							"Sent":         metrics.Sent,
+							"Delayed":      metrics.Delayed,
+							"AverageDelay": averageDelay.Round(time.Millisecond),
+							"MaxDelay":     metrics.MaxDelay.Round(time.Millisecond),
+							"Retried":      metrics.Retried,
+							"Dropped":      metrics.Dropped,
+						}, utils)
+					}
+
+					// Respond with command executed successfully
+					msg := tgbotapi.NewMessage(update.Message.Chat.ID, text)
+					msg.ReplyToMessageID = update.Message.MessageID
+					message, error := data.Bot.Send(msg)
+					if error != nil {
+						utils.Logger.WithFields(logrus.Fields{
+							"err": error,
+							"msg": message,
+						}).Error("Error while sending message")
+					}
+
+					// Log the /check command sent
+					utils.Logger.Debug("Outbox checked")
 				default:
 					// Respond with a message indicating that the command arguments are wrong
-					msg := tgbotapi.NewMessage(update.Message.Chat.ID, "Il comando è /check <events|users|logs>")
+					msg := tgbotapi.NewMessage(update.Message.Chat.ID, TranslateUpdate(update, "wrong_syntax", map[string]any{"Syntax": "/check <events|users|logs|outbox>"}, utils))
 					msg.ReplyToMessageID = update.Message.MessageID
 					message, error := data.Bot.Send(msg)
 					if error != nil {
@@ -156,7 +237,7 @@ func manageCommands(update tgbotapi.Update, utils types.Utils, data types.Data,
 		}
 	case "credits":
 		// Respond with useful information about the project
-		msg := tgbotapi.NewMessage(update.Message.Chat.ID, "The source code, available on GitHub at MoraGames/clockyuwu, is written entirely in GoLang and makes use of the \"telegram-bot-api\" library.\nFor any bug reports or feature proposals, please refer to the GitHub project.\n\nDeveloper:\n- Telegram: @MoraGames\n- Discord: @moragames\n- Instagram: @moragames.dev\n- GitHub: MoraGames\n\nProject:\n- Telegram: @clockyuwu_bot\n- GitHub: MoraGames/clockyuwu\n\nSpecial thanks go to the first testers (as well as players) of the minigame managed by the bot, \"Vano\", \"Ale\" and \"Alex\".")
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, TranslateUpdate(update, "credits", nil, utils))
 		msg.ReplyToMessageID = update.Message.MessageID
 		message, error := data.Bot.Send(msg)
 		if error != nil {
@@ -171,9 +252,152 @@ func manageCommands(update tgbotapi.Update, utils types.Utils, data types.Data,
 			"sender":  update.Message.From.UserName,
 			"chat":    update.Message.Chat.Title,
 		}).Debug("Response to \"/credits\" command sent successfully")
+	case "day":
+		/*
+			Description:
+				Show the recap and the activated events of an archived day of the chat.
+
+			Forms:
+				/day <date>
+		*/
+		// Split the command arguments
+		cmdArgs := strings.Split(update.Message.CommandArguments(), " ")
+		if len(cmdArgs) != 1 || cmdArgs[0] == "" {
+			// Respond with a message indicating that the command arguments are wrong
+			cmdSyntax := "/day <yyyy-mm-dd>"
+			SendWrongCommandSyntaxMessage(cmdSyntax, update, data, utils)
+			// Log the command failed execution
+			FinalCommandLog("Wrong command syntax", update, utils)
+		} else if _, err := time.Parse(history.DateFormat, cmdArgs[0]); err != nil {
+			// Respond with a message indicating that the date is not valid
+			SendParameterNotValidMessage("date", "expected.date", update, data, utils)
+			// Log the command failed execution
+			FinalCommandLog("Date not valid", update, utils)
+		} else {
+			SendDayMessage(cmdArgs[0], update, data, utils)
+		}
+	case "event":
+		/*
+			Description:
+				List the custom events of the chat, or add and remove them (only the bot-admins).
+				The custom events can be at any minute, with a name, fixed points, effects and a recurrence ("daily", a day of the year or a date).
+				The changes are applied from the next reset of the events.
+
+			Forms:
+				/event list
+				/event add <hh:mm> <points> <"daily"|mm-dd|yyyy-mm-dd> <name> [<effects>]
+				/event remove <hh:mm> [<"daily"|mm-dd|yyyy-mm-dd>]
+		*/
+		cmdSyntax := "/event <\"list\"|\"add\"|\"remove\"> [<hh:mm> <points> <\"daily\"|mm-dd|yyyy-mm-dd> <name> [<effects>]]"
+		// Split the command arguments
+		cmdArgs := strings.Fields(update.Message.CommandArguments())
+		if len(cmdArgs) == 0 {
+			// Respond with a message indicating that the command arguments are wrong
+			SendWrongCommandSyntaxMessage(cmdSyntax, update, data, utils)
+			// Log the command failed execution
+			FinalCommandLog("Wrong command syntax", update, utils)
+			break
+		}
+		if cmdArgs[0] == "list" && len(cmdArgs) == 1 {
+			// Respond with the list of the custom events of the chat
+			text := TranslateUpdate(update, "event.list.empty", nil, utils)
+			if custom := CustomEvents[update.Message.Chat.ID]; len(custom) != 0 {
+				text = TranslateUpdate(update, "event.list", map[string]any{"Events": custom}, utils)
+			}
+			SendMessage(tgbotapi.NewMessage(update.Message.Chat.ID, text), update, data, utils)
+			// Log the command executed successfully
+			FinalCommandLog("CustomEvents sent", update, utils)
+			SuccessResponseLog(update, utils)
+			break
+		}
+		// Check if the user is an bot-admin
+		if !isAdmin(update.Message.From, utils) {
+			// Respond and log with a message indicating that the user is not authorized to use this command
+			SendUserNotAuthorizedMessage(update, data, utils)
+			// Log the command failed execution
+			FinalCommandLog("Unauthorized user", update, utils)
+			break
+		}
+		switch {
+		case cmdArgs[0] == "add" && (len(cmdArgs) == 5 || len(cmdArgs) == 6):
+			// Get and check the time, the points and the recurrence of the custom event
+			if _, err := time.Parse("15:04", cmdArgs[1]); err != nil || len(cmdArgs[1]) != 5 {
+				SendParameterNotValidMessage("hh:mm", "expected.time", update, data, utils)
+				FinalCommandLog("Time not valid", update, utils)
+				break
+			}
+			points, err := strconv.Atoi(cmdArgs[2])
+			if err != nil {
+				SendParameterNotValidMessage("points", "expected.integer", update, data, utils)
+				FinalCommandLog("Wrong command syntax", update, utils)
+				break
+			}
+			if !events.ValidRecurrence(cmdArgs[3]) {
+				SendParameterNotValidMessage("recurrence", "expected.recurrence", update, data, utils)
+				FinalCommandLog("Recurrence not valid", update, utils)
+				break
+			}
+			// Get and check if the effects value is a slice of existing effects
+			effects := make([]*structs.Effect, 0)
+			if len(cmdArgs) == 6 {
+				effectsNames, err := types.ParseSlice(cmdArgs[5])
+				if err != nil {
+					SendParameterNotValidMessage("effects", "expected.effects", update, data, utils)
+					FinalCommandLog("Wrong command syntax", update, utils)
+					break
+				}
+				for _, effectName := range effectsNames {
+					if _, ok := structs.Effects[effectName]; !ok {
+						effects = nil
+						break
+					}
+					effects = append(effects, structs.Effects[effectName])
+				}
+				if effects == nil {
+					SendParameterNotValidMessage("effects", "expected.effects", update, data, utils)
+					FinalCommandLog("Effect not found", update, utils)
+					break
+				}
+			}
+			// The underscores of the name are spaces (as in the names of the effects)
+			ce := &events.CustomEvent{Time: cmdArgs[1], Name: strings.ReplaceAll(cmdArgs[4], "_", " "), Points: points, Effects: effects, Recurrence: cmdArgs[3]}
+			AddCustomEvent(update.Message.Chat.ID, ce, utils)
+			// Respond with command executed successfully
+			SendMessage(tgbotapi.NewMessage(update.Message.Chat.ID, TranslateUpdate(update, "event.added", ce, utils)), update, data, utils)
+			// Log the command executed successfully
+			FinalCommandLog("CustomEvent added", update, utils)
+			SuccessResponseLog(update, utils)
+		case cmdArgs[0] == "remove" && (len(cmdArgs) == 2 || len(cmdArgs) == 3):
+			recurrence := ""
+			if len(cmdArgs) == 3 {
+				recurrence = cmdArgs[2]
+			}
+			// Check if there are custom events to remove
+			custom := RemoveCustomEvents(update.Message.Chat.ID, cmdArgs[1], recurrence)
+			removed := len(CustomEvents[update.Message.Chat.ID]) - len(custom)
+			if removed == 0 {
+				// Respond with a message indicating that the custom event does not exist
+				SendEntityNotFoundMessage("entity.custom_event", strings.Join(cmdArgs[1:], " "), update, data, utils)
+				// Log the command failed execution
+				FinalCommandLog("CustomEvent not found", update, utils)
+				break
+			}
+			CustomEvents[update.Message.Chat.ID] = custom
+			SaveCustomEvents(utils)
+			// Respond with command executed successfully
+			SendMessage(tgbotapi.NewMessage(update.Message.Chat.ID, TranslateUpdate(update, "event.removed", map[string]any{"Count": removed, "Time": cmdArgs[1]}, utils)), update, data, utils)
+			// Log the command executed successfully
+			FinalCommandLog("CustomEvents removed", update, utils)
+			SuccessResponseLog(update, utils)
+		default:
+			// Respond with a message indicating that the command arguments are wrong
+			SendWrongCommandSyntaxMessage(cmdSyntax, update, data, utils)
+			// Log the command failed execution
+			FinalCommandLog("Wrong command syntax", update, utils)
+		}
 	case "help":
 		// Respond with useful information about the working and commands of the bot
-		msg := tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("Name: %v\nVersion: %v\n\nThis is a list of all possible commands within the bot:\n\n- /start : Get an introductory message about the bot's features.\n - /help : Get a complete list of all available commands.\n - /ranking : Get the ranking of the current championship.\n - /stats : Get the player's game statistics.\n - /ping : Verify if the bot is running.\n - /credits : Get more informations abount the project.\n\nAdmin's Only:\n - /check : Get more informations about bot status and data.\n - /reset : Force the execution of a specific Reset() function.\n -/update : Update the value of a data structure.", utils.Config.App.Name, utils.Config.App.Version))
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, TranslateUpdate(update, "help", map[string]any{"Name": utils.Config.App.Name, "Version": utils.Config.App.Version}, utils))
 		msg.ReplyToMessageID = update.Message.MessageID
 		message, error := data.Bot.Send(msg)
 		if error != nil {
@@ -188,6 +412,150 @@ func manageCommands(update tgbotapi.Update, utils types.Utils, data types.Data,
 			"sender":  update.Message.From.UserName,
 			"chat":    update.Message.Chat.Title,
 		}).Debug("Response to \"/help\" command sent successfully")
+	case "history":
+		/*
+			Description:
+				Show the last claims (10 by default) of the user who sent the command or of the user specified in the command arguments.
+
+			Forms:
+				/history
+				/history <n>
+				/history <user>
+				/history <user> <n>
+		*/
+		cmdSyntax := "/history [user] [n]"
+		// Split the command arguments
+		cmdArgs := strings.Fields(update.Message.CommandArguments())
+		userID, username, n := update.Message.From.ID, update.Message.From.UserName, 10
+		if len(cmdArgs) > 2 {
+			// Respond with a message indicating that the command arguments are wrong
+			SendWrongCommandSyntaxMessage(cmdSyntax, update, data, utils)
+			// Log the command failed execution
+			FinalCommandLog("Wrong command syntax", update, utils)
+			break
+		}
+		// The last argument is the number of claims, if it's a number
+		if len(cmdArgs) != 0 {
+			if num, err := strconv.Atoi(cmdArgs[len(cmdArgs)-1]); err == nil {
+				if num <= 0 {
+					// Respond with a message indicating that the number is not valid
+					SendParameterNotValidMessage("n", "expected.positive_integer", update, data, utils)
+					// Log the command failed execution
+					FinalCommandLog("Number not valid", update, utils)
+					break
+				}
+				n, cmdArgs = num, cmdArgs[:len(cmdArgs)-1]
+			}
+		}
+		if len(cmdArgs) == 2 {
+			// Respond with a message indicating that the command arguments are wrong
+			SendWrongCommandSyntaxMessage(cmdSyntax, update, data, utils)
+			// Log the command failed execution
+			FinalCommandLog("Wrong command syntax", update, utils)
+			break
+		}
+		// Get and check if the user exists
+		if len(cmdArgs) == 1 {
+			username = cmdArgs[0]
+			founded := false
+			for id, user := range Users {
+				if user.UserName == username {
+					founded, userID = true, id
+				}
+			}
+			if !founded {
+				// Respond with a message indicating that the user does not exist
+				SendEntityNotFoundMessage("entity.user", username, update, data, utils)
+				// Log the command failed execution
+				FinalCommandLog("User not found", update, utils)
+				break
+			}
+		}
+
+		// Send the message with the last claims of the user
+		claims := make([]history.Claim, 0)
+		if Timeline != nil {
+			recent, err := Timeline.Recent(userID, n)
+			if err != nil {
+				utils.Logger.WithFields(logrus.Fields{
+					"err":  err,
+					"user": userID,
+				}).Error("Error while reading the timeline")
+			}
+			claims = append(claims, recent...)
+		}
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, TranslateUpdate(update, "history.empty", map[string]any{"UserName": username}, utils))
+		if len(claims) != 0 {
+			msg = tgbotapi.NewMessage(update.Message.Chat.ID, TranslateUpdate(update, "history.claims", map[string]any{
+				"UserName":      username,
+				"Claims":        claims,
+				"RevealEffects": GetSettings(update.Message.Chat.ID, utils).RevealEffects,
+			}, utils))
+		}
+		SendMessage(msg, update, data, utils)
+		// Log the command executed successfully
+		FinalCommandLog("User timeline sent", update, utils)
+		SuccessResponseLog(update, utils)
+	case "language":
+		/*
+			Description:
+				Show or change the language of the bot for the user (everywhere) or for the chat.
+				The language of the chat can be changed by the bot-admin, or by the user in a private chat.
+
+			Forms:
+				/language
+				/language <language>
+				/language chat <language>
+		*/
+		languages := strings.Join(utils.Catalog.Languages(), "|")
+		cmdSyntax := fmt.Sprintf("/language [chat] <%v>", languages)
+		cmdArgs := strings.Fields(update.Message.CommandArguments())
+		switch {
+		case len(cmdArgs) == 0:
+			// Respond with the current languages
+			chatLanguage := GetSettings(update.Message.Chat.ID, utils).Language
+			msg := tgbotapi.NewMessage(update.Message.Chat.ID, TranslateUpdate(update, "language.current", map[string]any{"Language": Language(update.Message.Chat.ID, update.Message.From.ID, utils), "ChatLanguage": chatLanguage, "Languages": languages}, utils))
+			SendMessage(msg, update, data, utils)
+			// Log the command executed successfully
+			FinalCommandLog("Languages sent", update, utils)
+			SuccessResponseLog(update, utils)
+		case len(cmdArgs) == 1 && utils.Catalog.Has(cmdArgs[0]):
+			// Update the language of the user (saved as the one of their private chat)
+			if err := SetSetting(update.Message.From.ID, update.Message.From.UserName, "language", cmdArgs[0], utils); err != nil {
+				utils.Logger.WithFields(logrus.Fields{
+					"err": err,
+				}).Error("Error while updating user language")
+			}
+			msg := tgbotapi.NewMessage(update.Message.Chat.ID, TranslateUpdate(update, "language.updated", map[string]any{"Language": cmdArgs[0]}, utils))
+			SendMessage(msg, update, data, utils)
+			// Log the command executed successfully
+			FinalCommandLog("User language updated", update, utils)
+			SuccessResponseLog(update, utils)
+		case len(cmdArgs) == 2 && cmdArgs[0] == "chat" && utils.Catalog.Has(cmdArgs[1]):
+			// Check if the user can change the language of the chat
+			if !isChatModerator(update.Message.Chat, update.Message.From, utils, data) {
+				SendUserNotAuthorizedMessage(update, data, utils)
+				// Log the command failed execution
+				FinalCommandLog("Unauthorized user", update, utils)
+				break
+			}
+			// Update the language of the chat
+			if err := SetSetting(update.Message.Chat.ID, update.Message.Chat.Title, "language", cmdArgs[1], utils); err != nil {
+				utils.Logger.WithFields(logrus.Fields{
+					"err": err,
+				}).Error("Error while updating chat language")
+			}
+			msg := tgbotapi.NewMessage(update.Message.Chat.ID, Translate(update.Message.Chat.ID, 0, "language.chat_updated", map[string]any{"Language": cmdArgs[1]}, utils))
+			SendMessage(msg, update, data, utils)
+			// Log the command executed successfully
+			FinalCommandLog("Chat language updated", update, utils)
+			SuccessResponseLog(update, utils)
+		default:
+			// Respond with a message indicating that the command arguments are wrong
+			SendWrongCommandSyntaxMessage(cmdSyntax, update, data, utils)
+			// Log the command failed execution
+			FinalCommandLog("Wrong command syntax", update, utils)
+		}
 	case "list":
 		// Respond with the list of all enabled sets
 		/*
@@ -197,13 +565,14 @@ func manageCommands(update tgbotapi.Update, utils types.Utils, data types.Data,
 			Forms:
 				/list sets
 				/list effects
+				/list jackpot
 		*/
 		// Split the command arguments
 		cmdArgs := strings.Split(update.Message.CommandArguments(), " ")
 		// Check if the command arguments are in one of the above forms
 		if len(cmdArgs) != 1 {
 			// Respond with a message indicating that the command arguments are wrong
-			cmdSyntax := "/list <\"sets\"|\"effects\">"
+			cmdSyntax := "/list <\"sets\"|\"effects\"|\"jackpot\">"
 			SendWrongCommandSyntaxMessage(cmdSyntax, update, data, utils)
 			// Log the command failed execution
 			FinalCommandLog("Wrong command syntax", update, utils)
@@ -212,27 +581,37 @@ func manageCommands(update tgbotapi.Update, utils types.Utils, data types.Data,
 			switch cmdArgs[0] {
 			case "sets":
 				// Respond with the list of all enabled sets
-				text := fmt.Sprintf("\nSchemi Attivi (%v):\n", events.Events.Stats.EnabledSetsNum)
-				for _, setName := range events.Events.Stats.EnabledSets {
-					text += fmt.Sprintf(" | %q\n", setName)
-				}
+				// The sets that look at the calendar are shown with their minutes of today
+				today := utils.Clock.Now().In(GetSettings(update.Message.Chat.ID, utils).Location())
+				text := TranslateUpdate(update, "list.sets", map[string]any{"Count": ChatEvents(update.Message.Chat.ID, utils).Stats.EnabledSetsNum, "Sets": ChatEvents(update.Message.Chat.ID, utils).Stats.EnabledSets, "Today": ChatEvents(update.Message.Chat.ID, utils).DateSets(today)}, utils)
 				SendMessage(tgbotapi.NewMessage(update.Message.Chat.ID, text), update, data, utils)
 				// Log the command executed successfully
 				FinalCommandLog("Events.Stats.EnabledSets sent", update, utils)
 				SuccessResponseLog(update, utils)
 			case "effects":
-				// Respond with the list of all enabled sets
-				text := fmt.Sprintf("\nEffetti Attivi (%v):\n", events.Events.Stats.EnabledEffectsNum)
-				for effectName, effectNum := range events.Events.Stats.EnabledEffects {
-					text += fmt.Sprintf(" | %q = %v\n", effectName, effectNum)
+				// Check if the effects are revealed in the chat
+				if !GetSettings(update.Message.Chat.ID, utils).RevealEffects {
+					SendMessage(tgbotapi.NewMessage(update.Message.Chat.ID, TranslateUpdate(update, "settings.effects_hidden", nil, utils)), update, data, utils)
+					// Log the command failed execution
+					FinalCommandLog("Effects hidden in the chat", update, utils)
+					break
 				}
+				// Respond with the list of all enabled sets
+				text := TranslateUpdate(update, "list.effects", map[string]any{"Count": ChatEvents(update.Message.Chat.ID, utils).Stats.EnabledEffectsNum, "Effects": ChatEvents(update.Message.Chat.ID, utils).Stats.EnabledEffects, "Timing": utils.Config.TimingEffects}, utils)
 				SendMessage(tgbotapi.NewMessage(update.Message.Chat.ID, text), update, data, utils)
 				// Log the command executed successfully
 				FinalCommandLog("Events.Stats.EnabledEffects sent", update, utils)
 				SuccessResponseLog(update, utils)
+			case "jackpot":
+				// Respond with the points in the jackpot of the chat
+				text := TranslateUpdate(update, "list.jackpot", map[string]any{"Enabled": utils.Config.Game.Jackpot, "Jackpot": ChatEvents(update.Message.Chat.ID, utils).Jackpot, "Minute": utils.Config.Game.JackpotMinute}, utils)
+				SendMessage(tgbotapi.NewMessage(update.Message.Chat.ID, text), update, data, utils)
+				// Log the command executed successfully
+				FinalCommandLog("Events.Jackpot sent", update, utils)
+				SuccessResponseLog(update, utils)
 			default:
 				// Respond with a message indicating that the command arguments are wrong
-				cmdSyntax := "/list <\"sets\"|\"effects\">"
+				cmdSyntax := "/list <\"sets\"|\"effects\"|\"jackpot\">"
 				SendWrongCommandSyntaxMessage(cmdSyntax, update, data, utils)
 				// Log the command failed execution
 				FinalCommandLog("Wrong command syntax", update, utils)
@@ -240,7 +619,7 @@ func manageCommands(update tgbotapi.Update, utils types.Utils, data types.Data,
 		}
 	case "ping":
 		// Respond with a "pong" message. Useful for checking if the bot is online
-		msg := tgbotapi.NewMessage(update.Message.Chat.ID, "pong")
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, TranslateUpdate(update, "ping", nil, utils))
 		msg.ReplyToMessageID = update.Message.MessageID
 		message, error := data.Bot.Send(msg)
 		if error != nil {
@@ -256,55 +635,68 @@ func manageCommands(update tgbotapi.Update, utils types.Utils, data types.Data,
 			"chat":    update.Message.Chat.Title,
 		}).Debug("Response to \"/ping\" command sent successfully")
 	case "ranking":
-		// Respond with the ranking based on users' points
-		// Generate the ranking
-		ranking := make([]Rank, 0)
-		for _, u := range Users {
-			if u != nil {
-				ranking = append(ranking, Rank{u.UserName, u.TotalPoints, u.TotalEventPartecipations})
-			}
-		}
-
-		// Sort the ranking by points (and partecipations if points are equal)
-		sort.Slice(
-			ranking,
-			func(i, j int) bool {
-				if ranking[i].Points == ranking[j].Points {
-					return ranking[i].Partecipations < ranking[j].Partecipations
-				}
-				return ranking[i].Points > ranking[j].Points
-			},
-		)
-
-		// Generate the string to send
-		msg := tgbotapi.NewMessage(update.Message.Chat.ID, "Ancora nessun utente ha partecipato agli eventi della season.")
-		if len(ranking) != 0 {
-			leadersPoints := ranking[0].Points
-			rankingString := ""
-			for i, r := range ranking {
-				rankingString += fmt.Sprintf("%v] %v: %v (-%v)\n", i+1, r.Username, r.Points, leadersPoints-r.Points)
-			}
+		/*
+			Description:
+				Respond with the ranking of the players by a metric (points by default) in a period (the season by default).
 
-			// Send the message
-			msg = tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("La classifica è la seguente:\n\n%v", rankingString))
+			Forms:
+				/ranking [metric] [period]
+		*/
+		cmdSyntax := "/ranking [<\"points\"|\"wins\"|\"partecipations\"|\"winrate\"|\"avgdelay\">] [<\"day\"|\"week\"|\"month\"|\"season\"|\"all\">]"
+		// Split the command arguments
+		cmdArgs := strings.Fields(update.Message.CommandArguments())
+		metric, period := ranking.Metrics[0], Periods[0]
+		if len(cmdArgs) > 2 {
+			// Respond with a message indicating that the command arguments are wrong
+			SendWrongCommandSyntaxMessage(cmdSyntax, update, data, utils)
+			// Log the command failed execution
+			FinalCommandLog("Wrong command syntax", update, utils)
+			break
 		}
-		msg.ReplyToMessageID = update.Message.MessageID
-		message, error := data.Bot.Send(msg)
-		if error != nil {
-			utils.Logger.WithFields(logrus.Fields{
-				"err": error,
-				"msg": message,
-			}).Error("Error while sending message")
+		// The arguments can be a metric, a period or both (in this order)
+		if len(cmdArgs) != 0 && slices.Contains(ranking.Metrics, ranking.Metric(cmdArgs[0])) {
+			metric, cmdArgs = ranking.Metric(cmdArgs[0]), cmdArgs[1:]
+		}
+		if len(cmdArgs) != 0 && slices.Contains(Periods, cmdArgs[0]) {
+			period, cmdArgs = cmdArgs[0], cmdArgs[1:]
+		}
+		if len(cmdArgs) != 0 {
+			// Respond with a message indicating that the command arguments are wrong
+			SendWrongCommandSyntaxMessage(cmdSyntax, update, data, utils)
+			// Log the command failed execution
+			FinalCommandLog("Wrong command syntax", update, utils)
+			break
 		}
 
-		// Log the /ranking command sent
-		utils.Logger.Debug("Ranking sent")
+		// Generate the ranking and the string to send
+		board := RankingBoard(metric, period, GetSettings(update.Message.Chat.ID, utils).Location(), utils)
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, TranslateUpdate(update, "ranking.empty", nil, utils))
+		if len(board) != 0 {
+			rows := make([]map[string]any, 0, len(board))
+			for _, row := range board {
+				rows = append(rows, map[string]any{
+					"Position": row.Position,
+					"Username": row.Entry.UserName,
+					"Value":    RankingValueText(metric, row.Value, false),
+					"Gap":      RankingValueText(metric, row.Gap, true),
+				})
+			}
+			msg = tgbotapi.NewMessage(update.Message.Chat.ID, TranslateUpdate(update, "ranking", map[string]any{
+				"Metric":  TranslateUpdate(update, "ranking.metric."+string(metric), nil, utils),
+				"Period":  TranslateUpdate(update, "ranking.period."+period, nil, utils),
+				"Ranking": rows,
+			}, utils))
+		}
+		SendMessage(msg, update, data, utils)
+		// Log the command executed successfully
+		FinalCommandLog("Ranking sent", update, utils)
+		SuccessResponseLog(update, utils)
 	case "reset":
 		// Reset the events or users data structure
 		// Check if the user is an bot-admin
 		if !isAdmin(update.Message.From, utils) {
 			// Respond and log with a message indicating that the user is not authorized to use this command
-			msg := tgbotapi.NewMessage(update.Message.Chat.ID, "Non sei autorizzato ad usare questo comando")
+			msg := tgbotapi.NewMessage(update.Message.Chat.ID, TranslateUpdate(update, "unauthorized", nil, utils))
 			msg.ReplyToMessageID = update.Message.MessageID
 			message, error := data.Bot.Send(msg)
 			if error != nil {
@@ -324,7 +716,7 @@ func manageCommands(update tgbotapi.Update, utils types.Utils, data types.Data,
 			// Check if the command arguments are in the form /reset <events|users>
 			if len(cmdArgs) != 1 {
 				// Respond with a message indicating that the command arguments are wrong
-				msg := tgbotapi.NewMessage(update.Message.Chat.ID, "Il comando è /reset <events|users>")
+				msg := tgbotapi.NewMessage(update.Message.Chat.ID, TranslateUpdate(update, "wrong_syntax", map[string]any{"Syntax": "/reset <events|users>"}, utils))
 				msg.ReplyToMessageID = update.Message.MessageID
 				message, error := data.Bot.Send(msg)
 				if error != nil {
@@ -341,15 +733,17 @@ func manageCommands(update tgbotapi.Update, utils types.Utils, data types.Data,
 				// Check if the command argument is events or users
 				switch cmdArgs[0] {
 				case "events":
-					// Reset the events data structure
-					events.Events.Reset(
-						true,
-						&types.WriteMessageData{Bot: data.Bot, ChatID: update.Message.Chat.ID, ReplyMessageID: update.Message.MessageID},
+					// Reset the events data structure (the recap is kept by the cleanup)
+					settings := GetSettings(update.Message.Chat.ID, utils)
+					ResetChatEvents(
+						update.Message.Chat.ID,
+						settings,
+						&types.WriteMessageData{Bot: cleanup.Persistent(data.Bot), ChatID: update.Message.Chat.ID, ReplyMessageID: update.Message.MessageID, Settings: settings},
 						utils,
 					)
 
 					// Respond with command executed successfully
-					msg := tgbotapi.NewMessage(update.Message.Chat.ID, "Eventi resettati")
+					msg := tgbotapi.NewMessage(update.Message.Chat.ID, TranslateUpdate(update, "reset.events", nil, utils))
 					msg.ReplyToMessageID = update.Message.MessageID
 					message, error := data.Bot.Send(msg)
 					if error != nil {
@@ -364,27 +758,13 @@ func manageCommands(update tgbotapi.Update, utils types.Utils, data types.Data,
 				case "users":
 					// Reset the users data structure
 					Users = make(map[int64]*structs.User)
+					RebuildPointsIndex(utils)
 
 					// Overwrite the files/users.json file with the new (and empty) data structure
-					file, err := json.MarshalIndent(Users, "", " ")
-					if err != nil {
-						utils.Logger.WithFields(logrus.Fields{
-							"err":  err,
-							"note": "preoccupati",
-						}).Error("Error while marshalling data")
-						utils.Logger.Error(Users)
-					}
-					err = os.WriteFile("files/users.json", file, 0644)
-					if err != nil {
-						utils.Logger.WithFields(logrus.Fields{
-							"err":  err,
-							"note": "preoccupati tanto",
-						}).Error("Error while writing data")
-						utils.Logger.Error(Users)
-					}
+					SaveUsers(utils)
 
 					// Respond with command executed successfully
-					msg := tgbotapi.NewMessage(update.Message.Chat.ID, "Utenti resettati")
+					msg := tgbotapi.NewMessage(update.Message.Chat.ID, TranslateUpdate(update, "reset.users", nil, utils))
 					msg.ReplyToMessageID = update.Message.MessageID
 					message, error := data.Bot.Send(msg)
 					if error != nil {
@@ -398,7 +778,7 @@ func manageCommands(update tgbotapi.Update, utils types.Utils, data types.Data,
 					utils.Logger.Debug("Users resetted")
 				default:
 					// Respond with a message indicating that the command arguments are wrong
-					msg := tgbotapi.NewMessage(update.Message.Chat.ID, "Il comando è /reset <events|users>")
+					msg := tgbotapi.NewMessage(update.Message.Chat.ID, TranslateUpdate(update, "wrong_syntax", map[string]any{"Syntax": "/reset <events|users>"}, utils))
 					msg.ReplyToMessageID = update.Message.MessageID
 					message, error := data.Bot.Send(msg)
 					if error != nil {
@@ -416,16 +796,73 @@ func manageCommands(update tgbotapi.Update, utils types.Utils, data types.Data,
 				}
 			}
 		}
+	case "settings":
+		/*
+			Description:
+				Show the settings of the chat, with an inline menu to change them.
+				The settings can be changed by the moderators of the chat (the administrators of the group, or the user in a private chat) and by the bot-admin.
+
+			Forms:
+				/settings
+				/settings <option> <value>
+		*/
+		keys := make([]string, 0, len(SettingOptions))
+		for _, option := range SettingOptions {
+			keys = append(keys, option.Key)
+		}
+		cmdSyntax := fmt.Sprintf("/settings [<%v> <value>]", strings.Join(keys, "|"))
+		cmdArgs := strings.Fields(update.Message.CommandArguments())
+		switch len(cmdArgs) {
+		case 0:
+			// Respond with the settings menu
+			text, keyboard := SettingsMenu(update.Message.Chat.ID, update.Message.From.ID, "", utils)
+			msg := tgbotapi.NewMessage(update.Message.Chat.ID, text)
+			msg.ReplyMarkup = keyboard
+			SendMessage(msg, update, data, utils)
+			// Log the command executed successfully
+			FinalCommandLog("Settings menu sent", update, utils)
+			SuccessResponseLog(update, utils)
+		case 2:
+			// Check if the user can change the settings of the chat
+			if !isChatModerator(update.Message.Chat, update.Message.From, utils, data) {
+				SendUserNotAuthorizedMessage(update, data, utils)
+				// Log the command failed execution
+				FinalCommandLog("Unauthorized user", update, utils)
+				break
+			}
+			// Check if the option exists
+			option, ok := GetSettingOption(cmdArgs[0])
+			if !ok {
+				SendWrongCommandSyntaxMessage(cmdSyntax, update, data, utils)
+				// Log the command failed execution
+				FinalCommandLog("Wrong command syntax", update, utils)
+				break
+			}
+			// Update the setting of the chat
+			if err := SetSetting(update.Message.Chat.ID, update.Message.Chat.Title, option.Key, cmdArgs[1], utils); err != nil {
+				msg := tgbotapi.NewMessage(update.Message.Chat.ID, TranslateUpdate(update, "settings.not_valid", map[string]any{"Option": option.Key, "Value": cmdArgs[1]}, utils))
+				SendMessage(msg, update, data, utils)
+				// Log the command failed execution
+				FinalCommandLog("Setting value not valid", update, utils)
+				break
+			}
+			msg := tgbotapi.NewMessage(update.Message.Chat.ID, TranslateUpdate(update, "settings.updated", map[string]any{
+				"Option": TranslateUpdate(update, "settings.option."+option.Key, nil, utils),
+				"Value":  settingValueText(option, option.Value(GetSettings(update.Message.Chat.ID, utils)), update.Message.Chat.ID, update.Message.From.ID, utils),
+			}, utils))
+			SendMessage(msg, update, data, utils)
+			// Log the command executed successfully
+			FinalCommandLog("Chat setting updated", update, utils)
+			SuccessResponseLog(update, utils)
+		default:
+			// Respond with a message indicating that the command arguments are wrong
+			SendWrongCommandSyntaxMessage(cmdSyntax, update, data, utils)
+			// Log the command failed execution
+			FinalCommandLog("Wrong command syntax", update, utils)
+		}
 	case "start":
 		// Respond with an introduction message for the users of the bot
-		msg := tgbotapi.NewMessage(update.Message.Chat.ID,
-			ComposeMessage([]string{
-				"%v is a bot that allows you to play a time-wasting game with one or more groups of friends within Telegram groups.",
-				"Once the bot is added, the game mainly (but not exclusively) involves sending messages in the \"hh:mm\" format at certain times of the day, in exchange for valuable points.",
-				"The person who has earned the most points at the end of the championship will be the new Clocky Champion!\n",
-				"Use /help to get a list of all commands or /credits for more information about the project.\n\n- %v, a bot from @MoraGames.",
-			}, utils.Config.App.Name, utils.Config.App.Name),
-		)
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, TranslateUpdate(update, "start", map[string]any{"Name": utils.Config.App.Name}, utils))
 		SendMessage(msg, update, data, utils)
 		// Log the command executed successfully
 		FinalCommandLog("\"start message sent", update, utils)
@@ -444,11 +881,11 @@ func manageCommands(update tgbotapi.Update, utils types.Utils, data types.Data,
 			// Get the user from the Users data structure
 			u := Users[update.Message.From.ID]
 			// Check (and eventually update) the user effects
-			UpdateUserEffects(update.Message.From.ID)
+			UpdateUserEffects(update.Message.From.ID, utils)
 			// Send the message with user's stats
-			msg := tgbotapi.NewMessage(update.Message.Chat.ID, "Non hai ancora partecipato a nessun evento.")
+			msg := tgbotapi.NewMessage(update.Message.Chat.ID, TranslateUpdate(update, "stats.none", nil, utils))
 			if u != nil {
-				msg = tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("Le tue statistiche sono:\n\nPunti totali: %v\nPartecipazioni totali: %v\nVittorie totali: %v\nPunti/Partecipazioni: %.2f\nPunti/Vittorie: %.2f\nVittorie/Partecipazioni: %.2f\nVittorie/Sconfitte: %.2f\nEffetti attivi: %v", u.TotalPoints, u.TotalEventPartecipations, u.TotalEventWins, float64(u.TotalPoints)/float64(u.TotalEventPartecipations), float64(u.TotalPoints)/float64(u.TotalEventWins), float64(u.TotalEventWins)/float64(u.TotalEventPartecipations), float64(u.TotalEventWins)/float64(u.TotalEventPartecipations-u.TotalEventWins), u.StringifyEffects()))
+				msg = tgbotapi.NewMessage(update.Message.Chat.ID, TranslateUpdate(update, "stats.own", StatsData(u, utils), utils))
 			}
 			SendMessage(msg, update, data, utils)
 			// Log the command executed successfully
@@ -476,18 +913,18 @@ func manageCommands(update tgbotapi.Update, utils types.Utils, data types.Data,
 				}
 				if !founded {
 					// Respond with a message indicating that the user does not exist
-					SendEntityNotFoundMessage("Utente", username, update, data, utils)
+					SendEntityNotFoundMessage("entity.user", username, update, data, utils)
 					// Log the command failed execution
 					FinalCommandLog("User not found", update, utils)
 				} else {
 					// Get the user from the Users data structure
 					u := Users[userKey]
 					// Check (and eventually update) the user effects
-					UpdateUserEffects(userKey)
+					UpdateUserEffects(userKey, utils)
 					// Send the message with user's stats
-					msg := tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("%v non ha ancora partecipato a nessun evento.", username))
+					msg := tgbotapi.NewMessage(update.Message.Chat.ID, TranslateUpdate(update, "stats.other_none", map[string]any{"UserName": username}, utils))
 					if u != nil {
-						msg = tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("Le statistiche di %v sono:\n\nPunti totali: %v\nPartecipazioni totali: %v\nVittorie totali: %v\nPunti/Partecipazioni: %.2f\nPunti/Vittorie: %.2f\nVittorie/Partecipazioni: %.2f\nVittorie/Sconfitte: %.2f\nEffetti attivi: %v", u.UserName, u.TotalPoints, u.TotalEventPartecipations, u.TotalEventWins, float64(u.TotalPoints)/float64(u.TotalEventPartecipations), float64(u.TotalPoints)/float64(u.TotalEventWins), float64(u.TotalEventWins)/float64(u.TotalEventPartecipations), float64(u.TotalEventWins)/float64(u.TotalEventPartecipations-u.TotalEventWins), u.StringifyEffects()))
+						msg = tgbotapi.NewMessage(update.Message.Chat.ID, TranslateUpdate(update, "stats.other", StatsData(u, utils), utils))
 					}
 					SendMessage(msg, update, data, utils)
 					// Log the command executed successfully
@@ -531,9 +968,10 @@ func manageCommands(update tgbotapi.Update, utils types.Utils, data types.Data,
 				case "event":
 					// Get and check if the event exists
 					eventKey := cmdArgs[1]
-					if event, ok := events.Events.Map[eventKey]; !ok {
+					chatEvents := ChatEvents(update.Message.Chat.ID, utils)
+					if event, ok := chatEvents.Map[eventKey]; !ok {
 						// Respond with a message indicating that the event does not exist
-						SendEntityNotFoundMessage("Evento", eventKey, update, data, utils)
+						SendEntityNotFoundMessage("entity.event", eventKey, update, data, utils)
 						// Log the command failed execution
 						FinalCommandLog("Event not found", update, utils)
 					} else {
@@ -544,12 +982,12 @@ func manageCommands(update tgbotapi.Update, utils types.Utils, data types.Data,
 							points, err := strconv.Atoi(cmdArgs[3])
 							if err != nil {
 								// Respond with a message indicating that the points value is not valid
-								SendParameterNotValidMessage("points", "un numero intero", update, data, utils)
+								SendParameterNotValidMessage("points", "expected.integer", update, data, utils)
 								// Log the command failed execution
 								FinalCommandLog("Wrong command syntax", update, utils)
 							} else {
 								// Update the Event.Points value
-								events.Events.Map[eventKey] = &events.Event{Time: event.Time, Name: event.Name, Points: points, Enabled: event.Enabled, Effects: event.Effects, Activation: event.Activation, Partecipations: event.Partecipations}
+								chatEvents.Map[eventKey] = &events.Event{Time: event.Time, Name: event.Name, DisplayName: event.DisplayName, Seconds: event.Seconds, Points: points, Enabled: event.Enabled, Effects: event.Effects, Activation: event.Activation, Partecipations: event.Partecipations}
 								// Respond with command executed successfully
 								SendPropertyUpdatedMessage("Event.Points", update, data, utils)
 								// Log the /update command executed successfully
@@ -561,12 +999,12 @@ func manageCommands(update tgbotapi.Update, utils types.Utils, data types.Data,
 							enabled, err := strconv.ParseBool(cmdArgs[3])
 							if err != nil {
 								// Respond with a message indicating that the enabled value is not valid
-								SendParameterNotValidMessage("enabled", "un booleano", update, data, utils)
+								SendParameterNotValidMessage("enabled", "expected.boolean", update, data, utils)
 								// Log the command failed execution
 								FinalCommandLog("Wrong command syntax", update, utils)
 							} else {
 								// Update the Event.Enabled value
-								events.Events.Map[eventKey] = &events.Event{Time: event.Time, Name: event.Name, Points: event.Points, Enabled: enabled, Effects: event.Effects, Activation: event.Activation, Partecipations: event.Partecipations}
+								chatEvents.Map[eventKey] = &events.Event{Time: event.Time, Name: event.Name, DisplayName: event.DisplayName, Seconds: event.Seconds, Points: event.Points, Enabled: enabled, Effects: event.Effects, Activation: event.Activation, Partecipations: event.Partecipations}
 								// Respond with command executed successfully
 								SendPropertyUpdatedMessage("Event.Enabled", update, data, utils)
 								// Log the command executed successfully
@@ -578,7 +1016,7 @@ func manageCommands(update tgbotapi.Update, utils types.Utils, data types.Data,
 							effectsNames, err := types.ParseSlice(cmdArgs[3])
 							if err != nil {
 								// Respond with a message indicating that the effects value is not valid
-								SendParameterNotValidMessage("effects", "una lista di effetti validi", update, data, utils)
+								SendParameterNotValidMessage("effects", "expected.effects", update, data, utils)
 								// Log the command failed execution
 								FinalCommandLog("Wrong command syntax", update, utils)
 							} else {
@@ -594,7 +1032,7 @@ func manageCommands(update tgbotapi.Update, utils types.Utils, data types.Data,
 								}
 								if wrongEffect == "" {
 									// Update the Event.Effects value
-									events.Events.Map[eventKey] = &events.Event{Time: event.Time, Name: event.Name, Points: event.Points, Enabled: event.Enabled, Effects: effects, Activation: event.Activation, Partecipations: event.Partecipations}
+									chatEvents.Map[eventKey] = &events.Event{Time: event.Time, Name: event.Name, DisplayName: event.DisplayName, Seconds: event.Seconds, Points: event.Points, Enabled: event.Enabled, Effects: effects, Activation: event.Activation, Partecipations: event.Partecipations}
 									// Respond with command executed successfully
 									SendPropertyUpdatedMessage("Event.Effects", update, data, utils)
 									// Log the command executed successfully
@@ -602,7 +1040,7 @@ func manageCommands(update tgbotapi.Update, utils types.Utils, data types.Data,
 									SuccessResponseLog(update, utils)
 								} else {
 									// Respond with a message indicating that the effect does not exist
-									SendEntityNotFoundMessage("Effetto", wrongEffect, update, data, utils)
+									SendEntityNotFoundMessage("entity.effect", wrongEffect, update, data, utils)
 									// Log the command failed execution
 									FinalCommandLog("Effect not found", update, utils)
 								}
@@ -626,7 +1064,7 @@ func manageCommands(update tgbotapi.Update, utils types.Utils, data types.Data,
 					}
 					if user, ok := Users[userKey]; !ok {
 						// Respond with a message indicating that the user does not exist
-						SendEntityNotFoundMessage("Utente", username, update, data, utils)
+						SendEntityNotFoundMessage("entity.user", username, update, data, utils)
 						// Log the command failed execution
 						FinalCommandLog("User not found", update, utils)
 					} else {
@@ -637,12 +1075,14 @@ func manageCommands(update tgbotapi.Update, utils types.Utils, data types.Data,
 							points, err := strconv.Atoi(cmdArgs[3])
 							if err != nil {
 								// Respond with a message indicating that the points value is not valid
-								SendParameterNotValidMessage("points", "un numero intero", update, data, utils)
+								SendParameterNotValidMessage("points", "expected.integer", update, data, utils)
 								// Log the command failed execution
 								FinalCommandLog("Wrong command syntax", update, utils)
 							} else {
 								// Update the User.TotalPoints value
-								Users[userKey] = &structs.User{UserName: user.UserName, TotalPoints: points, TotalEventPartecipations: user.TotalEventPartecipations, TotalEventWins: user.TotalEventWins, TotalChampionshipPartecipations: user.TotalChampionshipPartecipations, TotalChampionshipWins: user.TotalChampionshipWins}
+								Users[userKey] = &structs.User{TelegramID: user.TelegramID, UserName: user.UserName, TotalPoints: points, TotalEventPartecipations: user.TotalEventPartecipations, TotalEventWins: user.TotalEventWins, TotalChampionshipPartecipations: user.TotalChampionshipPartecipations, TotalChampionshipWins: user.TotalChampionshipWins, Effects: user.Effects, Achievements: user.Achievements}
+								IndexUser(userKey)
+								InvalidateRankings()
 								// Respond with command executed successfully
 								SendPropertyUpdatedMessage("TotalPoints", update, data, utils)
 								// Log the command executed successfully
@@ -654,12 +1094,14 @@ func manageCommands(update tgbotapi.Update, utils types.Utils, data types.Data,
 							partecipations, err := strconv.Atoi(cmdArgs[3])
 							if err != nil || partecipations < 0 {
 								// Respond with a message indicating that the partecipations value is not valid
-								SendParameterNotValidMessage("partecipations", "un numero intero positivo", update, data, utils)
+								SendParameterNotValidMessage("partecipations", "expected.positive_integer", update, data, utils)
 								// Log the command failed execution
 								FinalCommandLog("Wrong command syntax", update, utils)
 							} else {
 								// Update the User.TotalEventPartecipations value
-								Users[userKey] = &structs.User{UserName: user.UserName, TotalPoints: user.TotalPoints, TotalEventPartecipations: partecipations, TotalEventWins: user.TotalEventWins, TotalChampionshipPartecipations: user.TotalChampionshipPartecipations, TotalChampionshipWins: user.TotalChampionshipWins}
+								Users[userKey] = &structs.User{TelegramID: user.TelegramID, UserName: user.UserName, TotalPoints: user.TotalPoints, TotalEventPartecipations: partecipations, TotalEventWins: user.TotalEventWins, TotalChampionshipPartecipations: user.TotalChampionshipPartecipations, TotalChampionshipWins: user.TotalChampionshipWins, Effects: user.Effects, Achievements: user.Achievements}
+								IndexUser(userKey)
+								InvalidateRankings()
 								// Respond with command executed successfully
 								SendPropertyUpdatedMessage("TotalEventPartecipations", update, data, utils)
 								// Log the command executed successfully
@@ -671,13 +1113,14 @@ func manageCommands(update tgbotapi.Update, utils types.Utils, data types.Data,
 							wins, err := strconv.Atoi(cmdArgs[3])
 							if err != nil || wins < 0 {
 								// Respond with a message indicating that the wins value is not valid
-								msg := tgbotapi.NewMessage(update.Message.Chat.ID, "Parametro <wins> deve essere un numero intero positivo.")
-								SendMessage(msg, update, data, utils)
+								SendParameterNotValidMessage("wins", "expected.positive_integer", update, data, utils)
 								// Log the command failed execution
 								FinalCommandLog("Wrong command syntax", update, utils)
 							} else {
 								// Update the User.TotalEventWins value
-								Users[userKey] = &structs.User{UserName: user.UserName, TotalPoints: user.TotalPoints, TotalEventPartecipations: user.TotalEventPartecipations, TotalEventWins: wins, TotalChampionshipPartecipations: user.TotalChampionshipPartecipations, TotalChampionshipWins: user.TotalChampionshipWins}
+								Users[userKey] = &structs.User{TelegramID: user.TelegramID, UserName: user.UserName, TotalPoints: user.TotalPoints, TotalEventPartecipations: user.TotalEventPartecipations, TotalEventWins: wins, TotalChampionshipPartecipations: user.TotalChampionshipPartecipations, TotalChampionshipWins: user.TotalChampionshipWins, Effects: user.Effects, Achievements: user.Achievements}
+								IndexUser(userKey)
+								InvalidateRankings()
 								// Respond with command executed successfully
 								SendPropertyUpdatedMessage("TotalEventWins", update, data, utils)
 								// Log the command executed successfully
@@ -701,6 +1144,10 @@ func manageCommands(update tgbotapi.Update, utils types.Utils, data types.Data,
 				}
 			}
 		}
+	case "yesterday":
+		// Respond with the recap and the activated events of the day before, in the timezone of the chat
+		yesterday := curTime.In(GetSettings(update.Message.Chat.ID, utils).Location()).AddDate(0, 0, -1)
+		SendDayMessage(yesterday.Format(history.DateFormat), update, data, utils)
 	}
 }
 
@@ -722,6 +1169,28 @@ func isAdmin(user *tgbotapi.User, utils types.Utils) bool {
 	return user.ID == adminUserID
 }
 
+// Send the recap and the activated events of the archived day of the chat
+func SendDayMessage(date string, update tgbotapi.Update, data types.Data, utils types.Utils) {
+	var day *history.Day
+	found := false
+	if History != nil {
+		day, found = History.Get(update.Message.Chat.ID, date)
+	}
+	if !found {
+		SendMessage(tgbotapi.NewMessage(update.Message.Chat.ID, TranslateUpdate(update, "history.not_found", map[string]any{"Date": date}, utils)), update, data, utils)
+		// Log the command failed execution
+		FinalCommandLog("Day not found in the history", update, utils)
+		return
+	}
+
+	settings := GetSettings(update.Message.Chat.ID, utils)
+	settings.Language = Language(update.Message.Chat.ID, update.Message.From.ID, utils)
+	SendMessage(tgbotapi.NewMessage(update.Message.Chat.ID, DayText(day, settings, utils)), update, data, utils)
+	// Log the command executed successfully
+	FinalCommandLog("History day sent", update, utils)
+	SuccessResponseLog(update, utils)
+}
+
 // Send a message
 func SendMessage(msg tgbotapi.MessageConfig, update tgbotapi.Update, data types.Data, utils types.Utils) {
 	msg.ReplyToMessageID = update.Message.MessageID
@@ -735,27 +1204,29 @@ func SendMessage(msg tgbotapi.MessageConfig, update tgbotapi.Update, data types.
 }
 
 func SendUserNotAuthorizedMessage(update tgbotapi.Update, data types.Data, utils types.Utils) {
-	msg := tgbotapi.NewMessage(update.Message.Chat.ID, "Non sei autorizzato ad usare questo comando")
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, TranslateUpdate(update, "unauthorized", nil, utils))
 	SendMessage(msg, update, data, utils)
 }
 
 func SendWrongCommandSyntaxMessage(cmdSyntax string, update tgbotapi.Update, data types.Data, utils types.Utils) {
-	msg := tgbotapi.NewMessage(update.Message.Chat.ID, "Il comando è: "+cmdSyntax)
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, TranslateUpdate(update, "wrong_syntax", map[string]any{"Syntax": cmdSyntax}, utils))
 	SendMessage(msg, update, data, utils)
 }
 
 func SendPropertyUpdatedMessage(property string, update tgbotapi.Update, data types.Data, utils types.Utils) {
-	msg := tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("Proprietà '%v' aggiornata.", property))
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, TranslateUpdate(update, "property_updated", map[string]any{"Property": property}, utils))
 	SendMessage(msg, update, data, utils)
 }
 
+// The expected value is the key of its description in the messages catalog
 func SendParameterNotValidMessage(parameter, expected string, update tgbotapi.Update, data types.Data, utils types.Utils) {
-	msg := tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("Parametro <%v> non valido. Deve essere %v.", parameter, expected))
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, TranslateUpdate(update, "parameter_not_valid", map[string]any{"Parameter": parameter, "Expected": TranslateUpdate(update, expected, nil, utils)}, utils))
 	SendMessage(msg, update, data, utils)
 }
 
+// The entity is the key of its name in the messages catalog
 func SendEntityNotFoundMessage(entity string, entityValue any, update tgbotapi.Update, data types.Data, utils types.Utils) {
-	msg := tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("%v (%v) non trovato.", entity, entityValue))
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, TranslateUpdate(update, "entity_not_found", map[string]any{"Entity": TranslateUpdate(update, entity, nil, utils), "Value": entityValue}, utils))
 	SendMessage(msg, update, data, utils)
 }
 
@@ -772,6 +1243,33 @@ func SuccessResponseLog(update tgbotapi.Update, utils types.Utils) {
 	utils.Logger.Debug(msg)
 }
 
+// Get the data of the stats messages of the user, with their handicaps, the metrics derived from their timeline (if kept) and their achievements
+func StatsData(u *structs.User, utils types.Utils) map[string]any {
+	var activity *history.ClaimsStats
+	if Timeline != nil {
+		if claims, err := Timeline.Claims(u.TelegramID); err == nil {
+			activity = history.Stats(claims)
+		}
+	}
+	achievements := make([]string, 0)
+	for _, achievement := range utils.Config.Achievements {
+		if _, ok := u.Achievements[achievement.Key]; ok {
+			achievements = append(achievements, achievement.Name)
+		}
+	}
+	return map[string]any{
+		"User":                   u,
+		"PointsPerPartecipation": float64(u.TotalPoints) / float64(u.TotalEventPartecipations),
+		"PointsPerWin":           float64(u.TotalPoints) / float64(u.TotalEventWins),
+		"WinsPerPartecipation":   float64(u.TotalEventWins) / float64(u.TotalEventPartecipations),
+		"WinsPerLoss":            float64(u.TotalEventWins) / float64(u.TotalEventPartecipations-u.TotalEventWins),
+		"Activity":               activity,
+		"Handicaps":              UserHandicaps(u.TelegramID, utils),
+		"Achievements":           achievements,
+		"AchievementsNum":        len(utils.Config.Achievements),
+	}
+}
+
 func ComposeMessage(subMessages []string, args ...any) string {
 	msg := ""
 	for _, subMessage := range subMessages {
@@ -1,8 +1,12 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"slices"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 	"github.com/joho/godotenv"
@@ -10,9 +14,23 @@ import (
 
 type (
 	Config struct {
-		App `yaml:"application"`
-		Log `yaml:"logger"`
-		Env `yaml:"required_envs"`
+		App           `yaml:"application"`
+		Log           `yaml:"logger"`
+		Bot           `yaml:"bot"`
+		Webhook       `yaml:"webhook"`
+		Console       `yaml:"console"`
+		Game          `yaml:"game"`
+		Settings      `yaml:"settings"`
+		Schedule      `yaml:"schedule"`
+		Claims        `yaml:"claims"`
+		Achievements  `yaml:"achievements"`
+		Handicaps     `yaml:"handicaps"`
+		TimingEffects `yaml:"timing_effects"`
+		Outbox        `yaml:"outbox"`
+		Cleanup       `yaml:"cleanup"`
+		Clock         `yaml:"clock"`
+		Shutdown      `yaml:"shutdown"`
+		Env           `yaml:"required_envs"`
 	}
 
 	App struct {
@@ -26,25 +44,181 @@ type (
 		Level  string `env-required:"true" yaml:"level" env:"LOG_LEVEL"`
 	}
 
+	Bot struct {
+		APIEndpoint    string `env-default:"https://api.telegram.org/bot%s/%s" yaml:"api_endpoint"    env:"TELEGRAM_API_ENDPOINT"`
+		Mode           string `env-default:"polling"                           yaml:"mode"            env:"BOT_MODE"`
+		PollingTimeout int    `env-default:"180"                               yaml:"polling_timeout" env:"BOT_POLLING_TIMEOUT"`
+	}
+
+	Webhook struct {
+		URL               string `env-default:""         yaml:"url"                env:"WEBHOOK_URL"`
+		Listen            string `env-default:":8443"    yaml:"listen"             env:"WEBHOOK_LISTEN"`
+		Path              string `env-default:"/webhook" yaml:"path"               env:"WEBHOOK_PATH"`
+		SecretToken       string `env-default:""         yaml:"secret_token"       env:"WEBHOOK_SECRET_TOKEN"`
+		CertFile          string `env-default:""         yaml:"cert_file"          env:"WEBHOOK_CERT_FILE"`
+		KeyFile           string `env-default:""         yaml:"key_file"           env:"WEBHOOK_KEY_FILE"`
+		UploadCertificate bool   `env-default:"false"    yaml:"upload_certificate" env:"WEBHOOK_UPLOAD_CERTIFICATE"`
+	}
+
+	Console struct {
+		Users []ConsoleIdentity `yaml:"users"`
+		Chats []ConsoleIdentity `yaml:"chats"`
+	}
+
+	ConsoleIdentity struct {
+		ID   int64  `yaml:"id"`
+		Name string `yaml:"name"`
+	}
+
+	Game struct {
+		AggregateResults bool   `env-default:"false" yaml:"aggregate_results" env:"GAME_AGGREGATE_RESULTS"`
+		Jackpot          bool   `env-default:"false" yaml:"jackpot"           env:"GAME_JACKPOT"`
+		JackpotMinute    string `env-default:""      yaml:"jackpot_minute"    env:"GAME_JACKPOT_MINUTE"`
+		// HardMode adds the events at exact seconds ("hh:mm:ss"), worth HardModePoints for every set that verifies them.
+		// Their claims must be sent in the second, and received within HardModeWindow from it.
+		HardMode       bool          `env-default:"false" yaml:"hard_mode"        env:"GAME_HARD_MODE"`
+		HardModePoints int           `env-default:"5"     yaml:"hard_mode_points" env:"GAME_HARD_MODE_POINTS"`
+		HardModeWindow time.Duration `env-default:"2s"    yaml:"hard_mode_window" env:"GAME_HARD_MODE_WINDOW"`
+	}
+
+	// Claims is how the messages are read as claims of the events.
+	// Formats are the accepted ones: "colon" ("12:34"), "dot" ("12.34") and "compact" ("1234").
+	// Emoji accepts the full-width and the keycap emoji digits, ignoring the other emoji.
+	// NearMisses replies to the claims of the event that just ended ("too late") or that is about to start ("false start").
+	Claims struct {
+		Formats    []string `env-default:"colon" env-separator:"," yaml:"formats"     env:"CLAIMS_FORMATS"`
+		Emoji      bool     `env-default:"false"                   yaml:"emoji"       env:"CLAIMS_EMOJI"`
+		NearMisses bool     `env-default:"false"                   yaml:"near_misses" env:"CLAIMS_NEAR_MISSES"`
+	}
+
+	// Settings are the default settings of the chats (each chat can change them with /settings)
+	Settings struct {
+		Language      string `env-default:"it"    yaml:"language"       env:"SETTINGS_LANGUAGE"`
+		Timezone      string `env-default:"Local" yaml:"timezone"       env:"SETTINGS_TIMEZONE"`
+		ResetTime     string `env-default:"23:58" yaml:"reset_time"     env:"SETTINGS_RESET_TIME"`
+		RevealEffects bool   `env-default:"true"  yaml:"reveal_effects" env:"SETTINGS_REVEAL_EFFECTS"`
+	}
+
+	// Schedule are the phases of the daily lifecycle of the events of a chat, relative to its reset time
+	Schedule struct {
+		PregenerateBefore time.Duration `env-default:"0s" yaml:"pregenerate_before" env:"SCHEDULE_PREGENERATE_BEFORE"`
+		ClosingBefore     time.Duration `env-default:"0s" yaml:"closing_before"     env:"SCHEDULE_CLOSING_BEFORE"`
+		AnnounceAfter     time.Duration `env-default:"0s" yaml:"announce_after"     env:"SCHEDULE_ANNOUNCE_AFTER"`
+	}
+
+	// Achievements are the long-term goals of the players
+	Achievements []Achievement
+
+	// Achievement is unlocked by the first claim that satisfies its rule:
+	//   - "set":         win all the enabled events of a set in a day
+	//   - "streak":      claim events in Value consecutive days
+	//   - "last_second": claim an event at its 59th second
+	//   - "survivor":    win an event with a multiplier of Value (e.g. -3)
+	// The Reward is the name of a user-scoped effect given to the player (optional).
+	Achievement struct {
+		Key         string `yaml:"key"`
+		Name        string `yaml:"name"`
+		Description string `yaml:"description"`
+		Rule        string `yaml:"rule"`
+		Value       int    `yaml:"value"`
+		Reward      string `yaml:"reward"`
+	}
+
+	// Handicaps are the effects given to the players behind the others
+	Handicaps []Handicap
+
+	// Handicap gives its effect to the players whose measure by the condition is at least Min and less than Max (0 for no limit):
+	//   - "leader_gap":  points behind the leader
+	//   - "next_gap":    points behind the next player
+	//   - "percentile":  percentage of the players ahead
+	//   - "days_absent": days since the last claim
+	// The effect changes the points of the claims as the event effects ("+", "-" or "*" the Value), by at most Cap points (0 for no cap).
+	Handicap struct {
+		Name      string `yaml:"name"`
+		Condition string `yaml:"condition"`
+		Min       int    `yaml:"min"`
+		Max       int    `yaml:"max"`
+		Key       string `yaml:"key"`
+		Value     int    `yaml:"value"`
+		Cap       int    `yaml:"cap"`
+	}
+
+	// TimingEffects are the effects given to the winners of the events by the timing of the claims
+	TimingEffects []TimingEffect
+
+	// TimingEffect is given to the winner of an event when its condition is satisfied:
+	//   - "second":       the claim is sent in a second from From to To (e.g. "59" and "59")
+	//   - "time":         the event is from the time From to To (e.g. "02:00" and "04:59")
+	//   - "photo_finish": the next claim arrives Within the duration from the winning one (the effect is given then)
+	// The effect changes the points as the event effects ("+", "-" or "*" the Value), by at most Cap points (0 for no cap).
+	TimingEffect struct {
+		Name      string        `yaml:"name"`
+		Condition string        `yaml:"condition"`
+		From      string        `yaml:"from"`
+		To        string        `yaml:"to"`
+		Within    time.Duration `yaml:"within"`
+		Key       string        `yaml:"key"`
+		Value     int           `yaml:"value"`
+		Cap       int           `yaml:"cap"`
+	}
+
+	Outbox struct {
+		GlobalRate  float64       `env-default:"30"    yaml:"global_rate"  env:"OUTBOX_GLOBAL_RATE"`
+		GlobalBurst int           `env-default:"30"    yaml:"global_burst" env:"OUTBOX_GLOBAL_BURST"`
+		ChatRate    float64       `env-default:"0.33"  yaml:"chat_rate"    env:"OUTBOX_CHAT_RATE"`
+		ChatBurst   int           `env-default:"5"     yaml:"chat_burst"   env:"OUTBOX_CHAT_BURST"`
+		MaxRetries  int           `env-default:"5"     yaml:"max_retries"  env:"OUTBOX_MAX_RETRIES"`
+		Backoff     time.Duration `env-default:"500ms" yaml:"backoff"      env:"OUTBOX_BACKOFF"`
+		MaxDelay    time.Duration `env-default:"1m"    yaml:"max_delay"    env:"OUTBOX_MAX_DELAY"`
+	}
+
+	Cleanup struct {
+		Enabled bool          `env-default:"false" yaml:"enabled" env:"CLEANUP_ENABLED"`
+		After   time.Duration `env-default:"10m"   yaml:"after"   env:"CLEANUP_AFTER"`
+		Claims  bool          `env-default:"false" yaml:"claims"  env:"CLEANUP_CLAIMS"`
+	}
+
+	Clock struct {
+		Start string  `env-default:""  yaml:"start" env:"CLOCK_START"`
+		Speed float64 `env-default:"1" yaml:"speed" env:"CLOCK_SPEED"`
+	}
+
+	Shutdown struct {
+		Timeout       time.Duration `env-default:"30s"   yaml:"timeout"        env:"SHUTDOWN_TIMEOUT"`
+		NotifyChats   bool          `env-default:"false" yaml:"notify_chats"   env:"SHUTDOWN_NOTIFY_CHATS"`
+		NotifyMessage string        `env-default:""      yaml:"notify_message" env:"SHUTDOWN_NOTIFY_MESSAGE"`
+	}
+
 	Env []string
 )
 
 func NewConfig() (*Config, error) {
 	cfg := &Config{}
 
-	if err := godotenv.Load("./config/.env"); err != nil {
+	// The env variables can be set without the .env file (e.g. to play in the console)
+	if err := godotenv.Load("./config/.env"); err != nil && !errors.Is(err, fs.ErrNotExist) {
 		return nil, err
 	}
 	if err := cfg.ReadConfig("./config/config.yml"); err != nil {
 		return nil, err
 	}
-	if err := cfg.ReadEnv(cfg.Env); err != nil {
+	if err := cfg.ReadEnv(cfg.RequiredEnvs()); err != nil {
 		return nil, err
 	}
 
 	return cfg, nil
 }
 
+// RequiredEnvs returns the env variables that must exist in the mode of the bot (the console mode plays without Telegram, so it doesn't need the token)
+func (cfg *Config) RequiredEnvs() Env {
+	if cfg.Bot.Mode != "console" {
+		return cfg.Env
+	}
+	return slices.DeleteFunc(slices.Clone(cfg.Env), func(env string) bool {
+		return env == "TELEGRAM_API_TOKEN"
+	})
+}
+
 func (cfg *Config) ReadConfig(path string) error {
 	if err := cleanenv.ReadConfig(path, cfg); err != nil {
 		return err
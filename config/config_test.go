@@ -0,0 +1,22 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func Test_RequiredEnvs(t *testing.T) {
+	cfg := &Config{Env: Env{"TELEGRAM_API_TOKEN", "TELEGRAM_ADMIN_ID"}}
+	if envs := cfg.RequiredEnvs(); !slices.Equal(envs, Env{"TELEGRAM_API_TOKEN", "TELEGRAM_ADMIN_ID"}) {
+		t.Errorf("The token should be required with Telegram, got %v", envs)
+	}
+
+	// The console mode doesn't need the token
+	cfg.Bot.Mode = "console"
+	if envs := cfg.RequiredEnvs(); !slices.Equal(envs, Env{"TELEGRAM_ADMIN_ID"}) {
+		t.Errorf("The token should not be required in the console mode, got %v", envs)
+	}
+	if len(cfg.Env) != 2 {
+		t.Errorf("The configured envs should not change, got %v", cfg.Env)
+	}
+}
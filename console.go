@@ -0,0 +1,25 @@
+package main
+
+import (
+	"os"
+
+	"github.com/MoraGames/clockyuwu/config"
+	"github.com/MoraGames/clockyuwu/pkg/clock"
+	"github.com/MoraGames/clockyuwu/pkg/console"
+)
+
+// Create the terminal console with the fake users and chats set in the configurations
+func NewConsole(conf *config.Config, gameClock clock.Clock) *console.Console {
+	users := make([]console.Identity, 0)
+	for _, user := range conf.Console.Users {
+		users = append(users, console.Identity{ID: user.ID, Name: user.Name})
+	}
+	chats := make([]console.Identity, 0)
+	for _, chat := range conf.Console.Chats {
+		chats = append(chats, console.Identity{ID: chat.ID, Name: chat.Name})
+	}
+
+	c := console.New(users, chats, os.Stdout)
+	c.Now = gameClock.Now
+	return c
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+
+	"github.com/MoraGames/clockyuwu/events"
+	"github.com/MoraGames/clockyuwu/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// CustomEvents contains the custom events of every chat, by chat ID (it's protected by stateMutex).
+// They are kept apart from the generated events, which they replace at every reset.
+var CustomEvents = make(map[int64][]*events.CustomEvent)
+
+// Add the custom event to the chat, replacing the one at the same minute with the same recurrence
+func AddCustomEvent(chatID int64, ce *events.CustomEvent, utils types.Utils) {
+	custom := RemoveCustomEvents(chatID, ce.Time, ce.Recurrence)
+	custom = append(custom, ce)
+	sort.SliceStable(custom, func(i, j int) bool { return custom[i].Time < custom[j].Time })
+	CustomEvents[chatID] = custom
+	SaveCustomEvents(utils)
+}
+
+// Get the custom events of the chat without the ones at the minute (only the ones with the recurrence, if it isn't empty)
+func RemoveCustomEvents(chatID int64, eventTime, recurrence string) []*events.CustomEvent {
+	kept := make([]*events.CustomEvent, 0, len(CustomEvents[chatID]))
+	for _, ce := range CustomEvents[chatID] {
+		if ce.Time != eventTime || (recurrence != "" && ce.Recurrence != recurrence) {
+			kept = append(kept, ce)
+		}
+	}
+	return kept
+}
+
+// Save the CustomEvents data structure on files/custom_events.json
+func SaveCustomEvents(utils types.Utils) {
+	file, err := json.MarshalIndent(CustomEvents, "", " ")
+	if err != nil {
+		utils.Logger.WithFields(logrus.Fields{
+			"err": err,
+		}).Error("Error while marshalling data")
+		return
+	}
+	err = os.WriteFile("files/custom_events.json", file, 0644)
+	if err != nil {
+		utils.Logger.WithFields(logrus.Fields{
+			"err": err,
+		}).Error("Error while writing data")
+	}
+}
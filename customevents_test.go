@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/MoraGames/clockyuwu/config"
+	"github.com/MoraGames/clockyuwu/events"
+	"github.com/MoraGames/clockyuwu/structs"
+)
+
+func Test_CustomEvents(t *testing.T) {
+	inTempDir(t)
+	utils := testUtils(&config.Config{Settings: config.Settings{Timezone: "UTC"}}, time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC))
+	resetState(utils)
+
+	// The custom events are sorted by minute, and replace the one at the same minute with the same recurrence
+	AddCustomEvent(-1, &events.CustomEvent{Time: "13:37", Name: "Leet", Points: 3, Recurrence: "daily"}, utils)
+	AddCustomEvent(-1, &events.CustomEvent{Time: "00:00", Name: "New Year", Points: 10, Recurrence: "01-01"}, utils)
+	AddCustomEvent(-1, &events.CustomEvent{Time: "13:37", Name: "Leet", Points: 5, Effects: []*structs.Effect{structs.DoublePositivePoints}, Recurrence: "daily"}, utils)
+	AddCustomEvent(-1, &events.CustomEvent{Time: "13:37", Name: "Christmas Leet", Points: 20, Recurrence: "12-25"}, utils)
+	custom := CustomEvents[-1]
+	if len(custom) != 3 || custom[0].Name != "New Year" || custom[1].Points != 5 || custom[2].Name != "Christmas Leet" {
+		t.Fatalf("Unexpected custom events: %+v", custom)
+	}
+
+	// They are saved on file
+	saved := make(map[int64][]*events.CustomEvent)
+	if file, err := os.ReadFile("files/custom_events.json"); err != nil || json.Unmarshal(file, &saved) != nil || len(saved[-1]) != 3 {
+		t.Errorf("The custom events should be saved on file, got %v (%v)", saved, err)
+	}
+
+	// The custom event replaces the generated one from the reset
+	ResetChatEvents(-1, GetSettings(-1, utils), nil, utils)
+	if event := events.Events[-1].Map["13:37"]; !event.Enabled || event.Points != 5 || !HasEffect(event.Effects, structs.DoublePositivePoints.Name) {
+		t.Errorf("The daily custom event should replace the generated one, got %+v", event)
+	}
+
+	// They are removed by minute, with the recurrence or all of them
+	if kept := RemoveCustomEvents(-1, "13:37", "12-25"); len(kept) != 2 {
+		t.Errorf("Only the custom event with the recurrence should be removed, got %+v", kept)
+	}
+	if kept := RemoveCustomEvents(-1, "13:37", ""); len(kept) != 1 || kept[0].Name != "New Year" {
+		t.Errorf("All the custom events at the minute should be removed, got %+v", kept)
+	}
+}
@@ -0,0 +1,122 @@
+package events
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/MoraGames/clockyuwu/structs"
+)
+
+const (
+	// Daily is the recurrence of the custom events that occur every day
+	Daily = "daily"
+	// YearlyFormat is the format of the recurrence of the custom events that occur every year on a day
+	YearlyFormat = "01-02"
+	// DateFormat is the format of the recurrence of the custom events that occur only on a date
+	DateFormat = "2006-01-02"
+)
+
+// CustomEvent is an event defined by the admins of a chat at any minute, with fixed points and effects.
+// The custom events are kept apart from the generated ones, and they replace them in the days they occur.
+type CustomEvent struct {
+	Time       string
+	Name       string
+	Points     int
+	Effects    []*structs.Effect
+	Recurrence string
+}
+
+// ValidRecurrence reports if the recurrence is "daily", a day of the year ("mm-dd") or a date ("yyyy-mm-dd")
+func ValidRecurrence(recurrence string) bool {
+	if recurrence == Daily {
+		return true
+	}
+	if _, err := time.Parse(DateFormat, recurrence); err == nil {
+		return true
+	}
+	// The day of the year is checked in a leap year, to allow the 29th of February
+	_, err := time.Parse(DateFormat, "2000-"+recurrence)
+	return err == nil && len(recurrence) == len(YearlyFormat)
+}
+
+// Kind returns the kind of the recurrence: "daily", "yearly" or "once"
+func (ce *CustomEvent) Kind() string {
+	switch {
+	case ce.Recurrence == Daily:
+		return "daily"
+	case len(ce.Recurrence) == len(YearlyFormat):
+		return "yearly"
+	}
+	return "once"
+}
+
+// OccursOn reports if the custom event occurs on the day (a date in the location of the chat)
+func (ce *CustomEvent) OccursOn(day time.Time) bool {
+	switch ce.Kind() {
+	case "daily":
+		return true
+	case "yearly":
+		return day.Format(YearlyFormat) == ce.Recurrence
+	}
+	return day.Format(DateFormat) == ce.Recurrence
+}
+
+// EffectNames returns the names of the effects of the custom event
+func (ce *CustomEvent) EffectNames() []string {
+	names := make([]string, 0, len(ce.Effects))
+	for _, effect := range ce.Effects {
+		names = append(names, effect.Name)
+	}
+	return names
+}
+
+// ApplyCustom replaces the events with the custom ones that occur after the instant, in the location.
+// When more custom events are at the same minute, the dated ones win over the yearly ones, and those over the daily ones.
+func (ed *EventsData) ApplyCustom(custom []*CustomEvent, location *time.Location, from time.Time) {
+	kinds := map[string]int{"daily": 0, "yearly": 1, "once": 2}
+	sorted := slices.Clone(custom)
+	sort.SliceStable(sorted, func(i, j int) bool { return kinds[sorted[i].Kind()] < kinds[sorted[j].Kind()] })
+
+	for _, ce := range sorted {
+		eventTime, err := time.Parse("15:04", ce.Time)
+		if err != nil {
+			continue
+		}
+		// The custom events follow the wall clock too (they are skipped by a DST change)
+		occurrences := NextOccurrences(from, eventTime.Hour(), eventTime.Minute(), location)
+		if len(occurrences) == 0 || !ce.OccursOn(occurrences[0]) {
+			continue
+		}
+
+		event, ok := ed.Map[ce.Time]
+		if !ok {
+			event = NewEvent(time.Date(0, time.January, 1, eventTime.Hour(), eventTime.Minute(), 0, 0, time.UTC), occurrences[0], nil)
+			event.Enabled, event.Points = false, 0
+			ed.Map[event.Name] = event
+			i, _ := slices.BinarySearch(ed.Keys, event.Name)
+			ed.Keys = slices.Insert(ed.Keys, i, event.Name)
+			ed.Stats.TotalEventsNum++
+		}
+
+		// The custom event replaces the points and the effects of the generated one
+		if event.Enabled {
+			ed.Stats.EnabledEventsNum--
+			ed.Stats.EnabledPointsSum -= event.Points
+			for _, effect := range event.Effects {
+				ed.Stats.EnabledEffectsNum--
+				if ed.Stats.EnabledEffects[effect.Name]--; ed.Stats.EnabledEffects[effect.Name] <= 0 {
+					delete(ed.Stats.EnabledEffects, effect.Name)
+				}
+			}
+		}
+		event.DisplayName, event.Points, event.Enabled = ce.Name, ce.Points, true
+		event.Effects = slices.Clone(ce.Effects)
+		ed.Stats.EnabledEventsNum++
+		ed.Stats.EnabledPointsSum += event.Points
+		for _, effect := range event.Effects {
+			ed.Stats.EnabledEffectsNum++
+			ed.Stats.EnabledEffects[effect.Name]++
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MoraGames/clockyuwu/structs"
+)
+
+func Test_ValidRecurrence(t *testing.T) {
+	for recurrence, valid := range map[string]bool{"daily": true, "01-01": true, "02-29": true, "2024-12-31": true, "13-01": false, "1-1": false, "weekly": false, "": false} {
+		if ValidRecurrence(recurrence) != valid {
+			t.Errorf("The recurrence %q should be valid: %v", recurrence, valid)
+		}
+	}
+}
+
+func Test_ApplyCustom(t *testing.T) {
+	ed := &EventsData{Map: make(EventsMap), Stats: EventsStats{EnabledEffects: make(map[string]int)}}
+	for _, name := range []string{"00:00", "12:34", "22:22"} {
+		eventTime, _ := time.Parse("15:04", name)
+		ed.Map[name] = NewEvent(eventTime, time.Time{}, nil)
+		ed.Map[name].Enabled, ed.Map[name].Points = true, 2
+		ed.Keys = append(ed.Keys, name)
+		ed.Stats.TotalEventsNum++
+		ed.Stats.EnabledEventsNum++
+		ed.Stats.EnabledPointsSum += 2
+	}
+	ed.Map["00:00"].Effects = []*structs.Effect{structs.AddOnePoint}
+	ed.Stats.EnabledEffectsNum, ed.Stats.EnabledEffects[structs.AddOnePoint.Name] = 1, 1
+
+	// The day after the instant is the new year for the events before 10:00
+	ed.ApplyCustom([]*CustomEvent{
+		{Time: "00:00", Name: "New Year", Points: 10, Recurrence: "01-01"},
+		{Time: "00:00", Name: "Midnight", Points: 3, Recurrence: "daily"},
+		{Time: "13:37", Name: "Leet", Points: 5, Effects: []*structs.Effect{structs.DoublePositivePoints}, Recurrence: "daily"},
+		{Time: "12:34", Name: "Summer", Points: 7, Recurrence: "2024-06-01"},
+	}, time.UTC, time.Date(2024, time.December, 31, 10, 0, 0, 0, time.UTC))
+
+	if event := ed.Map["00:00"]; event.DisplayName != "New Year" || event.Points != 10 || len(event.Effects) != 0 {
+		t.Errorf("The yearly custom event should win over the daily one, got %+v", event)
+	}
+	if event := ed.Map["13:37"]; event == nil || !event.Enabled || event.DisplayName != "Leet" || event.Points != 5 || len(event.Effects) != 1 {
+		t.Errorf("The custom event should be added at any minute, got %+v", event)
+	}
+	if event := ed.Map["12:34"]; event.DisplayName != "" || event.Points != 2 {
+		t.Errorf("The custom event of another date should not be applied, got %+v", event)
+	}
+	if len(ed.Keys) != 4 || ed.Keys[2] != "13:37" {
+		t.Errorf("The keys should stay in order, got %v", ed.Keys)
+	}
+	expected := EventsStats{TotalEventsNum: 4, EnabledEventsNum: 4, EnabledPointsSum: 19, EnabledEffectsNum: 1, EnabledEffects: map[string]int{structs.DoublePositivePoints.Name: 1}}
+	if ed.Stats.TotalEventsNum != expected.TotalEventsNum || ed.Stats.EnabledEventsNum != expected.EnabledEventsNum || ed.Stats.EnabledPointsSum != expected.EnabledPointsSum || ed.Stats.EnabledEffectsNum != expected.EnabledEffectsNum || len(ed.Stats.EnabledEffects) != 1 || ed.Stats.EnabledEffects[structs.DoublePositivePoints.Name] != 1 {
+		t.Errorf("The stats should be %+v, got %+v", expected, ed.Stats)
+	}
+
+	// The reset at 00:00 on the new year enables the New Year event, its minute is still the new year
+	ed.Map["00:00"].Reset(time.Time{}, nil)
+	ed.ApplyCustom([]*CustomEvent{
+		{Time: "00:00", Name: "New Year", Points: 10, Recurrence: "01-01"},
+	}, time.UTC, time.Date(2025, time.January, 1, 0, 0, 0, 250*int(time.Millisecond), time.UTC))
+	if event := ed.Map["00:00"]; !event.Enabled || event.DisplayName != "New Year" {
+		t.Errorf("The reset at 00:00 should enable the New Year event, got %+v", event)
+	}
+
+	// The reset event is no longer custom, and no set verifies it
+	ed.Map["13:37"].Reset(time.Time{}, nil)
+	if event := ed.Map["13:37"]; event.Enabled || event.DisplayName != "" {
+		t.Errorf("The reset should disable the custom-only event, got %+v", event)
+	}
+}
@@ -1,6 +1,7 @@
 package events
 
 import (
+	"slices"
 	"time"
 
 	"github.com/MoraGames/clockyuwu/structs"
@@ -8,8 +9,11 @@ import (
 
 type (
 	Event struct {
-		Time           time.Time
-		Name           string
+		Time        time.Time
+		Name        string
+		DisplayName string `json:",omitempty"`
+		// Seconds reports if the event is at an exact second ("15:04:05"), instead of a minute
+		Seconds        bool `json:",omitempty"`
 		Points         int
 		Enabled        bool
 		Effects        []*structs.Effect
@@ -22,6 +26,8 @@ type (
 		ActivatedAt  time.Time
 		ArrivedAt    time.Time
 		EarnedPoints int
+		// Jackpot are the points of the jackpot won with the event (included in the earned ones)
+		Jackpot int `json:",omitempty"`
 	}
 
 	EventPartecipation struct {
@@ -30,8 +36,11 @@ type (
 	}
 )
 
-func NewEvent(eventTime time.Time) *Event {
-	enabled, points := CalculateStatus(eventTime)
+// NewEvent creates the event at the minute of the time, occurring on the date (zero if it's unknown), in a chat with the enabled sets
+func NewEvent(eventTime, date time.Time, enabledSets []string) *Event {
+	ctx := NewSetContext(eventTime, date)
+	ctx.Enabled = enabledSets
+	enabled, points := CalculateStatus(ctx)
 	return &Event{
 		Time:           eventTime,
 		Name:           eventTime.Format("15:04"),
@@ -43,8 +52,36 @@ func NewEvent(eventTime time.Time) *Event {
 	}
 }
 
-func (e *Event) Reset() {
-	e.Enabled, e.Points = CalculateStatus(e.Time)
+// NewSecondsEvent creates the event at the exact second of the time, occurring on the date (zero if it's unknown), in a chat with the enabled sets
+func NewSecondsEvent(eventTime, date time.Time, enabledSets []string) *Event {
+	event := NewEvent(eventTime, date, enabledSets)
+	event.Name, event.Seconds = eventTime.Format("15:04:05"), true
+	event.Reset(date, enabledSets)
+	return event
+}
+
+// SetContext returns the context of the event for the sets, occurring on the date
+func (e *Event) SetContext(date time.Time) SetContext {
+	if e.Seconds {
+		return NewSecondsSetContext(e.Time, date)
+	}
+	return NewSetContext(e.Time, date)
+}
+
+// Precision returns the duration of the event: a second for the events at exact seconds, a minute for the others
+func (e *Event) Precision() time.Duration {
+	if e.Seconds {
+		return time.Second
+	}
+	return time.Minute
+}
+
+// Reset the event, occurring on the date (zero if it's unknown), in a chat with the enabled sets
+func (e *Event) Reset(date time.Time, enabledSets []string) {
+	ctx := e.SetContext(date)
+	ctx.Enabled = enabledSets
+	e.Enabled, e.Points = CalculateStatus(ctx)
+	e.DisplayName = ""
 	e.Effects = nil
 	e.Activation = nil
 	e.Partecipations = make(map[int64]*EventPartecipation)
@@ -75,25 +112,23 @@ func (e *Event) Partecipate(by *structs.User, at time.Time) {
 	}
 }
 
-func CalculateValid(time time.Time) bool {
-	hour1, hour2, minute1, minute2 := SplitTime(time)
-
+// CalculateValid reports if a set verifies the event in the context
+func CalculateValid(ctx SetContext) bool {
 	for _, set := range Sets {
-		if set.Verify(hour1, hour2, minute1, minute2) {
+		if set.Applies(ctx) {
 			return true
 		}
 	}
 	return false
 }
 
-func CalculateStatus(time time.Time) (bool, int) {
-	hour1, hour2, minute1, minute2 := SplitTime(time)
-
+// CalculateStatus returns if the event in the context is enabled and its points (one for every set enabled in the context that verifies it)
+func CalculateStatus(ctx SetContext) (bool, int) {
 	enabled := false
 	points := 0
 	for _, set := range Sets {
 
-		if set.Enabled && set.Verify(hour1, hour2, minute1, minute2) {
+		if slices.Contains(ctx.Enabled, set.Name) && set.Applies(ctx) {
 			enabled = true
 			points += 1
 		}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math/rand"
 	"os"
+	"slices"
 	"time"
 
 	"github.com/MoraGames/clockyuwu/pkg/types"
@@ -18,6 +19,12 @@ type (
 		Map   EventsMap
 		Keys  EventsKeys
 		Stats EventsStats
+		// Next are the events of the next day, when they are generated in advance (they replace these ones at the reset)
+		Next *EventsData `json:",omitempty"`
+		// NextReset is the reset the Next events were generated for (they are thrown away at a different one)
+		NextReset *time.Time `json:",omitempty"`
+		// Jackpot are the points of the events nobody claimed, not won yet (they are kept by the resets)
+		Jackpot int `json:",omitempty"`
 	}
 
 	EventsMap   map[string]*Event
@@ -34,36 +41,24 @@ type (
 	}
 )
 
-var Events *EventsData
+// Events contains the events data of every chat, by chat ID
+var Events = make(map[int64]*EventsData)
 var AssignEventsWithDefault = func(utils types.Utils) {
-	Events = NewEventsData(true, utils)
+	Events = make(map[int64]*EventsData)
 }
 
-func NewEventsData(newEffects bool, utils types.Utils) *EventsData {
+// NewEventsData creates the events of the day starting from the instant, for a chat in the location (the minutes the day skips for a DST change are disabled).
+// The custom events of the chat replace the generated ones in the day.
+func NewEventsData(newEffects bool, location *time.Location, from time.Time, custom []*CustomEvent, utils types.Utils) *EventsData {
 	ed := &EventsData{
-		make(EventsMap),
-		make(EventsKeys, 0),
-		EventsStats{0, 0, nil, 0, 0, 0, 0, make(map[string]int)},
+		Map:   make(EventsMap),
+		Keys:  make(EventsKeys, 0),
+		Stats: EventsStats{0, 0, nil, 0, 0, 0, 0, make(map[string]int)},
 	}
 
 	ed.EnabledRandomSets(types.Interval{Min: 0.65, Max: 1.00}, utils)
 
-	for i := 0; i < 24*60; i++ {
-		now := time.Now()
-		time := time.Date(now.Year(), now.Month(), now.Day(), i/60, i%60, 0, 0, now.Location())
-
-		if CalculateValid(time) {
-			event := NewEvent(time)
-			ed.Map[event.Name] = event
-			ed.Keys = append(ed.Keys, event.Name)
-
-			ed.Stats.TotalEventsNum++
-			if event.Enabled {
-				ed.Stats.EnabledEventsNum++
-				ed.Stats.EnabledPointsSum += event.Points
-			}
-		}
-	}
+	ed.generate(location, from, utils)
 
 	if newEffects {
 		ed.AssignRandomEffects(
@@ -81,23 +76,19 @@ func NewEventsData(newEffects bool, utils types.Utils) *EventsData {
 			structs.EffectPresence{Effect: structs.AddThreePoints, Possible: 0.50, Amount: types.Interval{Min: 0.05, Max: 0.15}},          //71E ->  50% of 03-10 effects.  |  119E ->  50% of 05-17 effects.
 		)
 	}
+	ed.ApplyCustom(custom, location, from)
 
 	return ed
 }
 
-func (ed *EventsData) Reset(newEffects bool, writeMsgData *types.WriteMessageData, utils types.Utils) {
+// Reset the events for the next day of the chat in the location (the minutes it skips for a DST change are disabled).
+// The custom events of the chat replace the generated ones in the day.
+func (ed *EventsData) Reset(newEffects bool, location *time.Location, custom []*CustomEvent, writeMsgData *types.WriteMessageData, utils types.Utils) {
 	ed.Stats = EventsStats{0, 0, nil, 0, 0, 0, 0, make(map[string]int)}
 	ed.EnabledRandomSets(types.Interval{Min: 0.65, Max: 1.0}, utils)
 
-	for eventName := range ed.Map {
-		ed.Map[eventName].Reset()
-
-		ed.Stats.TotalEventsNum++
-		if ed.Map[eventName].Enabled {
-			ed.Stats.EnabledEventsNum++
-			ed.Stats.EnabledPointsSum += ed.Map[eventName].Points
-		}
-	}
+	now := utils.Clock.Now()
+	ed.generate(location, now, utils)
 
 	if newEffects {
 		ed.AssignRandomEffects(
@@ -115,9 +106,10 @@ func (ed *EventsData) Reset(newEffects bool, writeMsgData *types.WriteMessageDat
 			structs.EffectPresence{Effect: structs.AddThreePoints, Possible: 0.50, Amount: types.Interval{Min: 0.05, Max: 0.15}},          //71E ->  50% of 03-10 effects.  |  119E ->  50% of 05-17 effects.
 		)
 	}
+	ed.ApplyCustom(custom, location, now)
 
 	// Save on file the new data
-	ed.SaveOnFile(utils)
+	SaveOnFile(utils)
 
 	// Write Reset Message
 	if writeMsgData != nil {
@@ -125,6 +117,69 @@ func (ed *EventsData) Reset(newEffects bool, writeMsgData *types.WriteMessageDat
 	}
 }
 
+// generate the events of the day starting from the instant, reusing the existing ones (and removing the ones no set verifies in the day).
+// The date of every event is the one of its next occurrence, for the sets that look at the calendar.
+// In the hard mode there are the events at exact seconds too, after the one of their minute.
+func (ed *EventsData) generate(location *time.Location, from time.Time, utils types.Utils) {
+	generated, keys := make(EventsMap), make(EventsKeys, 0, len(ed.Keys))
+	add := func(event *Event, enabled bool) {
+		// The minutes the day skips for a DST change are disabled
+		if !enabled {
+			event.Enabled = false
+		}
+		generated[event.Name] = event
+		keys = append(keys, event.Name)
+
+		ed.Stats.TotalEventsNum++
+		if event.Enabled {
+			ed.Stats.EnabledEventsNum++
+			ed.Stats.EnabledPointsSum += event.Points
+		}
+	}
+
+	for i := 0; i < 24*60; i++ {
+		// The events are times of the wall clock, not instants, so they are the same in every location
+		eventTime := time.Date(0, time.January, 1, i/60, i%60, 0, 0, time.UTC)
+
+		occurrences := NextOccurrences(from, i/60, i%60, location)
+		date := time.Time{}
+		if len(occurrences) != 0 {
+			date = occurrences[0]
+		}
+		if CalculateValid(NewSetContext(eventTime, date)) {
+			event, ok := ed.Map[eventTime.Format("15:04")]
+			if ok && !event.Seconds {
+				event.Reset(date, ed.Stats.EnabledSets)
+			} else {
+				event = NewEvent(eventTime, date, ed.Stats.EnabledSets)
+			}
+			add(event, len(occurrences) != 0)
+		}
+
+		if !utils.Config.Game.HardMode {
+			continue
+		}
+		for second := 0; second < 60; second++ {
+			secondTime := eventTime.Add(time.Duration(second) * time.Second)
+			if !CalculateValid(NewSecondsSetContext(secondTime, date)) {
+				continue
+			}
+			event, ok := ed.Map[secondTime.Format("15:04:05")]
+			if ok {
+				event.Reset(date, ed.Stats.EnabledSets)
+			} else {
+				event = NewSecondsEvent(secondTime, date, ed.Stats.EnabledSets)
+			}
+			// The events at exact seconds have their own points
+			event.Points *= utils.Config.Game.HardModePoints
+			add(event, len(occurrences) != 0)
+		}
+	}
+
+	// The events no set verifies in the day are removed (the custom ones are added again later)
+	ed.Map, ed.Keys = generated, keys
+}
+
 func (ed *EventsData) EnabledRandomSets(percentage types.Interval, utils types.Utils) error {
 	if percentage.Min < 0 {
 		return fmt.Errorf("minPercentage must be >= 0")
@@ -134,10 +189,16 @@ func (ed *EventsData) EnabledRandomSets(percentage types.Interval, utils types.U
 		return fmt.Errorf("minPercentage must be <= maxPercentage")
 	}
 
-	ed.Stats.TotalSetsNum = len(Sets)
-	for _, set := range Sets {
-		set.Enabled = false
+	// The sets of the events at exact seconds are used only in the hard mode.
+	// The sets are shared by the chats, so the ones enabled are kept only in the stats of the chat
+	available := make([]int, 0, len(Sets))
+	enabled := make([]bool, len(Sets))
+	for i, set := range Sets {
+		if set.Typology != "seconds" || utils.Config.Game.HardMode {
+			available = append(available, i)
+		}
 	}
+	ed.Stats.TotalSetsNum = len(available)
 
 	min, max := int(percentage.Min*float64(ed.Stats.TotalSetsNum)), int(percentage.Max*float64(ed.Stats.TotalSetsNum))
 
@@ -145,9 +206,9 @@ func (ed *EventsData) EnabledRandomSets(percentage types.Interval, utils types.U
 	setToActivate := r.Intn(max-min) + min
 
 	for i := 0; i < setToActivate; {
-		setIndex := r.Intn(ed.Stats.TotalSetsNum)
-		if !Sets[setIndex].Enabled {
-			Sets[setIndex].Enabled = true
+		setIndex := available[r.Intn(len(available))]
+		if !enabled[setIndex] {
+			enabled[setIndex] = true
 			ed.Stats.EnabledSetsNum++
 			ed.Stats.EnabledSets = append(ed.Stats.EnabledSets, Sets[setIndex].Name)
 			i++
@@ -163,6 +224,23 @@ func (ed *EventsData) EnabledRandomSets(percentage types.Interval, utils types.U
 	return nil
 }
 
+// DateSet is an enabled set that looks at the calendar, with the minutes it verifies in a day
+type DateSet struct {
+	Name    string
+	Minutes []string
+}
+
+// DateSets returns the enabled sets that look at the calendar, with the minutes they verify in the day (a date)
+func (ed *EventsData) DateSets(date time.Time) []DateSet {
+	dateSets := make([]DateSet, 0)
+	for _, set := range Sets {
+		if set.Typology == "date" && slices.Contains(ed.Stats.EnabledSets, set.Name) {
+			dateSets = append(dateSets, DateSet{set.Name, set.Minutes(date)})
+		}
+	}
+	return dateSets
+}
+
 func (ed *EventsData) AssignRandomEffects(utils types.Utils, effects ...structs.EffectPresence) {
 	var r *rand.Rand
 	multiplierEffectsNames, additiveEffectsNames := make([]string, 0), make([]string, 0)
@@ -266,7 +344,35 @@ func RemoveValue(s []string, value string) []string {
 	return newS
 }
 
-func (ed *EventsData) SaveOnFile(utils types.Utils) {
+// RollOver adds the points of the enabled events nobody claimed to the jackpot, returning them
+func (ed *EventsData) RollOver() int {
+	points := 0
+	for _, event := range ed.Map {
+		if event.Enabled && event.Activation == nil {
+			points += event.Points
+		}
+	}
+	ed.Jackpot += points
+	return points
+}
+
+// Snapshot returns a deep copy of the events (without the ones of the next day), that the next resets don't change
+func (ed *EventsData) Snapshot() (*EventsData, error) {
+	day := *ed
+	day.Next, day.NextReset = nil, nil
+	data, err := json.Marshal(day)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := new(EventsData)
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// SaveOnFile saves the sets and the events of every chat
+func SaveOnFile(utils types.Utils) {
 	//Save Sets
 	SetsJson = Sets.ToJsonSlice()
 	setsFile, err := json.MarshalIndent(SetsJson, "", " ")
@@ -298,26 +404,22 @@ func (ed *EventsData) SaveOnFile(utils types.Utils) {
 }
 
 func (ed *EventsData) WriteResetMessage(writeMsgData *types.WriteMessageData, utils types.Utils) {
-	// Generate text
-	text := "Gli eventi son stati resettati.\nEcco alcune informazioni:\n\n"
-	text += fmt.Sprintf("Schemi: %v/%v\nEventi: %v/%v\nPunti ottenibili: %v\n", ed.Stats.EnabledSetsNum, ed.Stats.TotalSetsNum, ed.Stats.EnabledEventsNum, ed.Stats.TotalEventsNum, ed.Stats.EnabledPointsSum)
-
-	text += fmt.Sprintf("\nSchemi Attivi (%v):\n", ed.Stats.EnabledSetsNum)
-	for _, setName := range ed.Stats.EnabledSets {
-		text += fmt.Sprintf(" | %q\n", setName)
-	}
-
-	text += fmt.Sprintf("\nEffetti Attivi (%v):\n", ed.Stats.EnabledEffectsNum)
-	for effectName, effectNum := range ed.Stats.EnabledEffects {
-		text += fmt.Sprintf(" | %q = %v\n", effectName, effectNum)
-	}
-
-	text += "\nBuona fortuna!"
+	// Generate text in the language of the chat (with the effects only if they are revealed in the chat)
+	text := utils.Catalog.Text(writeMsgData.Settings.Language, "reset.recap", struct {
+		EventsStats
+		RevealEffects bool
+	}{ed.Stats, writeMsgData.Settings.RevealEffects})
 
 	// Send message
 	message := tgbotapi.NewMessage(writeMsgData.ChatID, text)
 	if writeMsgData.ReplyMessageID != -1 {
 		message.ReplyToMessageID = writeMsgData.ReplyMessageID
 	}
-	writeMsgData.Bot.Send(message)
+	msg, err := writeMsgData.Bot.Send(message)
+	if err != nil {
+		utils.Logger.WithFields(logrus.Fields{
+			"err": err,
+			"msg": msg,
+		}).Error("Error while sending message")
+	}
 }
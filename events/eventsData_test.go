@@ -0,0 +1,24 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MoraGames/clockyuwu/structs"
+)
+
+func Test_RollOver(t *testing.T) {
+	ed := &EventsData{Map: make(EventsMap), Jackpot: 3}
+	for _, name := range []string{"01:23", "12:34", "13:31", "23:45"} {
+		eventTime, _ := time.Parse("15:04", name)
+		ed.Map[name] = NewEvent(eventTime, time.Time{}, nil)
+		ed.Map[name].Enabled, ed.Map[name].Points = true, 2
+	}
+	ed.Map["12:34"].Activate(structs.NewUser(1, "alice"), time.Now(), time.Now(), 2)
+	ed.Map["23:45"].Enabled = false
+
+	// Only the enabled events nobody claimed go to the jackpot
+	if points := ed.RollOver(); points != 4 || ed.Jackpot != 7 {
+		t.Errorf("The rollover should add 4 points to the jackpot of 3, got %v (jackpot %v)", points, ed.Jackpot)
+	}
+}
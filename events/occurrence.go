@@ -0,0 +1,51 @@
+package events
+
+import (
+	"sort"
+	"time"
+)
+
+// Occurrences returns the instants of the day (a date in the location) when the wall clock shows hour:minute.
+// They are none for the minutes skipped by a DST change, two for the minutes repeated by it, and one otherwise.
+func Occurrences(day time.Time, hour, minute int, location *time.Location) []time.Time {
+	year, month, date := day.Date()
+	wall := time.Date(year, month, date, hour, minute, 0, 0, time.UTC)
+
+	// A day has at most one DST change, so the offsets of its start and of its end are all the ones in use
+	start := time.Date(year, month, date, 0, 0, 0, 0, location)
+	end := time.Date(year, month, date+1, 0, 0, 0, 0, location).Add(-time.Nanosecond)
+
+	occurrences := make([]time.Time, 0, 2)
+	for _, t := range []time.Time{start, end} {
+		_, offset := t.Zone()
+		instant := wall.Add(-time.Duration(offset) * time.Second).In(location)
+		if instant.Hour() != hour || instant.Minute() != minute || instant.Day() != date {
+			continue
+		}
+		if len(occurrences) == 1 && occurrences[0].Equal(instant) {
+			continue
+		}
+		occurrences = append(occurrences, instant)
+	}
+
+	sort.Slice(occurrences, func(i, j int) bool { return occurrences[i].Before(occurrences[j]) })
+	return occurrences
+}
+
+// NextOccurrences returns the occurrences of hour:minute in the first day (in the location) when the wall clock reaches it from the minute of the instant.
+// The minute of the instant is still in its day (a reset at 00:00:00.x dates the 00:00 events today). They are empty if that day skips the minute.
+func NextOccurrences(after time.Time, hour, minute int, location *time.Location) []time.Time {
+	local := after.Truncate(time.Minute).In(location)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	if local.Hour()*60+local.Minute() > hour*60+minute {
+		day = day.AddDate(0, 0, 1)
+	}
+	return Occurrences(day, hour, minute, location)
+}
+
+// IsFirstOccurrence reports if the instant is in the first occurrence of its minute (false in the second occurrence of a minute repeated by a DST change)
+func IsFirstOccurrence(instant time.Time, location *time.Location) bool {
+	local := instant.In(location)
+	occurrences := Occurrences(local, local.Hour(), local.Minute(), location)
+	return len(occurrences) < 2 || instant.Truncate(time.Minute).Equal(occurrences[0])
+}
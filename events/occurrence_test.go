@@ -0,0 +1,58 @@
+package events
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_Occurrences(t *testing.T) {
+	rome, err := time.LoadLocation("Europe/Rome")
+	if err != nil {
+		t.Skip("Timezone database not available:", err)
+	}
+
+	tests := []struct {
+		day          time.Time
+		hour, minute int
+		expected     []string
+	}{
+		// A normal day
+		{time.Date(2024, time.March, 30, 0, 0, 0, 0, time.UTC), 2, 30, []string{"2024-03-30T01:30:00Z"}},
+		// The minutes from 02:00 to 02:59 are skipped when the DST starts
+		{time.Date(2024, time.March, 31, 0, 0, 0, 0, time.UTC), 2, 30, []string{}},
+		{time.Date(2024, time.March, 31, 0, 0, 0, 0, time.UTC), 3, 0, []string{"2024-03-31T01:00:00Z"}},
+		// ... and repeated when it ends
+		{time.Date(2024, time.October, 27, 0, 0, 0, 0, time.UTC), 2, 30, []string{"2024-10-27T00:30:00Z", "2024-10-27T01:30:00Z"}},
+		{time.Date(2024, time.October, 27, 0, 0, 0, 0, time.UTC), 12, 34, []string{"2024-10-27T11:34:00Z"}},
+	}
+	for _, test := range tests {
+		occurrences := Occurrences(test.day, test.hour, test.minute, rome)
+		if len(occurrences) != len(test.expected) {
+			t.Errorf("%v %02d:%02d should occur %v times, got %v", test.day.Format("2006-01-02"), test.hour, test.minute, len(test.expected), occurrences)
+			continue
+		}
+		for i, occurrence := range occurrences {
+			if occurrence.UTC().Format(time.RFC3339) != test.expected[i] {
+				t.Errorf("%v %02d:%02d occurrence %v should be %v, got %v", test.day.Format("2006-01-02"), test.hour, test.minute, i, test.expected[i], occurrence.UTC())
+			}
+		}
+	}
+
+	// Only the first occurrence of a repeated minute counts
+	first := time.Date(2024, time.October, 27, 0, 30, 15, 0, time.UTC)
+	if !IsFirstOccurrence(first, rome) || IsFirstOccurrence(first.Add(time.Hour), rome) {
+		t.Error("Only the first 02:30 of the end of the DST should be the first occurrence")
+	}
+
+	// The day of an event is the first one reaching its minute after the reset
+	reset := time.Date(2024, time.March, 30, 23, 58, 0, 0, rome)
+	if occurrences := NextOccurrences(reset, 2, 30, rome); len(occurrences) != 0 {
+		t.Errorf("The 02:30 after the reset should be skipped, got %v", occurrences)
+	}
+	if occurrences := NextOccurrences(reset, 23, 59, rome); len(occurrences) != 1 || occurrences[0].Day() != 30 {
+		t.Errorf("The 23:59 after the reset should be the same day, got %v", occurrences)
+	}
+	if occurrences := NextOccurrences(reset.Add(300*time.Millisecond), 23, 58, rome); len(occurrences) != 1 || occurrences[0].Day() != 30 {
+		t.Errorf("The minute of the reset should be the same day, got %v", occurrences)
+	}
+}
@@ -0,0 +1,104 @@
+package events
+
+import (
+	"sort"
+	"time"
+)
+
+type (
+	// DayRecap is the recap of the events of a day, made before they are reset
+	DayRecap struct {
+		Date               string
+		EnabledEventsNum   int
+		ActivatedEventsNum int
+		MissedEvents       []string
+		EarnedPointsSum    int
+		TopScorers         []ScorerRecap
+		BiggestWin         *EventRecap `json:",omitempty"`
+		FastestReaction    *EventRecap `json:",omitempty"`
+		NegativeVictims    []EventRecap
+	}
+
+	// ScorerRecap contains the events won by a user in a day
+	ScorerRecap struct {
+		UserID   int64
+		UserName string
+		Wins     int
+		Points   int
+	}
+
+	// EventRecap is an activation of an event worth mentioning in the recap
+	EventRecap struct {
+		EventName string
+		UserID    int64
+		UserName  string
+		Points    int
+		Delay     time.Duration
+	}
+)
+
+// Recap returns the recap of the events of the day, with the top scorers sorted by points (then by wins and name)
+func (ed *EventsData) Recap(date string) DayRecap {
+	recap := DayRecap{Date: date, MissedEvents: make([]string, 0), TopScorers: make([]ScorerRecap, 0), NegativeVictims: make([]EventRecap, 0)}
+	scorers := make(map[int64]*ScorerRecap)
+
+	for _, name := range ed.Keys {
+		event, ok := ed.Map[name]
+		if !ok || !event.Enabled {
+			continue
+		}
+		recap.EnabledEventsNum++
+		if event.Activation == nil || event.Activation.ActivatedBy == nil {
+			recap.MissedEvents = append(recap.MissedEvents, event.Name)
+			continue
+		}
+		recap.ActivatedEventsNum++
+		recap.EarnedPointsSum += event.Activation.EarnedPoints
+
+		user := event.Activation.ActivatedBy
+		scorer, ok := scorers[user.TelegramID]
+		if !ok {
+			scorer = &ScorerRecap{UserID: user.TelegramID, UserName: user.UserName}
+			scorers[user.TelegramID] = scorer
+		}
+		scorer.Wins++
+		scorer.Points += event.Activation.EarnedPoints
+
+		activation := EventRecap{
+			EventName: event.Name,
+			UserID:    user.TelegramID,
+			UserName:  user.UserName,
+			Points:    event.Activation.EarnedPoints,
+			Delay:     event.Activation.ActivatedAt.Sub(event.Activation.ArrivedAt.Truncate(event.Precision())),
+		}
+		if recap.BiggestWin == nil || activation.Points > recap.BiggestWin.Points {
+			biggest := activation
+			recap.BiggestWin = &biggest
+		}
+		if recap.FastestReaction == nil || activation.Delay < recap.FastestReaction.Delay {
+			fastest := activation
+			recap.FastestReaction = &fastest
+		}
+		for _, effect := range event.Effects {
+			if effect.Key == "*" && effect.Value < 0 {
+				recap.NegativeVictims = append(recap.NegativeVictims, activation)
+				break
+			}
+		}
+	}
+
+	for _, scorer := range scorers {
+		recap.TopScorers = append(recap.TopScorers, *scorer)
+	}
+	sort.Slice(recap.TopScorers, func(i, j int) bool {
+		a, b := recap.TopScorers[i], recap.TopScorers[j]
+		if a.Points != b.Points {
+			return a.Points > b.Points
+		}
+		if a.Wins != b.Wins {
+			return a.Wins > b.Wins
+		}
+		return a.UserName < b.UserName
+	})
+	return recap
+}
@@ -0,0 +1,49 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MoraGames/clockyuwu/structs"
+)
+
+func Test_Recap(t *testing.T) {
+	alice := structs.NewUser(1, "alice")
+	bob := structs.NewUser(2, "bob")
+	day := time.Date(2024, time.March, 30, 0, 0, 0, 0, time.UTC)
+
+	ed := &EventsData{Map: make(EventsMap)}
+	add := func(name string, enabled bool, effects []*structs.Effect, by *structs.User, delay time.Duration, points int) {
+		eventTime, _ := time.Parse("15:04", name)
+		event := NewEvent(eventTime, time.Time{}, nil)
+		event.Enabled, event.Effects = enabled, effects
+		if by != nil {
+			arrivedAt := day.Add(time.Duration(eventTime.Hour())*time.Hour + time.Duration(eventTime.Minute())*time.Minute)
+			event.Activate(by, arrivedAt.Add(delay), arrivedAt, points)
+		}
+		ed.Map[name] = event
+		ed.Keys = append(ed.Keys, name)
+	}
+	add("01:23", true, nil, alice, 3*time.Second, 2)
+	add("12:34", true, []*structs.Effect{structs.TripleNegativePoints}, bob, time.Second, -3)
+	add("13:31", true, nil, nil, 0, 0)
+	add("22:22", true, []*structs.Effect{structs.TriplePositivePoints}, alice, 5*time.Second, 6)
+	add("23:45", false, nil, nil, 0, 0)
+
+	recap := ed.Recap("2024-03-30")
+	if recap.EnabledEventsNum != 4 || recap.ActivatedEventsNum != 3 || len(recap.MissedEvents) != 1 || recap.MissedEvents[0] != "13:31" {
+		t.Errorf("Unexpected events count: %+v", recap)
+	}
+	if recap.EarnedPointsSum != 5 || len(recap.TopScorers) != 2 || recap.TopScorers[0].UserName != "alice" || recap.TopScorers[0].Points != 8 || recap.TopScorers[0].Wins != 2 {
+		t.Errorf("Unexpected scorers: %+v", recap.TopScorers)
+	}
+	if recap.BiggestWin == nil || recap.BiggestWin.EventName != "22:22" {
+		t.Errorf("Unexpected biggest win: %+v", recap.BiggestWin)
+	}
+	if recap.FastestReaction == nil || recap.FastestReaction.UserName != "bob" || recap.FastestReaction.Delay != time.Second {
+		t.Errorf("Unexpected fastest reaction: %+v", recap.FastestReaction)
+	}
+	if len(recap.NegativeVictims) != 1 || recap.NegativeVictims[0].EventName != "12:34" || recap.NegativeVictims[0].Points != -3 {
+		t.Errorf("Unexpected negative victims: %+v", recap.NegativeVictims)
+	}
+}
@@ -1,81 +1,137 @@
 package events
 
-import "github.com/MoraGames/clockyuwu/pkg/types"
+import (
+	"slices"
+	"time"
+
+	"github.com/MoraGames/clockyuwu/pkg/types"
+)
 
 type SetSlice []*Set
 type SetJsonSlice []*SetJson
-type FuncMap map[string]func(h1, h2, m1, m2 int) bool
+type FuncMap map[string]func(ctx SetContext) bool
 type Set struct {
 	Name     string
 	Typology string
-	Enabled  bool
-	Verify   func(h1, h2, m1, m2 int) bool
+	Verify   func(ctx SetContext) bool
 }
 type SetJson struct {
 	Name     string
 	Typology string
-	Enabled  bool
+}
+
+// SetContext is what the sets verify: the digits of the time of an event, and the date it occurs on (zero if it's unknown).
+// The "standard" sets look only at the digits, the "date" ones at the date too.
+// The "seconds" sets verify only the events at exact seconds, the other ones only the events at minutes.
+// Enabled are the names of the sets enabled in the chat of the event, the ones that give it points.
+type SetContext struct {
+	H1, H2, M1, M2 int
+	S1, S2         int
+	Seconds        bool
+	Date           time.Time
+	Enabled        []string
 }
 
 var (
 	SetsFunctions = FuncMap{
-		"aa:aa": aaaa,
-		"xa:aa": xaaa,
-		"ab:ab": abab,
-		"ab:ba": abba,
-		"ab:cd": abcd,
-		"xa:bc": xabc,
-		"dc:ba": dcba,
-		"xc:ba": xcba,
-		"ac:eg": aceg,
-		"xa:ce": xace,
-		"xe:ca": xeca,
-		"n:2*n": n2n,
-	}
-	Sets = SetSlice{
-		{"aa:aa", "standard", false, aaaa},
-		{"xa:aa", "standard", false, xaaa},
-		{"ab:ab", "standard", false, abab},
-		{"ab:ba", "standard", false, abba},
-		{"ab:cd", "standard", false, abcd},
-		{"xa:bc", "standard", false, xabc},
-		{"dc:ba", "standard", false, dcba},
-		{"xc:ba", "standard", false, xcba},
-		{"ac:eg", "standard", false, aceg},
-		{"xa:ce", "standard", false, xace},
-		{"xe:ca", "standard", false, xeca},
-		{"n:2*n", "standard", false, n2n},
+		"aa:aa":    digits(aaaa),
+		"xa:aa":    digits(xaaa),
+		"ab:ab":    digits(abab),
+		"ab:ba":    digits(abba),
+		"ab:cd":    digits(abcd),
+		"xa:bc":    digits(xabc),
+		"dc:ba":    digits(dcba),
+		"xc:ba":    digits(xcba),
+		"ac:eg":    digits(aceg),
+		"xa:ce":    digits(xace),
+		"xe:ca":    digits(xeca),
+		"n:2*n":    digits(n2n),
+		"dd:mm":    ddmm,
+		"mm:dd":    mmdd,
+		"xx:dd":    xxdd,
+		"aa:aa:aa": aaaaaa,
+		"ab:ab:ab": ababab,
+		"ab:ba:ab": abbaab,
+		"ab:cd:ef": abcdef,
 	}
+	Sets     = DefaultSets()
 	SetsJson = SetJsonSlice{}
 
 	AssignSetsFromSetsJson = func(utils types.Utils) {
 		Sets = SetsJson.ToSlice()
+		// The sets added after the file was saved are added too
+		for _, set := range DefaultSets() {
+			if !slices.ContainsFunc(Sets, func(s *Set) bool { return s.Name == set.Name }) {
+				Sets = append(Sets, set)
+			}
+		}
 	}
 	AssignSetsWithDefault = func(utils types.Utils) {
-		Sets = SetSlice{
-			{"aa:aa", "standard", false, aaaa},
-			{"xa:aa", "standard", false, xaaa},
-			{"ab:ab", "standard", false, abab},
-			{"ab:ba", "standard", false, abba},
-			{"ab:cd", "standard", false, abcd},
-			{"xa:bc", "standard", false, xabc},
-			{"dc:ba", "standard", false, dcba},
-			{"xc:ba", "standard", false, xcba},
-			{"ac:eg", "standard", false, aceg},
-			{"xa:ce", "standard", false, xace},
-			{"xe:ca", "standard", false, xeca},
-			{"n:2*n", "standard", false, n2n},
-		}
+		Sets = DefaultSets()
 	}
 )
 
+// DefaultSets returns all the sets
+func DefaultSets() SetSlice {
+	return SetSlice{
+		{"aa:aa", "standard", digits(aaaa)},
+		{"xa:aa", "standard", digits(xaaa)},
+		{"ab:ab", "standard", digits(abab)},
+		{"ab:ba", "standard", digits(abba)},
+		{"ab:cd", "standard", digits(abcd)},
+		{"xa:bc", "standard", digits(xabc)},
+		{"dc:ba", "standard", digits(dcba)},
+		{"xc:ba", "standard", digits(xcba)},
+		{"ac:eg", "standard", digits(aceg)},
+		{"xa:ce", "standard", digits(xace)},
+		{"xe:ca", "standard", digits(xeca)},
+		{"n:2*n", "standard", digits(n2n)},
+		{"dd:mm", "date", ddmm},
+		{"mm:dd", "date", mmdd},
+		{"xx:dd", "date", xxdd},
+		{"aa:aa:aa", "seconds", aaaaaa},
+		{"ab:ab:ab", "seconds", ababab},
+		{"ab:ba:ab", "seconds", abbaab},
+		{"ab:cd:ef", "seconds", abcdef},
+	}
+}
+
+// NewSetContext returns the context of the event at the time (only the hour and the minute are used), occurring on the date
+func NewSetContext(eventTime, date time.Time) SetContext {
+	h1, h2, m1, m2 := SplitTime(eventTime)
+	return SetContext{H1: h1, H2: h2, M1: m1, M2: m2, Date: date}
+}
+
+// NewSecondsSetContext returns the context of the event at the exact second of the time, occurring on the date
+func NewSecondsSetContext(eventTime, date time.Time) SetContext {
+	ctx := NewSetContext(eventTime, date)
+	ctx.S1, ctx.S2, ctx.Seconds = eventTime.Second()/10, eventTime.Second()%10, true
+	return ctx
+}
+
+// Applies reports if the set verifies the context (the "seconds" sets only the events at exact seconds, the others only the events at minutes)
+func (s *Set) Applies(ctx SetContext) bool {
+	return (s.Typology == "seconds") == ctx.Seconds && s.Verify(ctx)
+}
+
+// Minutes returns the minutes of the day (a date) verified by the set
+func (s *Set) Minutes(date time.Time) []string {
+	minutes := make([]string, 0)
+	for i := 0; i < 24*60; i++ {
+		eventTime := time.Date(0, time.January, 1, i/60, i%60, 0, 0, time.UTC)
+		if s.Verify(NewSetContext(eventTime, date)) {
+			minutes = append(minutes, eventTime.Format("15:04"))
+		}
+	}
+	return minutes
+}
+
 func (s SetSlice) ToJsonSlice() SetJsonSlice {
 	jsonSlice := make(SetJsonSlice, 0)
 	for _, set := range s {
 		jsonSlice = append(jsonSlice, &SetJson{
 			Name:     set.Name,
 			Typology: set.Typology,
-			Enabled:  set.Enabled,
 		})
 	}
 	return jsonSlice
@@ -87,13 +143,19 @@ func (sj SetJsonSlice) ToSlice() SetSlice {
 		slice = append(slice, &Set{
 			Name:     setjson.Name,
 			Typology: setjson.Typology,
-			Enabled:  setjson.Enabled,
 			Verify:   SetsFunctions[setjson.Name],
 		})
 	}
 	return slice
 }
 
+// digits adapts a verifier of the four digits of the time to the context
+func digits(verify func(h1, h2, m1, m2 int) bool) func(ctx SetContext) bool {
+	return func(ctx SetContext) bool {
+		return verify(ctx.H1, ctx.H2, ctx.M1, ctx.M2)
+	}
+}
+
 // aa:aa
 func aaaa(a, b, c, d int) bool {
 	return a == b && b == c && c == d
@@ -153,3 +215,38 @@ func xeca(_, b, c, d int) bool {
 func n2n(a, b, c, d int) bool {
 	return 2*((a*10)+b) == (c*10)+d
 }
+
+// dd:mm (the hour is the day and the minute is the month)
+func ddmm(ctx SetContext) bool {
+	return !ctx.Date.IsZero() && ctx.H1*10+ctx.H2 == ctx.Date.Day() && ctx.M1*10+ctx.M2 == int(ctx.Date.Month())
+}
+
+// mm:dd (the hour is the month and the minute is the day)
+func mmdd(ctx SetContext) bool {
+	return !ctx.Date.IsZero() && ctx.H1*10+ctx.H2 == int(ctx.Date.Month()) && ctx.M1*10+ctx.M2 == ctx.Date.Day()
+}
+
+// ??:dd (the minute is the day)
+func xxdd(ctx SetContext) bool {
+	return !ctx.Date.IsZero() && ctx.M1*10+ctx.M2 == ctx.Date.Day()
+}
+
+// aa:aa:aa
+func aaaaaa(ctx SetContext) bool {
+	return aaaa(ctx.H1, ctx.H2, ctx.M1, ctx.M2) && ctx.S1 == ctx.M2 && ctx.S2 == ctx.S1
+}
+
+// ab:ab:ab
+func ababab(ctx SetContext) bool {
+	return abab(ctx.H1, ctx.H2, ctx.M1, ctx.M2) && ctx.S1 == ctx.H1 && ctx.S2 == ctx.H2
+}
+
+// ab:ba:ab
+func abbaab(ctx SetContext) bool {
+	return abba(ctx.H1, ctx.H2, ctx.M1, ctx.M2) && ctx.S1 == ctx.H1 && ctx.S2 == ctx.H2
+}
+
+// ab:cd:ef
+func abcdef(ctx SetContext) bool {
+	return abcd(ctx.H1, ctx.H2, ctx.M1, ctx.M2) && ctx.S1 == ctx.M2+1 && ctx.S2 == ctx.S1+1
+}
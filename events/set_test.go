@@ -0,0 +1,128 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MoraGames/clockyuwu/config"
+	"github.com/MoraGames/clockyuwu/pkg/types"
+)
+
+func Test_DateSets(t *testing.T) {
+	may12 := time.Date(2024, time.May, 12, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		set     string
+		minutes []string
+	}{
+		{"dd:mm", []string{"12:05"}},
+		{"mm:dd", []string{"05:12"}},
+		{"xx:dd", []string{"00:12", "01:12", "02:12"}},
+	}
+	for _, test := range tests {
+		set := &Set{Name: test.set, Verify: SetsFunctions[test.set]}
+		minutes := set.Minutes(may12)
+		if len(minutes) < len(test.minutes) {
+			t.Errorf("The set %v should verify %v on the 12th of May, got %v", test.set, test.minutes, minutes)
+			continue
+		}
+		for i, minute := range test.minutes {
+			if minutes[i] != minute {
+				t.Errorf("The set %v should verify %v on the 12th of May, got %v", test.set, test.minutes, minutes)
+			}
+		}
+		// Without a date the sets that look at the calendar verify nothing
+		if minutes := set.Minutes(time.Time{}); len(minutes) != 0 {
+			t.Errorf("The set %v should verify nothing without a date, got %v", test.set, minutes)
+		}
+	}
+}
+
+func Test_GenerateDateEvents(t *testing.T) {
+	sets := Sets
+	defer func() { Sets = sets }()
+	Sets = SetSlice{{"dd:mm", "date", ddmm}}
+	utils := types.Utils{Config: &config.Config{}}
+
+	// The events of a day are the ones of its date, and the next days change them
+	ed := &EventsData{Map: make(EventsMap), Stats: EventsStats{EnabledSets: []string{"dd:mm"}, EnabledEffects: make(map[string]int)}}
+	ed.generate(time.UTC, time.Date(2024, time.May, 11, 23, 59, 0, 0, time.UTC), utils)
+	if event, ok := ed.Map["12:05"]; !ok || !event.Enabled || event.Points != 1 || len(ed.Keys) != 1 {
+		t.Errorf("Only 12:05 should be enabled on the 12th of May, got %v", ed.Keys)
+	}
+	ed.Stats = EventsStats{EnabledSets: []string{"dd:mm"}, EnabledEffects: make(map[string]int)}
+	ed.generate(time.UTC, time.Date(2024, time.May, 12, 23, 59, 0, 0, time.UTC), utils)
+	if _, ok := ed.Map["13:05"]; !ok || len(ed.Map) != 1 || len(ed.Keys) != 1 || ed.Keys[0] != "13:05" || ed.Stats.EnabledEventsNum != 1 {
+		t.Errorf("Only 13:05 should be left on the 13th of May, got %v", ed.Keys)
+	}
+}
+
+func Test_SecondsSets(t *testing.T) {
+	tests := map[string]int{"aa:aa:aa": 3, "ab:ab:ab": 21, "ab:ba:ab": 13, "ab:cd:ef": 2}
+	for name, expected := range tests {
+		set := &Set{Name: name, Typology: "seconds", Verify: SetsFunctions[name]}
+		count := 0
+		for i := 0; i < 24*60*60; i++ {
+			if set.Applies(NewSecondsSetContext(time.Date(0, time.January, 1, i/3600, i/60%60, i%60, 0, time.UTC), time.Time{})) {
+				count++
+			}
+		}
+		if count != expected {
+			t.Errorf("The set %v should verify %v seconds of the day, got %v", name, expected, count)
+		}
+		// The sets of the seconds don't verify the minutes
+		if set.Applies(NewSetContext(time.Date(0, time.January, 1, 11, 11, 0, 0, time.UTC), time.Time{})) {
+			t.Errorf("The set %v should not verify the minutes", name)
+		}
+	}
+}
+
+func Test_GenerateSecondsEvents(t *testing.T) {
+	sets := Sets
+	defer func() { Sets = sets }()
+	Sets = SetSlice{{"aa:aa", "standard", SetsFunctions["aa:aa"]}, {"aa:aa:aa", "seconds", aaaaaa}}
+	from := time.Date(2024, time.May, 11, 23, 59, 0, 0, time.UTC)
+
+	// Without the hard mode there are only the events at minutes
+	ed := &EventsData{Map: make(EventsMap), Stats: EventsStats{EnabledSets: []string{"aa:aa", "aa:aa:aa"}, EnabledEffects: make(map[string]int)}}
+	ed.generate(time.UTC, from, types.Utils{Config: &config.Config{}})
+	if len(ed.Keys) != 3 {
+		t.Errorf("There should be 3 events at minutes, got %v", ed.Keys)
+	}
+
+	// The events at exact seconds follow the ones of their minute, with their points
+	conf := &config.Config{}
+	conf.Game.HardMode, conf.Game.HardModePoints = true, 5
+	ed.generate(time.UTC, from, types.Utils{Config: conf})
+	expected := []string{"00:00", "00:00:00", "11:11", "11:11:11", "22:22", "22:22:22"}
+	if len(ed.Keys) != len(expected) {
+		t.Fatalf("The events should be %v, got %v", expected, ed.Keys)
+	}
+	for i, name := range expected {
+		if ed.Keys[i] != name {
+			t.Errorf("The events should be %v, got %v", expected, ed.Keys)
+		}
+	}
+	if event := ed.Map["11:11:11"]; !event.Seconds || !event.Enabled || event.Points != 5 || event.Precision() != time.Second {
+		t.Errorf("Unexpected event at exact second: %+v", event)
+	}
+	if event := ed.Map["11:11"]; event.Seconds || event.Points != 1 {
+		t.Errorf("Unexpected event at minute: %+v", event)
+	}
+}
+
+func Test_ChatSets(t *testing.T) {
+	sets := Sets
+	defer func() { Sets = sets }()
+	Sets = SetSlice{{"aa:aa", "standard", SetsFunctions["aa:aa"]}, {"ab:ab", "standard", SetsFunctions["ab:ab"]}}
+	utils := types.Utils{Config: &config.Config{}}
+	from := time.Date(2024, time.May, 11, 23, 59, 0, 0, time.UTC)
+
+	// Every chat has its own enabled sets
+	first := &EventsData{Map: make(EventsMap), Stats: EventsStats{EnabledSets: []string{"aa:aa"}, EnabledEffects: make(map[string]int)}}
+	second := &EventsData{Map: make(EventsMap), Stats: EventsStats{EnabledSets: []string{"ab:ab"}, EnabledEffects: make(map[string]int)}}
+	first.generate(time.UTC, from, utils)
+	second.generate(time.UTC, from, utils)
+	if !first.Map["11:11"].Enabled || first.Map["12:12"].Enabled || second.Map["11:11"].Enabled || !second.Map["12:12"].Enabled {
+		t.Errorf("The events should follow the sets of their chat, got %+v and %+v", first.Map, second.Map)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"time"
+
+	"github.com/MoraGames/clockyuwu/config"
+	"github.com/MoraGames/clockyuwu/history"
+	"github.com/MoraGames/clockyuwu/pkg/types"
+	"github.com/MoraGames/clockyuwu/structs"
+	"github.com/sirupsen/logrus"
+)
+
+// HandicapConditions are the conditions the handicaps of the configuration can use, by name.
+// They measure the player, returning false if the measure isn't available.
+var HandicapConditions = map[string]func(userID int64, utils types.Utils) (int, bool){
+	"leader_gap": func(userID int64, utils types.Utils) (int, bool) {
+		return PointsIndex.Gap(userID)
+	},
+	"next_gap": func(userID int64, utils types.Utils) (int, bool) {
+		above, ok := PointsIndex.Above(userID)
+		if !ok {
+			// Nobody is ahead of the leader
+			return 0, PointsIndex.Len() != 0
+		}
+		return above - Users[userID].TotalPoints, true
+	},
+	"percentile": func(userID int64, utils types.Utils) (int, bool) {
+		position, ok := PointsIndex.Position(userID)
+		if !ok {
+			return 0, false
+		}
+		return (position - 1) * 100 / PointsIndex.Len(), true
+	},
+	"days_absent": func(userID int64, utils types.Utils) (int, bool) {
+		if Timeline == nil {
+			return 0, false
+		}
+		claims, err := Timeline.Claims(userID)
+		if err != nil || len(claims) == 0 {
+			return 0, false
+		}
+		last := claims[len(claims)-1]
+		date, err := time.Parse(history.DateFormat, last.Date)
+		if err != nil {
+			return 0, false
+		}
+		// The dates of the claims are the ones of their chat, so today is the one of the chat too
+		now := utils.Clock.Now().In(GetSettings(last.ChatID, utils).Location())
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		return int(today.Sub(date).Hours() / 24), true
+	},
+}
+
+// AppliedHandicap is a handicap given to a player, with the measure that satisfied its condition
+type AppliedHandicap struct {
+	config.Handicap
+	Measure int
+}
+
+// Get the handicaps of the configuration whose conditions are satisfied by the player
+func UserHandicaps(userID int64, utils types.Utils) []AppliedHandicap {
+	applied := make([]AppliedHandicap, 0)
+	for _, handicap := range utils.Config.Handicaps {
+		condition, ok := HandicapConditions[handicap.Condition]
+		if !ok {
+			utils.Logger.WithFields(logrus.Fields{
+				"handicap":  handicap.Name,
+				"condition": handicap.Condition,
+			}).Warn("Handicap condition not found")
+			continue
+		}
+		measure, ok := condition(userID, utils)
+		if ok && measure >= handicap.Min && (handicap.Max == 0 || measure < handicap.Max) {
+			applied = append(applied, AppliedHandicap{handicap, measure})
+		}
+	}
+	return applied
+}
+
+// Get the effect given by the handicap
+func HandicapEffect(handicap config.Handicap) *structs.Effect {
+	return &structs.Effect{Name: handicap.Name, Scope: "User", Key: handicap.Key, Value: handicap.Value, Cap: handicap.Cap}
+}
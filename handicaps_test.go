@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MoraGames/clockyuwu/config"
+	"github.com/MoraGames/clockyuwu/history"
+	"github.com/MoraGames/clockyuwu/structs"
+)
+
+func Test_DaysAbsent_ChatTimezone(t *testing.T) {
+	// At 12:00 UTC it's already the next day in Auckland
+	utils := testUtils(&config.Config{Settings: config.Settings{Timezone: "UTC"}}, time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC))
+	auckland := "Pacific/Auckland"
+	Chats = map[int64]*structs.Chat{-2: {TelegramID: -2, Settings: structs.ChatSettings{Timezone: &auckland}}}
+	var err error
+	if Timeline, err = history.NewTimeline(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		Chats = make(map[int64]*structs.Chat)
+		Timeline = nil
+	}()
+
+	// The absence is counted in the timezone of the chat of the last claim
+	Timeline.Add(1, history.Claim{ChatID: -1, Date: "2024-03-31"})
+	Timeline.Add(2, history.Claim{ChatID: -2, Date: "2024-03-31"})
+	for userID, expected := range map[int64]int{1: 0, 2: 1} {
+		if days, ok := HandicapConditions["days_absent"](userID, utils); !ok || days != expected {
+			t.Errorf("User %v should be absent for %v days, got %v (%v)", userID, expected, days, ok)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/MoraGames/clockyuwu/events"
+)
+
+type (
+	// Day is a day of a chat kept in the history: its recap and its events, with activations, partecipations, effects and enabled sets
+	Day struct {
+		ChatID int64
+		Date   string
+		Recap  events.DayRecap
+		Events *events.EventsData `json:",omitempty"`
+	}
+
+	// Store keeps the history of the chats on files, one for every month of every chat ("<chat>-<yyyy-mm>.json" in the directory).
+	// It's not safe for concurrent use.
+	Store struct {
+		dir    string
+		months map[string][]*Day
+	}
+)
+
+// DateFormat is the format of the dates of the days
+const DateFormat = "2006-01-02"
+
+// New creates a store that keeps the history in the directory (created if it doesn't exist)
+func New(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir, months: make(map[string][]*Day)}, nil
+}
+
+// Add archives the day. If the chat and date are already archived (e.g. by a manual reset before the scheduled one) the two days are merged
+func (s *Store) Add(day *Day) error {
+	if len(day.Date) != len(DateFormat) {
+		return fmt.Errorf("date %q not valid", day.Date)
+	}
+	month, err := s.month(day.ChatID, day.Date[:7])
+	if err != nil {
+		return err
+	}
+
+	replaced := false
+	for i, archived := range month {
+		if archived.Date == day.Date {
+			month[i], replaced = merge(archived, day), true
+		}
+	}
+	if !replaced {
+		month = append(month, day)
+		sort.Slice(month, func(i, j int) bool { return month[i].Date < month[j].Date })
+	}
+	s.months[s.file(day.ChatID, day.Date[:7])] = month
+
+	return s.save(day.ChatID, day.Date[:7])
+}
+
+// merge returns the day archived again merged with the previous archive: the events activated in any of them are kept, the others are the latest ones.
+// The recap is made again from the merged events (the days without events are just replaced).
+func merge(archived, day *Day) *Day {
+	if archived.Events == nil || day.Events == nil {
+		return day
+	}
+
+	merged := *day.Events
+	merged.Map = make(events.EventsMap, len(archived.Events.Map)+len(day.Events.Map))
+	for name, event := range archived.Events.Map {
+		merged.Map[name] = event
+	}
+	for name, event := range day.Events.Map {
+		if previous, ok := merged.Map[name]; ok && previous.Activation != nil && event.Activation == nil {
+			continue
+		}
+		merged.Map[name] = event
+	}
+	merged.Keys = make(events.EventsKeys, 0, len(merged.Map))
+	for name := range merged.Map {
+		merged.Keys = append(merged.Keys, name)
+	}
+	sort.Strings(merged.Keys)
+
+	return &Day{ChatID: day.ChatID, Date: day.Date, Recap: merged.Recap(day.Date), Events: &merged}
+}
+
+// Get returns the archived day of the chat at the date
+func (s *Store) Get(chatID int64, date string) (*Day, bool) {
+	if len(date) != len(DateFormat) {
+		return nil, false
+	}
+	month, err := s.month(chatID, date[:7])
+	if err != nil {
+		return nil, false
+	}
+	for _, day := range month {
+		if day.Date == date {
+			return day, true
+		}
+	}
+	return nil, false
+}
+
+// Range returns the archived days of the chat from a date to another (both included), sorted by date.
+// It's the base of the rankings of longer periods, like weeks and months.
+func (s *Store) Range(chatID int64, from, to string) ([]*Day, error) {
+	start, err := time.Parse(DateFormat, from)
+	if err != nil {
+		return nil, err
+	}
+	end, err := time.Parse(DateFormat, to)
+	if err != nil {
+		return nil, err
+	}
+
+	days := make([]*Day, 0)
+	for month := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC); !month.After(end); month = month.AddDate(0, 1, 0) {
+		monthDays, err := s.month(chatID, month.Format("2006-01"))
+		if err != nil {
+			return nil, err
+		}
+		for _, day := range monthDays {
+			if day.Date >= from && day.Date <= to {
+				days = append(days, day)
+			}
+		}
+	}
+	return days, nil
+}
+
+func (s *Store) file(chatID int64, month string) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%d-%s.json", chatID, month))
+}
+
+// month returns the days of the month ("yyyy-mm") of the chat, loading them from the file the first time
+func (s *Store) month(chatID int64, month string) ([]*Day, error) {
+	file := s.file(chatID, month)
+	if days, ok := s.months[file]; ok {
+		return days, nil
+	}
+
+	days := make([]*Day, 0)
+	bytes, err := os.ReadFile(file)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		if err := json.Unmarshal(bytes, &days); err != nil {
+			return nil, err
+		}
+	}
+	s.months[file] = days
+	return days, nil
+}
+
+func (s *Store) save(chatID int64, month string) error {
+	file := s.file(chatID, month)
+	bytes, err := json.MarshalIndent(s.months[file], "", " ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, bytes, 0644)
+}
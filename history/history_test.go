@@ -0,0 +1,109 @@
+package history
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/MoraGames/clockyuwu/events"
+	"github.com/MoraGames/clockyuwu/structs"
+)
+
+func Test_Store(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, day := range []*Day{
+		{ChatID: -1, Date: "2024-03-31", Recap: events.DayRecap{ActivatedEventsNum: 1}},
+		{ChatID: -1, Date: "2024-03-30", Recap: events.DayRecap{ActivatedEventsNum: 2}},
+		{ChatID: -2, Date: "2024-03-30", Recap: events.DayRecap{ActivatedEventsNum: 3}},
+		// A day archived again replaces the previous one
+		{ChatID: -1, Date: "2024-03-31", Recap: events.DayRecap{ActivatedEventsNum: 4}},
+	} {
+		if err := s.Add(day); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Add(&Day{ChatID: -1, Date: "yesterday"}); err == nil {
+		t.Error("A day with a date not valid should not be archived")
+	}
+
+	// The history survives a restart
+	s, err = New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		chatID    int64
+		date      string
+		activated int
+		found     bool
+	}{
+		{-1, "2024-03-30", 2, true},
+		{-1, "2024-03-31", 4, true},
+		{-2, "2024-03-30", 3, true},
+		{-2, "2024-03-31", 0, false},
+		{-1, "2024-04-01", 0, false},
+	}
+	for _, test := range tests {
+		day, ok := s.Get(test.chatID, test.date)
+		if ok != test.found || (ok && day.Recap.ActivatedEventsNum != test.activated) {
+			t.Errorf("Get(%v, %q) should be %v with %v activated events, got %v %+v", test.chatID, test.date, test.found, test.activated, ok, day)
+		}
+	}
+
+	// The ranges can cross the months
+	if err := s.Add(&Day{ChatID: -1, Date: "2024-04-02"}); err != nil {
+		t.Fatal(err)
+	}
+	days, err := s.Range(-1, "2024-03-31", "2024-04-30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(days) != 2 || days[0].Date != "2024-03-31" || days[1].Date != "2024-04-02" {
+		t.Errorf("Unexpected days in the range: %+v", days)
+	}
+	if _, err := s.Range(-1, "march", "april"); err == nil {
+		t.Error("A range with dates not valid should fail")
+	}
+}
+
+func Test_Store_Merge(t *testing.T) {
+	s, err := New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := structs.NewUser(1, "alice")
+	snapshot := func(activated ...string) *events.EventsData {
+		ed := &events.EventsData{Map: make(events.EventsMap)}
+		for _, name := range []string{"01:23", "22:22"} {
+			eventTime, _ := time.Parse("15:04", name)
+			event := events.NewEvent(eventTime, time.Time{}, nil)
+			event.Enabled = true
+			if slices.Contains(activated, name) {
+				at := time.Date(2024, time.March, 30, eventTime.Hour(), eventTime.Minute(), 0, 0, time.UTC)
+				event.Activate(alice, at.Add(time.Second), at, 2)
+			}
+			ed.Map[name] = event
+			ed.Keys = append(ed.Keys, name)
+		}
+		return ed
+	}
+
+	// A manual reset archives the events played before it, the scheduled one the events played after it
+	for _, ed := range []*events.EventsData{snapshot("01:23"), snapshot("22:22")} {
+		if err := s.Add(&Day{ChatID: -1, Date: "2024-03-30", Recap: ed.Recap("2024-03-30"), Events: ed}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	day, ok := s.Get(-1, "2024-03-30")
+	if !ok || day.Events.Map["01:23"].Activation == nil || day.Events.Map["22:22"].Activation == nil || len(day.Events.Keys) != 2 {
+		t.Fatalf("The events of both the archives should be kept, got %+v", day)
+	}
+	if day.Recap.ActivatedEventsNum != 2 || day.Recap.EarnedPointsSum != 4 || len(day.Recap.MissedEvents) != 0 {
+		t.Errorf("The recap should be made from the merged events, got %+v", day.Recap)
+	}
+}
@@ -0,0 +1,202 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type (
+	// Claim is a claim of an event by a user: a win or a partecipation
+	Claim struct {
+		ChatID   int64
+		Date     string
+		EventKey string
+		Won      bool
+		Points   int
+		Effects  []string `json:",omitempty"`
+		Delay    time.Duration
+	}
+
+	// ClaimsStats are the metrics derived from the claims of a user
+	ClaimsStats struct {
+		BestDay               string
+		BestDayPoints         int
+		FavouriteMinute       string
+		FavouriteMinuteClaims int
+		AverageDelay          time.Duration
+		BestDelay             time.Duration
+	}
+
+	// Timeline keeps the claims of the users on files, one for every user ("<user>.jsonl" in the directory, a claim per line).
+	// It's not safe for concurrent use.
+	Timeline struct {
+		dir    string
+		claims map[int64][]Claim
+	}
+)
+
+// NewTimeline creates a timeline that keeps the claims in the directory (created if it doesn't exist)
+func NewTimeline(dir string) (*Timeline, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Timeline{dir: dir, claims: make(map[int64][]Claim)}, nil
+}
+
+// Add appends the claim to the timeline of the user
+func (tl *Timeline) Add(userID int64, claim Claim) error {
+	claims, err := tl.Claims(userID)
+	if err != nil {
+		return err
+	}
+	bytes, err := json.Marshal(claim)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(tl.file(userID), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if _, err := file.Write(append(bytes, '\n')); err != nil {
+		return err
+	}
+
+	tl.claims[userID] = append(claims, claim)
+	return nil
+}
+
+// Claims returns the claims of the user, from the oldest one, loading them from the file the first time
+func (tl *Timeline) Claims(userID int64) ([]Claim, error) {
+	if claims, ok := tl.claims[userID]; ok {
+		return claims, nil
+	}
+
+	claims := make([]Claim, 0)
+	file, err := os.Open(tl.file(userID))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		defer file.Close()
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			var claim Claim
+			if err := json.Unmarshal(scanner.Bytes(), &claim); err != nil {
+				return nil, err
+			}
+			claims = append(claims, claim)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+	tl.claims[userID] = claims
+	return claims, nil
+}
+
+// Recent returns the last n claims of the user, from the newest one
+func (tl *Timeline) Recent(userID int64, n int) ([]Claim, error) {
+	claims, err := tl.Claims(userID)
+	if err != nil {
+		return nil, err
+	}
+	recent := make([]Claim, 0, n)
+	for i := len(claims) - 1; i >= 0 && len(recent) < n; i-- {
+		recent = append(recent, claims[i])
+	}
+	return recent, nil
+}
+
+func (tl *Timeline) file(userID int64) string {
+	return filepath.Join(tl.dir, fmt.Sprintf("%d.jsonl", userID))
+}
+
+// Streak returns the number of consecutive days ending at the date (included) with at least one claim
+func Streak(claims []Claim, date string) int {
+	days := make(map[string]bool)
+	for _, claim := range claims {
+		days[claim.Date] = true
+	}
+	day, err := time.Parse(DateFormat, date)
+	if err != nil {
+		return 0
+	}
+	streak := 0
+	for days[day.Format(DateFormat)] {
+		streak++
+		day = day.AddDate(0, 0, -1)
+	}
+	return streak
+}
+
+// Stats derives the metrics from the claims (nil if there are none).
+// The ties of the best day and of the favourite minute go to the earliest one.
+func Stats(claims []Claim) *ClaimsStats {
+	if len(claims) == 0 {
+		return nil
+	}
+
+	stats := &ClaimsStats{BestDelay: claims[0].Delay}
+	dayPoints := make(map[string]int)
+	minuteClaims := make(map[string]int)
+	var delaySum time.Duration
+	for _, claim := range claims {
+		dayPoints[claim.Date] += claim.Points
+		minuteClaims[claim.EventKey]++
+		delaySum += claim.Delay
+		if claim.Delay < stats.BestDelay {
+			stats.BestDelay = claim.Delay
+		}
+	}
+	stats.AverageDelay = delaySum / time.Duration(len(claims))
+
+	for day, points := range dayPoints {
+		if stats.BestDay == "" || points > stats.BestDayPoints || (points == stats.BestDayPoints && day < stats.BestDay) {
+			stats.BestDay, stats.BestDayPoints = day, points
+		}
+	}
+	for minute, num := range minuteClaims {
+		if stats.FavouriteMinute == "" || num > stats.FavouriteMinuteClaims || (num == stats.FavouriteMinuteClaims && minute < stats.FavouriteMinute) {
+			stats.FavouriteMinute, stats.FavouriteMinuteClaims = minute, num
+		}
+	}
+	return stats
+}
+
+// Amend changes the last claim of the user that satisfies the match, rewriting the file of the user
+func (tl *Timeline) Amend(userID int64, match func(Claim) bool, amend func(*Claim)) error {
+	claims, err := tl.Claims(userID)
+	if err != nil {
+		return err
+	}
+	i := len(claims) - 1
+	for ; i >= 0 && !match(claims[i]); i-- {
+	}
+	if i < 0 {
+		return fmt.Errorf("claim not found in the timeline of %d", userID)
+	}
+	amended := append([]Claim(nil), claims...)
+	amend(&amended[i])
+
+	lines := make([]byte, 0)
+	for _, claim := range amended {
+		bytes, err := json.Marshal(claim)
+		if err != nil {
+			return err
+		}
+		lines = append(append(lines, bytes...), '\n')
+	}
+	if err := os.WriteFile(tl.file(userID), lines, 0644); err != nil {
+		return err
+	}
+	tl.claims[userID] = amended
+	return nil
+}
@@ -0,0 +1,99 @@
+package history
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_Timeline(t *testing.T) {
+	dir := t.TempDir()
+	tl, err := NewTimeline(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, claim := range []Claim{
+		{ChatID: -1, Date: "2024-03-30", EventKey: "12:12", Won: true, Points: 2, Effects: []string{"Mul +2"}, Delay: 3 * time.Second},
+		{ChatID: -1, Date: "2024-03-30", EventKey: "13:13", Points: 0, Delay: 5 * time.Second},
+		{ChatID: -1, Date: "2024-03-31", EventKey: "12:12", Won: true, Points: 1, Delay: time.Second},
+	} {
+		if err := tl.Add(1, claim); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The timeline survives a restart
+	tl, err = NewTimeline(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recent, err := tl.Recent(1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recent) != 2 || recent[0].Date != "2024-03-31" || recent[1].EventKey != "13:13" {
+		t.Errorf("Unexpected recent claims: %+v", recent)
+	}
+	if claims, _ := tl.Claims(1); len(claims) != 3 || claims[0].Effects[0] != "Mul +2" {
+		t.Errorf("Unexpected claims: %+v", claims)
+	}
+	if claims, _ := tl.Claims(2); len(claims) != 0 {
+		t.Errorf("A user who never claimed should have no claims, got %+v", claims)
+	}
+
+	// The last matching claim can be amended, and the change survives a restart
+	err = tl.Amend(1, func(c Claim) bool { return c.EventKey == "12:12" }, func(c *Claim) { c.Points += 1 })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tl.Amend(1, func(c Claim) bool { return c.EventKey == "14:14" }, func(c *Claim) {}); err == nil {
+		t.Error("Amending a claim not in the timeline should fail")
+	}
+	tl, _ = NewTimeline(dir)
+	if claims, _ := tl.Claims(1); len(claims) != 3 || claims[0].Points != 2 || claims[2].Points != 2 {
+		t.Errorf("Only the last claim of 12:12 should be amended, got %+v", claims)
+	}
+}
+
+func Test_Stats(t *testing.T) {
+	if Stats(nil) != nil {
+		t.Error("No claims should have no stats")
+	}
+
+	stats := Stats([]Claim{
+		{Date: "2024-03-30", EventKey: "13:13", Points: 2, Delay: 4 * time.Second},
+		{Date: "2024-03-31", EventKey: "12:12", Points: 1, Delay: 2 * time.Second},
+		{Date: "2024-03-31", EventKey: "13:13", Points: 1, Delay: 6 * time.Second},
+		{Date: "2024-04-01", EventKey: "12:12", Points: -3, Delay: 4 * time.Second},
+	})
+	expected := ClaimsStats{
+		BestDay:               "2024-03-30",
+		BestDayPoints:         2,
+		FavouriteMinute:       "12:12",
+		FavouriteMinuteClaims: 2,
+		AverageDelay:          4 * time.Second,
+		BestDelay:             2 * time.Second,
+	}
+	if *stats != expected {
+		t.Errorf("Stats should be %+v, got %+v", expected, *stats)
+	}
+}
+
+func Test_Streak(t *testing.T) {
+	claims := []Claim{{Date: "2024-02-27"}, {Date: "2024-02-28"}, {Date: "2024-02-29"}, {Date: "2024-02-29"}, {Date: "2024-03-01"}, {Date: "2024-03-03"}}
+	tests := []struct {
+		date   string
+		streak int
+	}{
+		{"2024-03-01", 4},
+		{"2024-02-28", 2},
+		{"2024-03-03", 1},
+		{"2024-03-02", 0},
+		{"today", 0},
+	}
+	for _, test := range tests {
+		if streak := Streak(claims, test.date); streak != test.streak {
+			t.Errorf("Streak(%q) should be %v, got %v", test.date, test.streak, streak)
+		}
+	}
+}
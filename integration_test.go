@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MoraGames/clockyuwu/config"
+	"github.com/MoraGames/clockyuwu/events"
+	"github.com/MoraGames/clockyuwu/history"
+	"github.com/MoraGames/clockyuwu/pkg/clock"
+	"github.com/MoraGames/clockyuwu/pkg/fakebot"
+	"github.com/MoraGames/clockyuwu/pkg/types"
+	"github.com/MoraGames/clockyuwu/structs"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// startTestBot runs the bot against a fake Bot API server, with a virtual clock, inside a temporary working directory.
+// The configuration can be changed by the configure functions.
+func startTestBot(t *testing.T, start time.Time, configure ...func(*config.Config)) (*fakebot.Server, *clock.Virtual, types.Utils) {
+	t.Helper()
+
+	inTempDir(t)
+	t.Setenv("TELEGRAM_ADMIN_ID", fmt.Sprint(testAdmin.ID))
+
+	conf := &config.Config{App: config.App{Name: "go-o-clock", Version: "test"}}
+	conf.Bot.PollingTimeout = 1
+	conf.Settings = config.Settings{Language: "it", Timezone: "Local", ResetTime: "00:00", RevealEffects: true}
+	conf.Outbox = config.Outbox{MaxRetries: 3, Backoff: 10 * time.Millisecond, MaxDelay: 5 * time.Second}
+	for _, f := range configure {
+		f(conf)
+	}
+	utils := testUtils(conf, start)
+	virtual := utils.Clock.(*clock.Virtual)
+
+	server := fakebot.NewServer()
+	bot, err := tgbotapi.NewBotAPIWithAPIEndpoint("TEST-TOKEN", server.Endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resetState(utils)
+	if History, err = history.New("files/history"); err != nil {
+		t.Fatal(err)
+	}
+	if Timeline, err = history.NewTimeline("files/timeline"); err != nil {
+		t.Fatal(err)
+	}
+
+	updates, stopUpdates := StartUpdates(bot, utils)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		run(ctx, utils, types.Data{Bot: NewOutbox(conf, bot, utils.Logger), Updates: updates})
+		close(done)
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		stopUpdates()
+		server.Close()
+	})
+	return server, virtual, utils
+}
+
+// waitForMessages waits for the n-th sendMessage call and returns its text
+func waitForMessages(t *testing.T, server *fakebot.Server, n int) string {
+	t.Helper()
+
+	calls := server.WaitForCalls("sendMessage", n, 5*time.Second)
+	if len(calls) < n {
+		t.Fatalf("Expected at least %v sent messages, got %v", n, len(calls))
+	}
+	return calls[n-1].Params.Get("text")
+}
+
+// pickEnabledEvent returns an enabled event, giving it the effects
+func pickEnabledEvent(t *testing.T, effects ...*structs.Effect) *events.Event {
+	t.Helper()
+
+	stateMutex.Lock()
+	defer stateMutex.Unlock()
+	chatEvents, ok := events.Events[testChat.ID]
+	if !ok {
+		t.Fatal("No events in the test chat")
+	}
+	for _, key := range chatEvents.Keys {
+		if event := chatEvents.Map[key]; event.Enabled {
+			event.Effects = effects
+			return event
+		}
+	}
+	t.Fatal("No enabled event after reset")
+	return nil
+}
+
+func Test_Integration_Day(t *testing.T) {
+	now := time.Now()
+	server, virtual, utils := startTestBot(t, time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local))
+
+	// Reset the events as the scheduled job does: the announcement, then the confirmation
+	server.PushMessage(testChat, testAdmin, "/reset events", now)
+	if text := waitForMessages(t, server, 2); text != Translate(testChat.ID, testAdmin.ID, "reset.events", nil, utils) {
+		t.Errorf("Unexpected reset confirmation: %q", text)
+	}
+
+	// Choose an enabled event and give it a known effect
+	event := pickEnabledEvent(t, structs.DoublePositivePoints)
+	points := event.Points * 2
+	at := time.Date(now.Year(), now.Month(), now.Day(), event.Time.Hour(), event.Time.Minute(), 5, 0, time.Local)
+
+	// Alice wins the event, Bob arrives too late: both get a reply
+	virtual.Set(at.Add(250 * time.Millisecond))
+	server.PushMessage(testChat, testAlice, event.Name, at)
+	waitForMessages(t, server, 3)
+	virtual.Set(at.Add(1500 * time.Millisecond))
+	server.PushMessage(testChat, testBob, event.Name, at.Add(time.Second))
+	waitForMessages(t, server, 4)
+
+	// Messages not matching an event are ignored, the commands are answered
+	server.PushMessage(testChat, testBob, "ciao", at.Add(2*time.Second))
+	server.PushMessage(testChat, testBob, "/ping", at.Add(3*time.Second))
+	if text := waitForMessages(t, server, 5); text != Translate(testChat.ID, testBob.ID, "ping", nil, utils) {
+		t.Errorf("Only the command should be answered, got %q", text)
+	}
+
+	stateMutex.Lock()
+	defer stateMutex.Unlock()
+	if alice := Users[testAlice.ID]; alice == nil || alice.TotalPoints != points || alice.TotalEventWins != 1 || alice.TotalEventPartecipations != 1 {
+		t.Errorf("Unexpected alice stats: %+v", alice)
+	}
+	if bob := Users[testBob.ID]; bob == nil || bob.TotalPoints != 0 || bob.TotalEventWins != 0 || bob.TotalEventPartecipations != 1 {
+		t.Errorf("Unexpected bob stats: %+v", bob)
+	}
+	if activation := event.Activation; activation == nil || activation.ActivatedBy.TelegramID != testAlice.ID || activation.EarnedPoints != points {
+		t.Errorf("The event should be activated by alice for %v points, got %+v", points, activation)
+	}
+	if _, err := os.Stat("files/users.json"); err != nil {
+		t.Errorf("Users should be saved on file: %v", err)
+	}
+}
+
+func Test_Integration_Lifecycle(t *testing.T) {
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 23, 50, 0, 0, time.Local)
+	server, virtual, utils := startTestBot(t, start, func(conf *config.Config) {
+		conf.Schedule.ClosingBefore = time.Minute
+	})
+
+	bot, err := tgbotapi.NewBotAPIWithAPIEndpoint("TEST-TOKEN", server.Endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheduler := clock.NewScheduler(virtual, time.Local)
+	if _, err := ScheduleLifecycle(scheduler, bot, testChat.ID, utils); err != nil {
+		t.Fatal(err)
+	}
+	scheduler.StartAsync()
+
+	// Nothing happens before the closing at 23:59
+	virtual.AdvanceScheduler(scheduler, start.Add(8*time.Minute))
+	if calls := server.Calls("sendMessage"); len(calls) != 0 {
+		t.Fatalf("No message should be sent before the closing, got %v", len(calls))
+	}
+
+	// The day closes with the summary, then the events are reset and announced at 00:00
+	virtual.AdvanceScheduler(scheduler, start.Add(15*time.Minute))
+	calls := server.Calls("sendMessage")
+	if len(calls) != 2 || calls[0].At.After(calls[1].At) || calls[1].Params.Get("chat_id") != fmt.Sprint(testChat.ID) {
+		t.Fatalf("The closing summary and the reset announcement should be sent, got %v", calls)
+	}
+	stateMutex.Lock()
+	day, archived := History.Get(testChat.ID, start.Format(history.DateFormat))
+	if archived && calls[0].Params.Get("text") != RecapText(day.Recap, GetSettings(testChat.ID, utils), utils) {
+		t.Errorf("The summary should be the recap of the archived day, got %q", calls[0].Params.Get("text"))
+	}
+	stateMutex.Unlock()
+	if !archived {
+		t.Error("The day should be archived at the reset")
+	}
+	scheduler.Stop()
+
+	// The bot is down for a day: at the restart the missed phases run once, in order
+	stateMutex.Lock()
+	Lifecycle = make(map[int64]map[string]time.Time)
+	file, err := os.ReadFile("files/lifecycle.json")
+	if err == nil {
+		err = json.Unmarshal(file, &Lifecycle)
+	}
+	stateMutex.Unlock()
+	if err != nil {
+		t.Fatal("The lifecycle should be saved on file:", err)
+	}
+
+	virtual.Set(start.Add(24*time.Hour + 30*time.Minute))
+	scheduler = clock.NewScheduler(virtual, time.Local)
+	if _, err := ScheduleLifecycle(scheduler, bot, testChat.ID, utils); err != nil {
+		t.Fatal(err)
+	}
+	scheduler.StartAsync()
+	defer scheduler.Stop()
+	virtual.AdvanceScheduler(scheduler, start.Add(24*time.Hour+31*time.Minute))
+	if calls = server.Calls("sendMessage"); len(calls) != 4 {
+		t.Errorf("The missed phases should run at the restart, got %v", calls)
+	}
+	stateMutex.Lock()
+	defer stateMutex.Unlock()
+	if _, archived := History.Get(testChat.ID, start.Add(24*time.Hour).Format(history.DateFormat)); !archived {
+		t.Error("The missed day should be archived at the restart")
+	}
+}
+
+func Test_Integration_Settings(t *testing.T) {
+	now := time.Now()
+	server, _, utils := startTestBot(t, now)
+	menuText := func() string {
+		stateMutex.Lock()
+		defer stateMutex.Unlock()
+		text, _ := SettingsMenu(testChat.ID, 0, "", utils)
+		return text
+	}
+	settings := func() types.Settings {
+		stateMutex.Lock()
+		defer stateMutex.Unlock()
+		return GetSettings(testChat.ID, utils)
+	}
+
+	// The menu shows the settings, with a button for every option
+	server.PushMessage(testChat, testBob, "/settings", now)
+	if text := waitForMessages(t, server, 1); text != menuText() {
+		t.Errorf("Unexpected settings menu: %q", text)
+	}
+	menu := server.Calls("sendMessage")[0]
+	if markup := menu.Params.Get("reply_markup"); !strings.Contains(markup, `"callback_data":"settings:reveal_effects"`) {
+		t.Fatalf("The menu should have the settings buttons, got %v", markup)
+	}
+	message := &tgbotapi.Message{MessageID: 1000, Chat: &testChat}
+
+	// A member of the group can't change the settings
+	server.PushCallbackQuery(message, testBob, "settings:reveal_effects:false")
+	answers := server.WaitForCalls("answerCallbackQuery", 1, 5*time.Second)
+	if len(answers) != 1 || answers[0].Params.Get("text") != Translate(testChat.ID, testBob.ID, "unauthorized", nil, utils) {
+		t.Fatalf("The unauthorized press should be answered, got %v", answers)
+	}
+	if !settings().RevealEffects {
+		t.Error("The settings should not be changed by a member")
+	}
+
+	// An administrator of the group can, and the menu is updated
+	server.SetChatMember(testChat.ID, testBob.ID, "administrator")
+	server.PushCallbackQuery(message, testBob, "settings:reveal_effects:false")
+	server.WaitForCalls("answerCallbackQuery", 2, 5*time.Second)
+	edits := server.WaitForCalls("editMessageText", 1, 5*time.Second)
+	if len(edits) != 1 || edits[0].Params.Get("message_id") != "1000" || edits[0].Params.Get("text") != menuText() {
+		t.Errorf("The menu should be updated, got %v", edits)
+	}
+	if got := settings(); got.RevealEffects || got.Language != "it" {
+		t.Errorf("Only the effects should be hidden, got %+v", got)
+	}
+
+	// The effects are hidden in the chat, and the values are validated
+	server.PushMessage(testChat, testAlice, "/list effects", now)
+	if text := waitForMessages(t, server, 2); text != Translate(testChat.ID, testAlice.ID, "settings.effects_hidden", nil, utils) {
+		t.Errorf("Unexpected hidden effects message: %q", text)
+	}
+	server.PushMessage(testChat, testBob, "/settings timezone Mars/Olympus", now)
+	waitForMessages(t, server, 3)
+	if timezone := settings().Timezone; timezone == "Mars/Olympus" {
+		t.Error("A not valid timezone should not be set")
+	}
+	server.PushMessage(testChat, testBob, "/settings timezone Europe/Rome", now)
+	waitForMessages(t, server, 4)
+	if timezone := settings().Timezone; timezone != "Europe/Rome" {
+		t.Errorf("The timezone should be updated, got %q", timezone)
+	}
+}
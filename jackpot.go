@@ -0,0 +1,61 @@
+package main
+
+import (
+	"github.com/MoraGames/clockyuwu/events"
+	"github.com/MoraGames/clockyuwu/pkg/cleanup"
+	"github.com/MoraGames/clockyuwu/pkg/types"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// Add the points of the events nobody claimed in the day to the jackpot of the chat
+func RollOverJackpot(chatID int64, ed *events.EventsData, utils types.Utils) {
+	points := ed.RollOver()
+	utils.Logger.WithFields(logrus.Fields{
+		"chat":    chatID,
+		"points":  points,
+		"jackpot": ed.Jackpot,
+	}).Debug("Jackpot rolled over")
+}
+
+// Check if the jackpot of the chat can be won with the event
+func JackpotInPlay(ed *events.EventsData, event *events.Event, utils types.Utils) bool {
+	return utils.Config.Game.Jackpot && ed.Jackpot != 0 && (utils.Config.Game.JackpotMinute == "" || utils.Config.Game.JackpotMinute == event.Name)
+}
+
+// Give the jackpot of the chat to the winner of the event, returning the points won
+func TakeJackpot(chatID int64, event *events.Event, utils types.Utils) int {
+	ed := ChatEvents(chatID, utils)
+	if !JackpotInPlay(ed, event, utils) {
+		return 0
+	}
+	jackpot := ed.Jackpot
+	ed.Jackpot = 0
+	event.Activation.EarnedPoints += jackpot
+	event.Activation.Jackpot = jackpot
+
+	// The empty pot is saved right away, so a restart can't give it again
+	events.SaveOnFile(utils)
+	return jackpot
+}
+
+// Announce the jackpot won by the user in the chat
+func AnnounceJackpot(claim Claim, jackpot int, utils types.Utils, data types.Data) {
+	// The announcement is kept in the chat, even if the replies to the claims are deleted
+	text := Translate(claim.ChatID, claim.UserID, "jackpot.won", map[string]any{
+		"User":    claim.UserName,
+		"Event":   claim.Text,
+		"Jackpot": jackpot,
+	}, utils)
+	if message, err := cleanup.Persistent(data.Bot).Send(tgbotapi.NewMessage(claim.ChatID, text)); err != nil {
+		utils.Logger.WithFields(logrus.Fields{
+			"err": err,
+			"msg": message,
+		}).Error("Error while sending message")
+	}
+
+	utils.Logger.WithFields(logrus.Fields{
+		"user":    claim.UserName,
+		"jackpot": jackpot,
+	}).Info("Jackpot won")
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MoraGames/clockyuwu/config"
+	"github.com/MoraGames/clockyuwu/events"
+	"github.com/MoraGames/clockyuwu/pkg/types"
+)
+
+func Test_JackpotInPlay(t *testing.T) {
+	event := &events.Event{Name: "12:34"}
+	tests := []struct {
+		enabled bool
+		minute  string
+		jackpot int
+		ok      bool
+	}{
+		{true, "", 7, true},
+		{true, "12:34", 7, true},
+		{true, "13:31", 7, false},
+		{true, "", 0, false},
+		{false, "", 7, false},
+	}
+	for _, test := range tests {
+		conf := &config.Config{}
+		conf.Game.Jackpot, conf.Game.JackpotMinute = test.enabled, test.minute
+		if ok := JackpotInPlay(&events.EventsData{Jackpot: test.jackpot}, event, types.Utils{Config: conf}); ok != test.ok {
+			t.Errorf("The jackpot of %v (enabled %v, minute %q) should be in play %v, got %v", test.jackpot, test.enabled, test.minute, test.ok, ok)
+		}
+	}
+}
+
+func Test_TakeJackpot(t *testing.T) {
+	inTempDir(t)
+	at := time.Date(2024, 3, 31, 12, 34, 1, 0, time.UTC)
+	conf := &config.Config{Settings: config.Settings{Timezone: "UTC"}}
+	conf.Game.Jackpot = true
+	utils := testUtils(conf, at)
+	resetState(utils)
+	ed := testEvents(-1, 3, "12:34", "13:31")
+	ed.Jackpot = 7
+	sender := &recordingSender{}
+
+	// Alice wins the next event and takes the jackpot, which is announced
+	ManageClaim(testClaim(-1, testAlice, "12:34", at, time.Second), utils, types.Data{Bot: sender})
+	if activation := ed.Map["12:34"].Activation; activation == nil || activation.EarnedPoints != 10 || activation.Jackpot != 7 || Users[testAlice.ID].TotalPoints != 10 {
+		t.Errorf("Alice should win 3 points and the jackpot of 7, got %+v", activation)
+	}
+	expected := Translate(-1, testAlice.ID, "jackpot.won", map[string]any{"User": testAlice.UserName, "Event": "12:34", "Jackpot": 7}, utils)
+	if texts := sender.texts(); len(texts) != 2 || texts[1] != expected {
+		t.Errorf("The jackpot should be announced with %q, got %q", expected, texts)
+	}
+
+	// The pot is empty for the next events
+	ManageClaim(testClaim(-1, testBob, "13:31", at.Add(57*time.Minute), time.Second), utils, types.Data{Bot: sender})
+	if ed.Jackpot != 0 || ed.Map["13:31"].Activation.Jackpot != 0 || Users[testBob.ID].TotalPoints != 3 {
+		t.Errorf("The jackpot should be won once, got %v left and %+v", ed.Jackpot, ed.Map["13:31"].Activation)
+	}
+}
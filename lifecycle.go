@@ -0,0 +1,308 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MoraGames/clockyuwu/events"
+	"github.com/MoraGames/clockyuwu/history"
+	"github.com/MoraGames/clockyuwu/pkg/types"
+	"github.com/go-co-op/gocron"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// Phase is a phase of the daily lifecycle of the events of a chat, run at an offset from the reset time of the chat
+type Phase struct {
+	Name string
+	// Offset from the reset time (negative for the phases before the reset)
+	Offset func(utils types.Utils) time.Duration
+	// Enabled reports if the phase is used
+	Enabled func(utils types.Utils) bool
+	// Run the phase for the chat, whose reset is at the instant
+	Run func(chatID int64, settings types.Settings, reset time.Time, sender types.Sender, utils types.Utils)
+}
+
+// Phases of the daily lifecycle, in the order they run when they are due at the same instant
+var Phases = []Phase{
+	{
+		Name:    "pregenerate",
+		Offset:  func(utils types.Utils) time.Duration { return -utils.Config.Schedule.PregenerateBefore },
+		Enabled: func(utils types.Utils) bool { return utils.Config.Schedule.PregenerateBefore > 0 },
+		Run: func(chatID int64, settings types.Settings, reset time.Time, sender types.Sender, utils types.Utils) {
+			// Generate the events of the day starting at the reset
+			ed := ChatEvents(chatID, utils)
+			ed.Next, ed.NextReset = events.NewEventsData(true, settings.Location(), reset, CustomEvents[chatID], utils), &reset
+			events.SaveOnFile(utils)
+		},
+	},
+	{
+		Name:    "closing",
+		Offset:  func(utils types.Utils) time.Duration { return -utils.Config.Schedule.ClosingBefore },
+		Enabled: func(utils types.Utils) bool { return true },
+		Run: func(chatID int64, settings types.Settings, reset time.Time, sender types.Sender, utils types.Utils) {
+			// Send the recap of the day that is closing
+			recap := ChatEvents(chatID, utils).Recap(RecapDate(reset, settings.Location()))
+			WriteLifecycleMessage(sender, chatID, RecapText(recap, settings, utils), utils)
+		},
+	},
+	{
+		Name:    "reset",
+		Offset:  func(utils types.Utils) time.Duration { return 0 },
+		Enabled: func(utils types.Utils) bool { return true },
+		Run: func(chatID int64, settings types.Settings, reset time.Time, sender types.Sender, utils types.Utils) {
+			// Archive the day, then use the events generated in advance for this reset or reset the current ones
+			ed := ChatEvents(chatID, utils)
+			ArchiveDay(chatID, ed, RecapDate(reset, settings.Location()), utils)
+			if utils.Config.Game.Jackpot {
+				RollOverJackpot(chatID, ed, utils)
+			}
+			next, nextReset := ed.Next, ed.NextReset
+			ed.Next, ed.NextReset = nil, nil
+			if next != nil && nextReset != nil && nextReset.Equal(reset) {
+				next.Jackpot = ed.Jackpot
+				events.Events[chatID] = next
+				events.SaveOnFile(utils)
+				return
+			}
+			ed.Reset(true, settings.Location(), CustomEvents[chatID], nil, utils)
+		},
+	},
+	{
+		Name:    "announcement",
+		Offset:  func(utils types.Utils) time.Duration { return utils.Config.Schedule.AnnounceAfter },
+		Enabled: func(utils types.Utils) bool { return true },
+		Run: func(chatID int64, settings types.Settings, reset time.Time, sender types.Sender, utils types.Utils) {
+			// Announce the new events
+			ChatEvents(chatID, utils).WriteResetMessage(&types.WriteMessageData{Bot: sender, ChatID: chatID, ReplyMessageID: -1, Settings: settings}, utils)
+		},
+	},
+}
+
+// History contains the archived days of the chats (it's protected by stateMutex, and nil if the history can't be kept)
+var History *history.Store
+
+// Lifecycle contains the last run of the phases, by chat ID and phase name (it's protected by stateMutex)
+var Lifecycle = make(map[int64]map[string]time.Time)
+
+// Schedule the daily lifecycle of the events of every chat (the default one included).
+// The job checks every minute which phases are due, so a restart runs the ones missed while the bot was down.
+func ScheduleLifecycle(scheduler *gocron.Scheduler, sender types.Sender, chatID int64, utils types.Utils) (*gocron.Job, error) {
+	return scheduler.Every(1).Minute().StartAt(utils.Clock.Now().Truncate(time.Minute).Add(time.Minute)).Do(
+		func() {
+			stateMutex.Lock()
+			defer stateMutex.Unlock()
+			if chatID != 0 {
+				ChatEvents(chatID, utils)
+			}
+			RunLifecycle(sender, utils)
+		},
+	)
+}
+
+// Run the phases due in every chat, in the order they were due.
+// The chats seen for the first time start from the next phases, without running the past ones.
+func RunLifecycle(sender types.Sender, utils types.Utils) {
+	now := utils.Clock.Now()
+
+	chatIDs := make([]int64, 0, len(events.Events))
+	for chatID := range events.Events {
+		chatIDs = append(chatIDs, chatID)
+	}
+	sort.Slice(chatIDs, func(i, j int) bool { return chatIDs[i] < chatIDs[j] })
+
+	changed := false
+	for _, chatID := range chatIDs {
+		lastRuns, ok := Lifecycle[chatID]
+		if !ok {
+			RestartLifecycle(chatID, utils)
+			continue
+		}
+
+		settings := GetSettings(chatID, utils)
+		for _, run := range DuePhases(now, settings, lastRuns, utils) {
+			run.Phase.Run(chatID, settings, run.Reset, sender, utils)
+			lastRuns[run.Phase.Name] = now
+			changed = true
+
+			utils.Logger.WithFields(logrus.Fields{
+				"chat":  chatID,
+				"phase": run.Phase.Name,
+				"due":   run.Due.Format(utils.TimeFormat),
+			}).Info("Lifecycle phase executed")
+		}
+	}
+
+	if changed {
+		SaveLifecycle(utils)
+	}
+}
+
+// PhaseRun is a phase due in a chat, with the instant it was due and the reset it refers to
+type PhaseRun struct {
+	Phase Phase
+	Due   time.Time
+	Reset time.Time
+}
+
+// Get the phases due in the chat that haven't run since, sorted by the instant they were due.
+// After a long downtime the missed phases can refer to different resets (e.g. the reset of yesterday and the pregeneration for tomorrow).
+func DuePhases(now time.Time, settings types.Settings, lastRuns map[string]time.Time, utils types.Utils) []PhaseRun {
+	runs := make([]PhaseRun, 0, len(Phases))
+	for _, phase := range Phases {
+		if !phase.Enabled(utils) {
+			continue
+		}
+		due, reset := PhaseDue(now, settings, phase.Offset(utils))
+		if !lastRuns[phase.Name].Before(due) {
+			continue
+		}
+		runs = append(runs, PhaseRun{phase, due, reset})
+	}
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].Due.Before(runs[j].Due) })
+	return runs
+}
+
+// Start the lifecycle of the chat from now, without running the phases already due (e.g. after a change of its reset time)
+func RestartLifecycle(chatID int64, utils types.Utils) {
+	lastRuns := make(map[string]time.Time)
+	for _, phase := range Phases {
+		lastRuns[phase.Name] = utils.Clock.Now()
+	}
+	Lifecycle[chatID] = lastRuns
+	SaveLifecycle(utils)
+}
+
+// Get the last instant (not after now) when the phase with the offset was due in the chat, and the reset it refers to
+func PhaseDue(now time.Time, settings types.Settings, offset time.Duration) (time.Time, time.Time) {
+	location := settings.Location()
+	local := now.In(location)
+
+	var due, reset time.Time
+	for days := -1; days <= 1; days++ {
+		dayReset := ResetInstant(time.Date(local.Year(), local.Month(), local.Day()+days, 0, 0, 0, 0, time.UTC), settings.ResetTime, location)
+		if dayDue := dayReset.Add(offset); !dayDue.After(now) && dayDue.After(due) {
+			due, reset = dayDue, dayReset
+		}
+	}
+	return due, reset
+}
+
+// Get the instant of the reset in the day (a date in the location) at the reset time ("15:04").
+// In a repeated minute it's the first occurrence; in a skipped one it's when the clock jumps.
+func ResetInstant(day time.Time, resetTime string, location *time.Location) time.Time {
+	clock, err := time.Parse("15:04", resetTime)
+	if err != nil {
+		clock = time.Time{}
+	}
+	if occurrences := events.Occurrences(day, clock.Hour(), clock.Minute(), location); len(occurrences) != 0 {
+		return occurrences[0]
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), clock.Hour(), clock.Minute(), 0, 0, location)
+}
+
+// Get the date of the day ended by the reset: the one of the middle of the day, so it's right for any reset time
+func RecapDate(reset time.Time, location *time.Location) string {
+	return reset.Add(-12 * time.Hour).In(location).Format(history.DateFormat)
+}
+
+// Get the text of the recap of the day, in the language of the chat (the effects are mentioned only if they are revealed)
+func RecapText(recap events.DayRecap, settings types.Settings, utils types.Utils) string {
+	return utils.Catalog.Text(settings.Language, "recap", struct {
+		events.DayRecap
+		RevealEffects bool
+	}{recap, settings.RevealEffects})
+}
+
+// DayEvent is an event activated in an archived day, as it's shown by /yesterday and /day
+type DayEvent struct {
+	Name         string
+	Winner       string
+	Points       int
+	Effects      string
+	Partecipants int
+}
+
+// Get the text of an archived day: its recap and its activated events, in the language of the chat
+func DayText(day *history.Day, settings types.Settings, utils types.Utils) string {
+	dayEvents := make([]DayEvent, 0)
+	if day.Events != nil {
+		for _, name := range day.Events.Keys {
+			event, ok := day.Events.Map[name]
+			if !ok || event.Activation == nil || event.Activation.ActivatedBy == nil {
+				continue
+			}
+			dayEvents = append(dayEvents, DayEvent{event.Name, event.Activation.ActivatedBy.UserName, event.Activation.EarnedPoints, strings.Join(EffectNames(event.Effects), ", "), len(event.Partecipations)})
+		}
+	}
+
+	return utils.Catalog.Text(settings.Language, "history.day", struct {
+		events.DayRecap
+		RevealEffects bool
+		Events        []DayEvent
+	}{day.Recap, settings.RevealEffects, dayEvents})
+}
+
+// Archive the recap and the events of the chat, before they are reset
+func ArchiveDay(chatID int64, ed *events.EventsData, date string, utils types.Utils) {
+	if History == nil {
+		return
+	}
+	snapshot, err := ed.Snapshot()
+	if err == nil {
+		err = History.Add(&history.Day{ChatID: chatID, Date: date, Recap: ed.Recap(date), Events: snapshot})
+	}
+	if err != nil {
+		utils.Logger.WithFields(logrus.Fields{
+			"err":  err,
+			"chat": chatID,
+			"date": date,
+		}).Error("Error while archiving the day")
+		return
+	}
+	utils.Logger.WithFields(logrus.Fields{
+		"chat": chatID,
+		"date": date,
+	}).Info("Day archived")
+}
+
+// Reset the events of the chat right away (with /reset events): the day so far is archived with the current date, and its unclaimed points roll over into the jackpot
+func ResetChatEvents(chatID int64, settings types.Settings, writeMsgData *types.WriteMessageData, utils types.Utils) {
+	ed := ChatEvents(chatID, utils)
+	ArchiveDay(chatID, ed, utils.Clock.Now().In(settings.Location()).Format(history.DateFormat), utils)
+	if utils.Config.Game.Jackpot {
+		RollOverJackpot(chatID, ed, utils)
+	}
+	ed.Reset(true, settings.Location(), CustomEvents[chatID], writeMsgData, utils)
+}
+
+// Write a message of the lifecycle in the chat
+func WriteLifecycleMessage(sender types.Sender, chatID int64, text string, utils types.Utils) {
+	message, err := sender.Send(tgbotapi.NewMessage(chatID, text))
+	if err != nil {
+		utils.Logger.WithFields(logrus.Fields{
+			"err": err,
+			"msg": message,
+		}).Error("Error while sending message")
+	}
+}
+
+// Save the Lifecycle data structure on files/lifecycle.json
+func SaveLifecycle(utils types.Utils) {
+	file, err := json.MarshalIndent(Lifecycle, "", " ")
+	if err != nil {
+		utils.Logger.WithFields(logrus.Fields{
+			"err": err,
+		}).Error("Error while marshalling data")
+		return
+	}
+	err = os.WriteFile("files/lifecycle.json", file, 0644)
+	if err != nil {
+		utils.Logger.WithFields(logrus.Fields{
+			"err": err,
+		}).Error("Error while writing data")
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MoraGames/clockyuwu/config"
+	"github.com/MoraGames/clockyuwu/events"
+	"github.com/MoraGames/clockyuwu/history"
+	"github.com/MoraGames/clockyuwu/structs"
+)
+
+func Test_ResetChatEvents_Jackpot(t *testing.T) {
+	inTempDir(t)
+	conf := &config.Config{Settings: config.Settings{Timezone: "UTC"}}
+	conf.Game.Jackpot = true
+	utils := testUtils(conf, time.Date(2024, 3, 31, 15, 0, 0, 0, time.UTC))
+	events.AssignSetsWithDefault(utils)
+	events.AssignEventsWithDefault(utils)
+	defer events.AssignEventsWithDefault(utils)
+
+	// The chat has a pot, and one of its enabled events was claimed
+	ed := ChatEvents(-1, utils)
+	ed.Jackpot = 5
+	unclaimed, claimed := 0, false
+	for _, key := range ed.Keys {
+		event := ed.Map[key]
+		if !event.Enabled {
+			continue
+		}
+		if !claimed {
+			event.Activation, claimed = &events.EventActivation{}, true
+			continue
+		}
+		unclaimed += event.Points
+	}
+
+	// The manual reset keeps the pot, adding the points nobody claimed
+	ResetChatEvents(-1, GetSettings(-1, utils), nil, utils)
+	if jackpot := events.Events[-1].Jackpot; jackpot != 5+unclaimed {
+		t.Errorf("The jackpot should be %v after the reset, got %v", 5+unclaimed, jackpot)
+	}
+
+	// Without the jackpot the points aren't rolled over
+	conf.Game.Jackpot = false
+	ResetChatEvents(-1, GetSettings(-1, utils), nil, utils)
+	if jackpot := events.Events[-1].Jackpot; jackpot != 5+unclaimed {
+		t.Errorf("The jackpot should stay %v without the jackpot enabled, got %v", 5+unclaimed, jackpot)
+	}
+}
+
+func Test_DuePhases_Missed(t *testing.T) {
+	day := time.Date(2024, time.May, 11, 0, 0, 0, 0, time.UTC)
+	inTempDir(t)
+	conf := &config.Config{Settings: config.Settings{Timezone: "UTC", ResetTime: "23:58"}}
+	conf.Schedule.PregenerateBefore = time.Hour
+	utils := testUtils(conf, day)
+	resetState(utils)
+	settings := GetSettings(-1, utils)
+
+	// The bot is down from before the reset of the 11th until after the pregeneration for the 12th:
+	// the missed phases run in the order they were due, so the reset of the 11th comes before the pregeneration
+	down := time.Date(2024, time.May, 11, 23, 0, 0, 0, time.UTC)
+	lastRuns := map[string]time.Time{"pregenerate": down, "closing": down, "reset": down, "announcement": down}
+	runs := DuePhases(time.Date(2024, time.May, 12, 23, 30, 0, 0, time.UTC), settings, lastRuns, utils)
+	names := make([]string, 0, len(runs))
+	for _, run := range runs {
+		names = append(names, run.Phase.Name)
+	}
+	if strings.Join(names, ",") != "closing,reset,announcement,pregenerate" || !runs[3].Reset.Equal(time.Date(2024, time.May, 12, 23, 58, 0, 0, time.UTC)) {
+		t.Fatalf("The missed phases should run in the order they were due, got %v", names)
+	}
+
+	// The events generated in advance for another reset are thrown away
+	reset, other := runs[1].Reset, runs[3].Reset
+	ed := ChatEvents(-1, utils)
+	stale := events.NewEventsData(true, time.UTC, other, nil, utils)
+	ed.Next, ed.NextReset = stale, &other
+	runs[1].Phase.Run(-1, settings, reset, nil, utils)
+	if events.Events[-1] != ed || ed.Next != nil || ed.NextReset != nil {
+		t.Error("The events generated for another reset should be thrown away")
+	}
+
+	// The ones generated for the reset replace the events
+	next := events.NewEventsData(true, time.UTC, reset, nil, utils)
+	ed.Next, ed.NextReset = next, &reset
+	runs[1].Phase.Run(-1, settings, reset, nil, utils)
+	if events.Events[-1] != next {
+		t.Error("The events generated for the reset should replace the current ones")
+	}
+}
+
+func Test_ArchiveDay(t *testing.T) {
+	at := time.Date(2024, 3, 31, 12, 34, 1, 0, time.UTC)
+	utils := testUtils(&config.Config{Settings: config.Settings{Timezone: "UTC"}}, at)
+	resetState(utils)
+	var err error
+	if History, err = history.New(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { History = nil }()
+
+	// Alice wins one of the two events of the day
+	alice := structs.NewUser(testAlice.ID, testAlice.UserName)
+	ed := testEvents(-1, 3, "12:34", "13:31")
+	event := ed.Map["12:34"]
+	event.AddEffect(structs.DoublePositivePoints)
+	event.Activate(alice, at, at, 6)
+	event.Partecipate(alice, at)
+	ArchiveDay(-1, ed, "2024-03-31", utils)
+
+	// The day is archived with its recap and a snapshot of its events
+	event.Activation = nil
+	day, ok := History.Get(-1, "2024-03-31")
+	if !ok {
+		t.Fatal("The day should be archived")
+	}
+	if day.Recap.ActivatedEventsNum != 1 || day.Recap.EnabledEventsNum != 2 || len(day.Recap.MissedEvents) != 1 || day.Recap.MissedEvents[0] != "13:31" {
+		t.Errorf("Unexpected recap: %+v", day.Recap)
+	}
+	archived := day.Events.Map["12:34"]
+	if archived.Activation == nil || archived.Activation.ActivatedBy.UserName != testAlice.UserName || archived.Activation.EarnedPoints != 6 || len(archived.Effects) != 1 || len(archived.Partecipations) != 1 {
+		t.Errorf("The activated event should be archived as it was, got %+v", archived)
+	}
+	if _, ok := History.Get(-1, "2024-03-30"); ok {
+		t.Error("Only the archived day should be in the history")
+	}
+}
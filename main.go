@@ -1,18 +1,26 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log"
 	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 	"time"
 
 	"github.com/MoraGames/clockyuwu/config"
 	"github.com/MoraGames/clockyuwu/events"
+	"github.com/MoraGames/clockyuwu/history"
+	"github.com/MoraGames/clockyuwu/pkg/cleanup"
+	"github.com/MoraGames/clockyuwu/pkg/clock"
+	"github.com/MoraGames/clockyuwu/pkg/console"
 	"github.com/MoraGames/clockyuwu/pkg/logger"
+	"github.com/MoraGames/clockyuwu/pkg/outbox"
 	"github.com/MoraGames/clockyuwu/pkg/types"
-	"github.com/go-co-op/gocron"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/sirupsen/logrus"
 )
@@ -37,38 +45,59 @@ func main() {
 		log.Println(err)
 	}
 
-	mw := io.MultiWriter(os.Stdout, logFile)
+	//in console mode the terminal is used to play, so the logs are written only on file
+	var mw io.Writer = io.MultiWriter(os.Stdout, logFile)
+	if conf.Bot.Mode == "console" {
+		mw = logFile
+	}
 	l.SetOutput(mw)
 
-	//link Telegram API
-	apiToken := os.Getenv("TELEGRAM_API_TOKEN")
-	if apiToken == "" {
-		l.WithFields(logrus.Fields{
-			"env": "TELEGRAM_API_TOKEN",
-		}).Panic("Env not set")
-	}
+	//get the clock (the real one, or a virtual one for simulations)
+	gameClock, virtualClock := NewClock(conf, l)
+	utils := types.Utils{Config: conf, Logger: l, Clock: gameClock, Catalog: NewCatalog(conf.Settings.Language), TimeFormat: "15:04:05.000000 MST -07:00"}
 
-	//get the bot API
-	bot, err := tgbotapi.NewBotAPI(apiToken)
-	if err != nil {
+	//get the front end used to respond (Telegram bot API or terminal console)
+	var bot *tgbotapi.BotAPI
+	var cons *console.Console
+	var sender types.Sender
+	if conf.Bot.Mode == "console" {
+		cons = NewConsole(conf, gameClock)
+		sender = cons
+		l.Info("Console mode enabled")
+	} else {
+		//link Telegram API
+		apiToken := os.Getenv("TELEGRAM_API_TOKEN")
+		if apiToken == "" {
+			l.WithFields(logrus.Fields{
+				"env": "TELEGRAM_API_TOKEN",
+			}).Panic("Env not set")
+		}
+
+		//get the bot API
+		bot, err = tgbotapi.NewBotAPIWithAPIEndpoint(apiToken, conf.Bot.APIEndpoint)
+		if err != nil {
+			l.WithFields(logrus.Fields{
+				"err":      err,
+				"endpoint": conf.Bot.APIEndpoint,
+			}).Panic("Error while getting bot API")
+		}
 		l.WithFields(logrus.Fields{
-			"err": err,
-		}).Panic("Error while getting bot API")
-	}
-	l.WithFields(logrus.Fields{
-		"id":       bot.Self.ID,
-		"username": bot.Self.UserName,
-	}).Info("Account authorized")
+			"id":       bot.Self.ID,
+			"username": bot.Self.UserName,
+		}).Info("Account authorized")
+
+		bot.Debug = false
 
-	bot.Debug = false
-	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 180
+		//send through the outbox, respecting the Telegram flood limits
+		sender = NewOutbox(conf, bot, l)
+	}
 
-	//get current time location
-	timeLocation, err := time.LoadLocation("Local")
+	//get the time location of the default timezone
+	timeLocation, err := time.LoadLocation(conf.Settings.Timezone)
 	if err != nil {
 		l.WithFields(logrus.Fields{
-			"err": err,
+			"err":      err,
+			"timezone": conf.Settings.Timezone,
 		}).Warn("Time location not get (using UTC)")
 	}
 
@@ -86,18 +115,13 @@ func main() {
 			"err": err,
 		}).Warn("Error while parsing TELEGRAM_DEFAULT_CHAT_ID to int64")
 	}
+	if defChatID == 0 && cons != nil {
+		defChatID = cons.DefaultChatID()
+	}
 
-	//set the gocron events reset
-	gcScheduler := gocron.NewScheduler(timeLocation)
-	gcJob, err := gcScheduler.Every(1).Day().At("23:58").Do(
-		func() {
-			events.Events.Reset(
-				true,
-				&types.WriteMessageData{Bot: bot, ChatID: defChatID, ReplyMessageID: -1},
-				types.Utils{Config: conf, Logger: l, TimeFormat: "15:04:05.000000 MST -07:00"},
-			)
-		},
-	)
+	//set the daily lifecycle of the events (closing summary, reset and announcement)
+	gcScheduler := clock.NewScheduler(gameClock, timeLocation)
+	gcJob, err := ScheduleLifecycle(gcScheduler, sender, defChatID, utils)
 	if err != nil {
 		l.WithFields(logrus.Fields{
 			"gcJob": gcJob,
@@ -105,25 +129,103 @@ func main() {
 		}).Error("GoCron job not set")
 	}
 
-	updates := bot.GetUpdatesChan(u)
-	l.WithFields(logrus.Fields{
-		"debugMode": bot.Debug,
-		"timeout":   u.Timeout,
-	}).Debug("Update channel retreived")
+	//keep the history of the days
+	History, err = history.New("files/history")
+	if err != nil {
+		l.WithFields(logrus.Fields{
+			"err": err,
+		}).Error("History not available")
+	}
+
+	//keep the timeline of the claims of the users
+	Timeline, err = history.NewTimeline("files/timeline")
+	if err != nil {
+		l.WithFields(logrus.Fields{
+			"err": err,
+		}).Error("Timeline not available")
+	}
+
+	//set the cleanup of the transient messages
+	if conf.Cleanup.Enabled {
+		Cleanup = cleanup.New(sender, gameClock, cleanup.Options{After: conf.Cleanup.After, Claims: conf.Cleanup.Claims, File: "files/cleanup.json"}, l)
+		cleanupJob, err := gcScheduler.Every(1).Minute().Do(Cleanup.Sweep)
+		if err != nil {
+			l.WithFields(logrus.Fields{
+				"cleanupJob": cleanupJob,
+				"error":      err,
+			}).Error("GoCron job not set")
+		}
+	}
+
+	var updates tgbotapi.UpdatesChannel
+	stopUpdates := func() {}
+	if cons != nil {
+		updates = cons.Updates
+	} else {
+		updates, stopUpdates = StartUpdates(bot, utils)
+	}
 
 	// Read from specified files and reload the data into the structs
 	ReloadStatus(
 		[]types.Reload{
 			{FileName: "files/sets.json", DataStruct: &events.SetsJson, IfOkay: events.AssignSetsFromSetsJson, IfFail: events.AssignSetsWithDefault},
 			{FileName: "files/events.json", DataStruct: &events.Events, IfOkay: nil, IfFail: events.AssignEventsWithDefault},
-			{FileName: "files/users.json", DataStruct: &Users, IfOkay: nil, IfFail: nil},
+			{FileName: "files/users.json", DataStruct: &Users, IfOkay: RebuildPointsIndex, IfFail: nil},
+			{FileName: "files/chats.json", DataStruct: &Chats, IfOkay: nil, IfFail: nil},
+			{FileName: "files/lifecycle.json", DataStruct: &Lifecycle, IfOkay: nil, IfFail: nil},
+			{FileName: "files/custom_events.json", DataStruct: &CustomEvents, IfOkay: nil, IfFail: nil},
 		},
-		types.Utils{Config: conf, Logger: l, TimeFormat: "15:04:05.000000 MST -07:00"},
+		utils,
 	)
 
+	//handle the termination signals (a second signal forces the exit)
+	ctx, cancel := context.WithCancelCause(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		l.WithFields(logrus.Fields{
+			"signal": sig.String(),
+		}).Warn("Termination signal received")
+		cancel(fmt.Errorf("signal %v received", sig))
+
+		sig = <-signals
+		l.WithFields(logrus.Fields{
+			"signal": sig.String(),
+		}).Error("Second termination signal received, forcing exit")
+		os.Exit(1)
+	}()
+
+	if cons != nil {
+		if virtualClock != nil {
+			// Stop at every scheduled job, so that the jobs see the clock at their scheduled time
+			cons.Advance = func(d time.Duration) {
+				virtualClock.AdvanceScheduler(gcScheduler, virtualClock.Now().Add(d))
+			}
+		}
+		go cons.Run(ctx, os.Stdin)
+	}
+	if virtualClock != nil && conf.Clock.Speed != 1 {
+		go virtualClock.FastForward(ctx, conf.Clock.Speed, 100*time.Millisecond)
+	}
+
 	gcScheduler.StartAsync()
-	run(types.Utils{Config: conf, Logger: l, TimeFormat: "15:04:05.000000 MST -07:00"}, types.Data{Bot: bot, Updates: updates})
-	gcScheduler.Stop()
+	startedAt := gameClock.Now()
+	managed := run(ctx, utils, types.Data{Bot: sender, Updates: updates})
+	shutdownReason := "updates channel closed"
+	if ctx.Err() != nil {
+		shutdownReason = context.Cause(ctx).Error()
+	}
+	stopUpdates()
+	cancel(nil)
+
+	Shutdown(
+		sender,
+		gcScheduler,
+		PlayingChats(defChatID),
+		ShutdownSummary{Reason: shutdownReason, StartedAt: startedAt, UpdatesManaged: managed},
+		utils,
+	)
 }
 
 func ReloadStatus(reloads []types.Reload, utils types.Utils) {
@@ -204,10 +306,61 @@ func ReloadStatus(reloads []types.Reload, utils types.Utils) {
 	}).Info("Reloading data completed")
 }
 
-func WriteMessage(bot *tgbotapi.BotAPI, chatID int64, replyMessageID int, text string) {
+func WriteMessage(bot types.Sender, chatID int64, replyMessageID int, text string, utils types.Utils) {
 	msg := tgbotapi.NewMessage(chatID, text)
 	if replyMessageID != -1 {
 		msg.ReplyToMessageID = replyMessageID
 	}
-	bot.Send(msg)
+	message, err := bot.Send(msg)
+	if err != nil {
+		utils.Logger.WithFields(logrus.Fields{
+			"err": err,
+			"msg": message,
+		}).Error("Error while sending message")
+	}
+}
+
+// Create the outbox used to send the messages to Telegram.
+// It always uses the real clock, as the flood limits are measured by Telegram.
+func NewOutbox(conf *config.Config, sender types.Sender, l *logrus.Logger) *outbox.Outbox {
+	return outbox.New(
+		sender,
+		clock.Real,
+		outbox.Options{
+			GlobalRate:  conf.Outbox.GlobalRate,
+			GlobalBurst: conf.Outbox.GlobalBurst,
+			ChatRate:    conf.Outbox.ChatRate,
+			ChatBurst:   conf.Outbox.ChatBurst,
+			MaxRetries:  conf.Outbox.MaxRetries,
+			Backoff:     conf.Outbox.Backoff,
+			MaxDelay:    conf.Outbox.MaxDelay,
+		},
+		l,
+	)
+}
+
+// Create the clock used by the game: the real one, or a virtual one if a start time or a speed is configured
+func NewClock(conf *config.Config, l *logrus.Logger) (clock.Clock, *clock.Virtual) {
+	if conf.Clock.Start == "" && conf.Clock.Speed == 1 {
+		return clock.Real, nil
+	}
+
+	start := time.Now()
+	if conf.Clock.Start != "" {
+		var err error
+		start, err = time.Parse(time.RFC3339, conf.Clock.Start)
+		if err != nil {
+			l.WithFields(logrus.Fields{
+				"err":   err,
+				"start": conf.Clock.Start,
+			}).Panic("Error while parsing the virtual clock start")
+		}
+	}
+
+	l.WithFields(logrus.Fields{
+		"start": start,
+		"speed": conf.Clock.Speed,
+	}).Warn("Virtual clock enabled")
+	virtual := clock.NewVirtual(start)
+	return virtual, virtual
 }
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/MoraGames/clockyuwu/config"
+	"github.com/MoraGames/clockyuwu/events"
+	"github.com/MoraGames/clockyuwu/pkg/clock"
+	"github.com/MoraGames/clockyuwu/pkg/outbox"
+	"github.com/MoraGames/clockyuwu/pkg/types"
+	"github.com/MoraGames/clockyuwu/ranking"
+	"github.com/MoraGames/clockyuwu/structs"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// The chat and the users of the tests
+var (
+	testChat  = tgbotapi.Chat{ID: -100, Type: "supergroup", Title: "Test Group"}
+	testAdmin = tgbotapi.User{ID: 10, UserName: "admin"}
+	testAlice = tgbotapi.User{ID: 11, UserName: "alice"}
+	testBob   = tgbotapi.User{ID: 12, UserName: "bob"}
+	testCarol = tgbotapi.User{ID: 13, UserName: "carol"}
+)
+
+// inTempDir runs the test inside a temporary working directory with the files folder, so the state saved on file is thrown away
+func inTempDir(t *testing.T) {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.Mkdir(dir+"/files", 0755); err != nil {
+		t.Fatal(err)
+	}
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+// testUtils returns the utils of a unit test, with the configuration and a virtual clock at the instant
+func testUtils(conf *config.Config, now time.Time) types.Utils {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return types.Utils{Config: conf, Logger: logger, Clock: clock.NewVirtual(now), Catalog: NewCatalog("it"), TimeFormat: "15:04:05.000000 MST -07:00"}
+}
+
+// resetState empties the state of the bot (users, chats, events, ...) for the test, without the history and the timeline
+func resetState(utils types.Utils) {
+	Users = make(map[int64]*structs.User)
+	Chats = make(map[int64]*structs.Chat)
+	Results = make(map[resultKey]*EventResult)
+	CustomEvents = make(map[int64][]*events.CustomEvent)
+	Lifecycle = make(map[int64]map[string]time.Time)
+	Rankings = ranking.NewCache()
+	PointsIndex = ranking.NewIndex()
+	Cleanup = nil
+	History, Timeline = nil, nil
+	events.AssignSetsWithDefault(utils)
+	events.AssignEventsWithDefault(utils)
+}
+
+// recordingSender records the requests, answering them as the Bot API would
+type recordingSender struct {
+	sent []tgbotapi.Chattable
+}
+
+func (s *recordingSender) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	s.sent = append(s.sent, c)
+	return tgbotapi.Message{MessageID: len(s.sent), Chat: &tgbotapi.Chat{ID: outbox.ChatID(c)}}, nil
+}
+
+func (s *recordingSender) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	s.sent = append(s.sent, c)
+	return &tgbotapi.APIResponse{Ok: true}, nil
+}
+
+// texts returns the texts of the sent messages and of the edits, in order
+func (s *recordingSender) texts() []string {
+	texts := make([]string, 0, len(s.sent))
+	for _, c := range s.sent {
+		switch c := c.(type) {
+		case tgbotapi.MessageConfig:
+			texts = append(texts, c.Text)
+		case tgbotapi.EditMessageTextConfig:
+			texts = append(texts, c.Text)
+		}
+	}
+	return texts
+}
+
+// testEvents gives the chat only the events with the names ("15:04" or "15:04:05"), enabled and worth the points
+func testEvents(chatID int64, points int, names ...string) *events.EventsData {
+	ed := &events.EventsData{Map: make(events.EventsMap), Keys: make(events.EventsKeys, 0, len(names)), Stats: events.EventsStats{EnabledEffects: make(map[string]int)}}
+	for _, name := range names {
+		seconds := len(name) == len("15:04:05")
+		layout := "15:04"
+		if seconds {
+			layout = "15:04:05"
+		}
+		eventTime, err := time.Parse(layout, name)
+		if err != nil {
+			panic(err)
+		}
+		event := events.NewEvent(eventTime, time.Time{}, nil)
+		event.Name, event.Seconds, event.Enabled, event.Points = name, seconds, true, points
+		ed.Map[name], ed.Keys = event, append(ed.Keys, name)
+	}
+	events.Events[chatID] = ed
+	return ed
+}
+
+// testClaim is the claim of the event by the user in the chat, sent at the instant and received after the delay
+func testClaim(chatID int64, user tgbotapi.User, text string, sentAt time.Time, delay time.Duration) Claim {
+	return Claim{ChatID: chatID, MessageID: 1, UserID: user.ID, UserName: user.UserName, Text: text, SentAt: sentAt, ReceivedAt: sentAt.Add(delay)}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"github.com/MoraGames/clockyuwu/pkg/i18n"
+	"github.com/MoraGames/clockyuwu/pkg/types"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Messages of the bot in Italian (the original language of the game)
+var messagesIT = map[string]string{
+	// Common responses
+	"unauthorized":              "Non sei autorizzato ad usare questo comando",
+	"wrong_syntax":              "Il comando è: {{.Syntax}}",
+	"property_updated":          "Proprietà '{{.Property}}' aggiornata.",
+	"parameter_not_valid":       "Parametro <{{.Parameter}}> non valido. Deve essere {{.Expected}}.",
+	"expected.integer":          "un numero intero",
+	"expected.positive_integer": "un numero intero positivo",
+	"expected.boolean":          "un booleano",
+	"expected.effects":          "una lista di effetti validi",
+	"expected.date":             "una data nel formato aaaa-mm-gg",
+	"expected.time":             "un orario nel formato hh:mm",
+	"expected.recurrence":       "\"daily\", un giorno nel formato mm-gg o una data nel formato aaaa-mm-gg",
+	"entity_not_found":          "{{.Entity}} ({{.Value}}) non trovato.",
+	"entity.user":               "Utente",
+	"entity.event":              "Evento",
+	"entity.effect":             "Effetto",
+	"entity.custom_event":       "Evento personalizzato",
+
+	// Claims
+	"claim.activated":         "{{if .EventName}}{{.EventName}}! {{end}}{{if lt .Points 0}}Accidenti{{else if eq .Points 0}}Peccato{{else}}Complimenti{{end}} {{.User}}! {{.Points}} {{plural .Points \"punto\" \"punti\"}} per te{{if .Effects}} grazie agli effetti:\n{{.Effects}}{{end}}.\nHai impiegato +{{.Delay}}s",
+	"claim.already_activated": "L'evento è già stato attivato da {{.Winner}} +{{.Delta}}s fa.\nHai impiegato +{{.Delay}}s.{{if .Effects}}\nIl tuo arrivo è stato così vicino che {{.Winner}} riceve gli effetti:\n{{.Effects}}.{{end}}",
+	"claim.repeated_minute":   "Le {{.EventName}} si ripetono per il cambio dell'ora: l'evento vale solo la prima volta.",
+	"claim.too_late":          "Troppo tardi! L'evento delle {{.EventName}} è terminato da +{{printf \"%.2f\" .Late}}s.",
+	"claim.false_start":       "Falsa partenza! L'evento delle {{.EventName}} non è ancora iniziato.",
+	"result":                  "Evento {{.EventName}} vinto da {{.Winner}}: {{.Points}} {{plural .Points \"punto\" \"punti\"}}{{if .Effects}} grazie agli effetti:\n{{.Effects}}{{end}}\n\nPartecipanti:\n{{range $i, $p := .Partecipants}}{{inc $i}}] {{$p.UserName}} +{{$p.Delay.Seconds}}s\n{{end}}\n{{if .Final}}Risultato definitivo.{{else}}In aggiornamento fino alla fine del minuto...{{end}}",
+
+	// Commands
+	"check.logs":                    "Log controllati. Ecco lo stato attuale:\n\n",
+	"check.users":                   "Utenti controllati. Ecco lo stato attuale:\n\n",
+	"check.events":                  "Eventi controllati. Ecco lo stato attuale:\n\n",
+	"check.outbox":                  "Outbox controllata. Ecco lo stato attuale:\n\nInviati: {{.Sent}}\nRitardati: {{.Delayed}} (medio {{.AverageDelay}}, massimo {{.MaxDelay}})\nRitentativi: {{.Retried}}\nScartati: {{.Dropped}}",
+	"check.outbox_disabled":         "Outbox non attiva.",
+	"credits":                       "Il codice sorgente, disponibile su GitHub in MoraGames/clockyuwu, è scritto interamente in GoLang e usa la libreria \"telegram-bot-api\".\nPer segnalare bug o proporre nuove funzionalità, fai riferimento al progetto su GitHub.\n\nSviluppatore:\n- Telegram: @MoraGames\n- Discord: @moragames\n- Instagram: @moragames.dev\n- GitHub: MoraGames\n\nProgetto:\n- Telegram: @clockyuwu_bot\n- GitHub: MoraGames/clockyuwu\n\nUn ringraziamento speciale va ai primi tester (nonché giocatori) del minigioco gestito dal bot, \"Vano\", \"Ale\" e \"Alex\".",
+	"help":                          "Nome: {{.Name}}\nVersione: {{.Version}}\n\nQuesta è la lista di tutti i comandi del bot:\n\n- /start : Ottieni un messaggio introduttivo sulle funzionalità del bot.\n - /help : Ottieni la lista completa dei comandi disponibili.\n - /ranking [points|wins|partecipations|winrate|avgdelay] [day|week|month|season|all] : Ottieni la classifica dei giocatori (di default per punti nella season in corso).\n - /stats : Ottieni le statistiche di gioco del giocatore.\n - /achievements [utente] : Ottieni gli obiettivi sbloccati dal giocatore.\n - /history [utente] [n] : Ottieni le ultime partecipazioni del giocatore.\n - /yesterday : Ottieni il riepilogo della giornata di ieri.\n - /day <aaaa-mm-gg> : Ottieni il riepilogo di una giornata passata.\n - /language : Scegli la lingua del bot, per te o per la chat.\n - /settings : Mostra o cambia le impostazioni della chat (solo i moderatori).\n - /ping : Verifica se il bot è in funzione.\n - /credits : Ottieni più informazioni sul progetto.\n\nSolo per gli admin:\n - /check : Ottieni più informazioni sullo stato e sui dati del bot.\n - /reset : Forza l'esecuzione di una specifica funzione Reset().\n - /update : Aggiorna il valore di una struttura dati.\n - /event <list|add|remove> : Gestisci gli eventi personalizzati della chat (la lista è per tutti).",
+	"list.sets":                     "\nSchemi Attivi ({{.Count}}):\n{{range .Sets}} | {{printf \"%q\" .}}\n{{end}}{{if .Today}}\nSchemi del calendario di oggi:\n{{range .Today}} | {{printf \"%q\" .Name}}: {{if .Minutes}}{{join .Minutes \", \"}}{{else}}nessun evento oggi{{end}}\n{{end}}{{end}}",
+	"list.effects":                  "\nEffetti Attivi ({{.Count}}):\n{{range $name, $num := .Effects}} | {{printf \"%q\" $name}} = {{$num}}\n{{end}}{{if .Timing}}\nEffetti di tempismo ({{len .Timing}}):\n{{range .Timing}} | {{printf \"%q\" .Name}} ({{.Key}}{{.Value}}{{if .Cap}}, al massimo {{.Cap}} {{plural .Cap \"punto\" \"punti\"}}{{end}}): {{if eq .Condition \"second\"}}{{if eq .From .To}}rivendicato al secondo {{.From}}{{else}}rivendicato dal secondo {{.From}} al {{.To}}{{end}}{{else if eq .Condition \"time\"}}eventi dalle {{.From}} alle {{.To}}{{else if eq .Condition \"photo_finish\"}}il secondo arriva entro {{.Within}}{{end}}\n{{end}}{{end}}",
+	"list.jackpot":                  "{{if not .Enabled}}Il jackpot non è attivo.{{else}}Il jackpot è di {{.Jackpot}} {{plural .Jackpot \"punto\" \"punti\"}}, {{if .Minute}}per chi vince l'evento delle {{.Minute}}{{else}}per chi vince il prossimo evento{{end}}.{{end}}",
+	"event.added":                   "Evento personalizzato {{printf \"%q\" .Name}} delle {{.Time}} aggiunto, sarà attivo dal prossimo reset degli eventi.",
+	"event.removed":                 "{{.Count}} {{plural .Count \"evento personalizzato\" \"eventi personalizzati\"}} delle {{.Time}} {{plural .Count \"rimosso\" \"rimossi\"}}, dal prossimo reset degli eventi.",
+	"event.list.empty":              "Non ci sono eventi personalizzati in questa chat.",
+	"event.list":                    "Eventi personalizzati ({{len .Events}}):\n{{range .Events}} | {{.Time}} {{printf \"%q\" .Name}}: {{.Points}} {{plural .Points \"punto\" \"punti\"}}{{if .Effects}} con gli effetti {{join .EffectNames \", \"}}{{end}}, {{if eq .Kind \"daily\"}}ogni giorno{{else if eq .Kind \"yearly\"}}ogni anno il {{.Recurrence}}{{else}}il {{.Recurrence}}{{end}}\n{{end}}",
+	"ping":                          "pong",
+	"ranking.empty":                 "Ancora nessun utente ha partecipato agli eventi della season.",
+	"ranking":                       "La classifica per {{.Metric}} ({{.Period}}) è la seguente:\n\n{{range .Ranking}}{{.Position}}] {{.Username}}: {{.Value}} ({{.Gap}})\n{{end}}",
+	"ranking.metric.points":         "punti",
+	"ranking.metric.wins":           "vittorie",
+	"ranking.metric.partecipations": "partecipazioni",
+	"ranking.metric.winrate":        "vittorie/partecipazioni",
+	"ranking.metric.avgdelay":       "ritardo medio",
+	"ranking.period.day":            "oggi",
+	"ranking.period.week":           "questa settimana",
+	"ranking.period.month":          "questo mese",
+	"ranking.period.season":         "season",
+	"ranking.period.all":            "da sempre",
+	"reset.events":                  "Eventi resettati",
+	"reset.users":                   "Utenti resettati",
+	"start":                         "{{.Name}} è un bot che ti permette di giocare a un gioco perditempo con uno o più gruppi di amici all'interno dei gruppi Telegram. Una volta aggiunto il bot, il gioco consiste principalmente (ma non solo) nell'inviare messaggi nel formato \"hh:mm\" in certi orari della giornata, in cambio di preziosi punti. Chi avrà guadagnato più punti alla fine del campionato sarà il nuovo Clocky Champion!\nUsa /help per la lista di tutti i comandi o /credits per più informazioni sul progetto.\n\n- {{.Name}}, un bot di @MoraGames.",
+	"stats.none":                    "Non hai ancora partecipato a nessun evento.",
+	"stats.own":                     "Le tue statistiche sono:\n\n{{template \"stats\" .}}",
+	"stats.other_none":              "{{.UserName}} non ha ancora partecipato a nessun evento.",
+	"stats.other":                   "Le statistiche di {{.User.UserName}} sono:\n\n{{template \"stats\" .}}",
+	"stats":                         "Punti totali: {{.User.TotalPoints}}\nPartecipazioni totali: {{.User.TotalEventPartecipations}}\nVittorie totali: {{.User.TotalEventWins}}\nPunti/Partecipazioni: {{printf \"%.2f\" .PointsPerPartecipation}}\nPunti/Vittorie: {{printf \"%.2f\" .PointsPerWin}}\nVittorie/Partecipazioni: {{printf \"%.2f\" .WinsPerPartecipation}}\nVittorie/Sconfitte: {{printf \"%.2f\" .WinsPerLoss}}\nEffetti attivi: {{.User.StringifyEffects}}{{if .Handicaps}}\nHandicap:{{range .Handicaps}}\n | {{.Name}} ({{.Key}}{{.Value}}{{if .Cap}}, al massimo {{.Cap}} {{plural .Cap \"punto\" \"punti\"}}{{end}}): {{if eq .Condition \"leader_gap\"}}{{.Measure}} {{plural .Measure \"punto\" \"punti\"}} dal primo{{else if eq .Condition \"next_gap\"}}{{.Measure}} {{plural .Measure \"punto\" \"punti\"}} dal giocatore davanti{{else if eq .Condition \"percentile\"}}{{.Measure}}% dei giocatori davanti{{else if eq .Condition \"days_absent\"}}{{.Measure}} {{plural .Measure \"giorno\" \"giorni\"}} di assenza{{end}}{{end}}{{end}}{{with .Activity}}\nGiorno migliore: {{.BestDay}} ({{.BestDayPoints}} {{plural .BestDayPoints \"punto\" \"punti\"}})\nMinuto preferito: {{.FavouriteMinute}} ({{.FavouriteMinuteClaims}} {{plural .FavouriteMinuteClaims \"volta\" \"volte\"}})\nRitardo medio: +{{printf \"%.2f\" .AverageDelay.Seconds}}s\nRitardo migliore: +{{.BestDelay.Seconds}}s{{end}}{{if .AchievementsNum}}\nObiettivi: {{len .Achievements}}/{{.AchievementsNum}}{{if .Achievements}} ({{join .Achievements \", \"}}){{end}}{{end}}",
+	"language.current":              "La tua lingua è {{.Language}}{{if .ChatLanguage}}, quella della chat è {{.ChatLanguage}}{{end}}.\nUsa /language <{{.Languages}}> per cambiare la tua lingua, o /language chat <{{.Languages}}> per cambiare quella della chat.",
+	"language.updated":              "Lingua aggiornata: {{.Language}}.",
+	"language.chat_updated":         "Lingua della chat aggiornata: {{.Language}}.",
+
+	// Settings
+	"settings.menu":                  "Impostazioni della chat:\n\nLingua: {{.Language}}\nFuso orario: {{.Timezone}}\nOrario del reset: {{.ResetTime}}\nEffetti visibili: {{if .RevealEffects}}sì{{else}}no{{end}}\n\nSolo i moderatori possono cambiarle.",
+	"settings.choose":                "{{.Option}} (ora: {{.Value}}).\nScegli il nuovo valore, oppure usa /settings <impostazione> <valore> per uno non in lista.",
+	"settings.button":                "{{.Option}}: {{.Value}}",
+	"settings.back":                  "« Indietro",
+	"settings.updated":               "{{.Option}} aggiornato: {{.Value}}.",
+	"settings.not_valid":             "Valore non valido per '{{.Option}}': {{.Value}}.",
+	"settings.effects_hidden":        "Gli effetti sono nascosti in questa chat.",
+	"settings.option.language":       "Lingua",
+	"settings.option.timezone":       "Fuso orario",
+	"settings.option.reset_time":     "Orario del reset",
+	"settings.option.reveal_effects": "Effetti visibili",
+	"settings.value.true":            "sì",
+	"settings.value.false":           "no",
+
+	// Scheduled messages
+	"recap":           "Riepilogo della giornata {{.Date}}:\n\nEventi attivati: {{.ActivatedEventsNum}}/{{.EnabledEventsNum}}\nEventi mancati: {{len .MissedEvents}}{{if and .MissedEvents (le (len .MissedEvents) 10)}} ({{range $i, $e := .MissedEvents}}{{if $i}}, {{end}}{{$e}}{{end}}){{end}}\nPunti assegnati: {{.EarnedPointsSum}}\n{{if .TopScorers}}\nMigliori giocatori:\n{{range $i, $s := .TopScorers}}{{if lt $i 3}}{{inc $i}}] {{$s.UserName}}: {{$s.Points}} {{plural $s.Points \"punto\" \"punti\"}} ({{$s.Wins}} {{plural $s.Wins \"evento\" \"eventi\"}})\n{{end}}{{end}}{{with .BiggestWin}}\nVittoria più grande: {{.UserName}} alle {{.EventName}}, {{.Points}} {{plural .Points \"punto\" \"punti\"}}\n{{end}}{{with .FastestReaction}}Reazione più veloce: {{.UserName}} alle {{.EventName}}, +{{.Delay.Seconds}}s\n{{end}}{{if and .RevealEffects .NegativeVictims}}\nVittime dei moltiplicatori negativi:\n{{range .NegativeVictims}} | {{.UserName}} alle {{.EventName}}: {{.Points}} {{plural .Points \"punto\" \"punti\"}}\n{{end}}{{end}}{{else}}\nNessun evento è stato attivato.\n{{end}}",
+	"reset.recap":     "Gli eventi son stati resettati.\nEcco alcune informazioni:\n\nSchemi: {{.EnabledSetsNum}}/{{.TotalSetsNum}}\nEventi: {{.EnabledEventsNum}}/{{.TotalEventsNum}}\nPunti ottenibili: {{.EnabledPointsSum}}\n\nSchemi Attivi ({{.EnabledSetsNum}}):\n{{range .EnabledSets}} | {{printf \"%q\" .}}\n{{end}}{{if .RevealEffects}}\nEffetti Attivi ({{.EnabledEffectsNum}}):\n{{range $name, $num := .EnabledEffects}} | {{printf \"%q\" $name}} = {{$num}}\n{{end}}{{end}}\nBuona fortuna!",
+	"shutdown.notice": "Il bot sta andando offline, a presto!",
+
+	// Jackpot
+	"jackpot.won": "{{.User}} ha vinto il jackpot di {{.Jackpot}} {{plural .Jackpot \"punto\" \"punti\"}} con l'evento delle {{.Event}}!",
+
+	// Achievements
+	"achievement.unlocked": "{{.User}} ha sbloccato l'obiettivo \"{{.Name}}\": {{.Description}}.{{if .Reward}}\nRicompensa: {{.Reward}}.{{end}}",
+	"achievements":         "Obiettivi di {{.UserName}} ({{.Unlocked}}/{{len .Achievements}}):\n\n{{range .Achievements}}{{if .Unlocked}}[x]{{else}}[ ]{{end}} {{.Name}}: {{.Description}}{{if .RewardName}} (ricompensa: {{.RewardName}}){{end}}{{if .Unlocked}}, sbloccato il {{.UnlockedAt}}{{end}}\n{{end}}",
+	"achievements.none":    "Non ci sono obiettivi.",
+
+	// History
+	"history.day":       "{{template \"recap\" .}}{{if .Events}}\nEventi attivati:\n{{range .Events}} | {{.Name}} {{.Winner}}: {{.Points}} {{plural .Points \"punto\" \"punti\"}}{{if and $.RevealEffects .Effects}} [{{.Effects}}]{{end}}, {{.Partecipants}} {{plural .Partecipants \"partecipante\" \"partecipanti\"}}\n{{end}}{{end}}",
+	"history.not_found": "Non c'è nessuna giornata {{.Date}} nello storico della chat.",
+	"history.empty":     "{{.UserName}} non ha ancora partecipato a nessun evento.",
+	"history.claims":    "Ultime partecipazioni di {{.UserName}}:\n\n{{range .Claims}} | {{.Date}} {{.EventKey}} {{if .Won}}vittoria{{else}}partecipazione{{end}}: {{.Points}} {{plural .Points \"punto\" \"punti\"}}{{if and $.RevealEffects .Effects}} [{{join .Effects \", \"}}]{{end}}, +{{.Delay.Seconds}}s\n{{end}}",
+}
+
+// Messages of the bot in English
+var messagesEN = map[string]string{
+	// Common responses
+	"unauthorized":              "You are not authorized to use this command",
+	"wrong_syntax":              "The command is: {{.Syntax}}",
+	"property_updated":          "Property '{{.Property}}' updated.",
+	"parameter_not_valid":       "Parameter <{{.Parameter}}> not valid. It must be {{.Expected}}.",
+	"expected.integer":          "an integer number",
+	"expected.positive_integer": "a positive integer number",
+	"expected.boolean":          "a boolean",
+	"expected.effects":          "a list of valid effects",
+	"expected.date":             "a date in the yyyy-mm-dd format",
+	"expected.time":             "a time in the hh:mm format",
+	"expected.recurrence":       "\"daily\", a day in the mm-dd format or a date in the yyyy-mm-dd format",
+	"entity_not_found":          "{{.Entity}} ({{.Value}}) not found.",
+	"entity.user":               "User",
+	"entity.event":              "Event",
+	"entity.effect":             "Effect",
+	"entity.custom_event":       "Custom event",
+
+	// Claims
+	"claim.activated":         "{{if .EventName}}{{.EventName}}! {{end}}{{if lt .Points 0}}Damn{{else if eq .Points 0}}Too bad{{else}}Congratulations{{end}} {{.User}}! {{.Points}} {{plural .Points \"point\" \"points\"}} for you{{if .Effects}} thanks to the effects:\n{{.Effects}}{{end}}.\nIt took you +{{.Delay}}s",
+	"claim.already_activated": "The event has already been activated by {{.Winner}} +{{.Delta}}s ago.\nIt took you +{{.Delay}}s.{{if .Effects}}\nYou arrived so close that {{.Winner}} gets the effects:\n{{.Effects}}.{{end}}",
+	"claim.repeated_minute":   "{{.EventName}} is repeated by the clock change: the event counts only the first time.",
+	"claim.too_late":          "Too late! The {{.EventName}} event ended +{{printf \"%.2f\" .Late}}s ago.",
+	"claim.false_start":       "False start! The {{.EventName}} event hasn't started yet.",
+	"result":                  "Event {{.EventName}} won by {{.Winner}}: {{.Points}} {{plural .Points \"point\" \"points\"}}{{if .Effects}} thanks to the effects:\n{{.Effects}}{{end}}\n\nPartecipants:\n{{range $i, $p := .Partecipants}}{{inc $i}}] {{$p.UserName}} +{{$p.Delay.Seconds}}s\n{{end}}\n{{if .Final}}Final result.{{else}}Updating until the end of the minute...{{end}}",
+
+	// Commands
+	"check.logs":                    "Logs checked. Here is the current state:\n\n",
+	"check.users":                   "Users checked. Here is the current state:\n\n",
+	"check.events":                  "Events checked. Here is the current state:\n\n",
+	"check.outbox":                  "Outbox checked. Here is the current state:\n\nSent: {{.Sent}}\nDelayed: {{.Delayed}} (average {{.AverageDelay}}, max {{.MaxDelay}})\nRetries: {{.Retried}}\nDropped: {{.Dropped}}",
+	"check.outbox_disabled":         "Outbox not enabled.",
+	"credits":                       "The source code, available on GitHub at MoraGames/clockyuwu, is written entirely in GoLang and makes use of the \"telegram-bot-api\" library.\nFor any bug reports or feature proposals, please refer to the GitHub project.\n\nDeveloper:\n- Telegram: @MoraGames\n- Discord: @moragames\n- Instagram: @moragames.dev\n- GitHub: MoraGames\n\nProject:\n- Telegram: @clockyuwu_bot\n- GitHub: MoraGames/clockyuwu\n\nSpecial thanks go to the first testers (as well as players) of the minigame managed by the bot, \"Vano\", \"Ale\" and \"Alex\".",
+	"help":                          "Name: {{.Name}}\nVersion: {{.Version}}\n\nThis is a list of all possible commands within the bot:\n\n- /start : Get an introductory message about the bot's features.\n - /help : Get a complete list of all available commands.\n - /ranking [points|wins|partecipations|winrate|avgdelay] [day|week|month|season|all] : Get the ranking of the players (by default by points in the current season).\n - /stats : Get the player's game statistics.\n - /achievements [user] : Get the achievements unlocked by the player.\n - /history [user] [n] : Get the last partecipations of the player.\n - /yesterday : Get the recap of yesterday.\n - /day <yyyy-mm-dd> : Get the recap of a past day.\n - /language : Choose the language of the bot, for you or for the chat.\n - /settings : Show or change the settings of the chat (moderators only).\n - /ping : Verify if the bot is running.\n - /credits : Get more informations abount the project.\n\nAdmin's Only:\n - /check : Get more informations about bot status and data.\n - /reset : Force the execution of a specific Reset() function.\n - /update : Update the value of a data structure.\n - /event <list|add|remove> : Manage the custom events of the chat (the list is for everyone).",
+	"list.sets":                     "\nEnabled Sets ({{.Count}}):\n{{range .Sets}} | {{printf \"%q\" .}}\n{{end}}{{if .Today}}\nCalendar Sets of today:\n{{range .Today}} | {{printf \"%q\" .Name}}: {{if .Minutes}}{{join .Minutes \", \"}}{{else}}no events today{{end}}\n{{end}}{{end}}",
+	"list.effects":                  "\nEnabled Effects ({{.Count}}):\n{{range $name, $num := .Effects}} | {{printf \"%q\" $name}} = {{$num}}\n{{end}}{{if .Timing}}\nTiming Effects ({{len .Timing}}):\n{{range .Timing}} | {{printf \"%q\" .Name}} ({{.Key}}{{.Value}}{{if .Cap}}, at most {{.Cap}} {{plural .Cap \"point\" \"points\"}}{{end}}): {{if eq .Condition \"second\"}}{{if eq .From .To}}claimed at second {{.From}}{{else}}claimed from second {{.From}} to {{.To}}{{end}}{{else if eq .Condition \"time\"}}events from {{.From}} to {{.To}}{{else if eq .Condition \"photo_finish\"}}the runner-up arrives within {{.Within}}{{end}}\n{{end}}{{end}}",
+	"list.jackpot":                  "{{if not .Enabled}}The jackpot is not enabled.{{else}}The jackpot is {{.Jackpot}} {{plural .Jackpot \"point\" \"points\"}}, {{if .Minute}}for the winner of the {{.Minute}} event{{else}}for the winner of the next event{{end}}.{{end}}",
+	"event.added":                   "Custom event {{printf \"%q\" .Name}} at {{.Time}} added, it will be active from the next reset of the events.",
+	"event.removed":                 "{{.Count}} {{plural .Count \"custom event\" \"custom events\"}} at {{.Time}} removed, from the next reset of the events.",
+	"event.list.empty":              "There are no custom events in this chat.",
+	"event.list":                    "Custom events ({{len .Events}}):\n{{range .Events}} | {{.Time}} {{printf \"%q\" .Name}}: {{.Points}} {{plural .Points \"point\" \"points\"}}{{if .Effects}} with the effects {{join .EffectNames \", \"}}{{end}}, {{if eq .Kind \"daily\"}}every day{{else if eq .Kind \"yearly\"}}every year on {{.Recurrence}}{{else}}on {{.Recurrence}}{{end}}\n{{end}}",
+	"ping":                          "pong",
+	"ranking.empty":                 "No user has partecipated in the events of the season yet.",
+	"ranking":                       "The ranking by {{.Metric}} ({{.Period}}) is the following:\n\n{{range .Ranking}}{{.Position}}] {{.Username}}: {{.Value}} ({{.Gap}})\n{{end}}",
+	"ranking.metric.points":         "points",
+	"ranking.metric.wins":           "wins",
+	"ranking.metric.partecipations": "partecipations",
+	"ranking.metric.winrate":        "wins/partecipations",
+	"ranking.metric.avgdelay":       "average delay",
+	"ranking.period.day":            "today",
+	"ranking.period.week":           "this week",
+	"ranking.period.month":          "this month",
+	"ranking.period.season":         "season",
+	"ranking.period.all":            "all time",
+	"reset.events":                  "Events reset",
+	"reset.users":                   "Users reset",
+	"start":                         "{{.Name}} is a bot that allows you to play a time-wasting game with one or more groups of friends within Telegram groups. Once the bot is added, the game mainly (but not exclusively) involves sending messages in the \"hh:mm\" format at certain times of the day, in exchange for valuable points. The person who has earned the most points at the end of the championship will be the new Clocky Champion!\nUse /help to get a list of all commands or /credits for more information about the project.\n\n- {{.Name}}, a bot from @MoraGames.",
+	"stats.none":                    "You haven't partecipated in any event yet.",
+	"stats.own":                     "Your stats are:\n\n{{template \"stats\" .}}",
+	"stats.other_none":              "{{.UserName}} hasn't partecipated in any event yet.",
+	"stats.other":                   "The stats of {{.User.UserName}} are:\n\n{{template \"stats\" .}}",
+	"stats":                         "Total points: {{.User.TotalPoints}}\nTotal partecipations: {{.User.TotalEventPartecipations}}\nTotal wins: {{.User.TotalEventWins}}\nPoints/Partecipations: {{printf \"%.2f\" .PointsPerPartecipation}}\nPoints/Wins: {{printf \"%.2f\" .PointsPerWin}}\nWins/Partecipations: {{printf \"%.2f\" .WinsPerPartecipation}}\nWins/Losses: {{printf \"%.2f\" .WinsPerLoss}}\nActive effects: {{.User.StringifyEffects}}{{if .Handicaps}}\nHandicaps:{{range .Handicaps}}\n | {{.Name}} ({{.Key}}{{.Value}}{{if .Cap}}, at most {{.Cap}} {{plural .Cap \"point\" \"points\"}}{{end}}): {{if eq .Condition \"leader_gap\"}}{{.Measure}} {{plural .Measure \"point\" \"points\"}} behind the leader{{else if eq .Condition \"next_gap\"}}{{.Measure}} {{plural .Measure \"point\" \"points\"}} behind the next player{{else if eq .Condition \"percentile\"}}{{.Measure}}% of the players ahead{{else if eq .Condition \"days_absent\"}}{{.Measure}} {{plural .Measure \"day\" \"days\"}} of absence{{end}}{{end}}{{end}}{{with .Activity}}\nBest day: {{.BestDay}} ({{.BestDayPoints}} {{plural .BestDayPoints \"point\" \"points\"}})\nFavourite minute: {{.FavouriteMinute}} ({{.FavouriteMinuteClaims}} {{plural .FavouriteMinuteClaims \"time\" \"times\"}})\nAverage delay: +{{printf \"%.2f\" .AverageDelay.Seconds}}s\nBest delay: +{{.BestDelay.Seconds}}s{{end}}{{if .AchievementsNum}}\nAchievements: {{len .Achievements}}/{{.AchievementsNum}}{{if .Achievements}} ({{join .Achievements \", \"}}){{end}}{{end}}",
+	"language.current":              "Your language is {{.Language}}{{if .ChatLanguage}}, the chat one is {{.ChatLanguage}}{{end}}.\nUse /language <{{.Languages}}> to change your language, or /language chat <{{.Languages}}> to change the chat one.",
+	"language.updated":              "Language updated: {{.Language}}.",
+	"language.chat_updated":         "Chat language updated: {{.Language}}.",
+
+	// Settings
+	"settings.menu":                  "Chat settings:\n\nLanguage: {{.Language}}\nTimezone: {{.Timezone}}\nReset time: {{.ResetTime}}\nEffects revealed: {{if .RevealEffects}}yes{{else}}no{{end}}\n\nOnly the moderators can change them.",
+	"settings.choose":                "{{.Option}} (now: {{.Value}}).\nChoose the new value, or use /settings <setting> <value> for one not listed.",
+	"settings.button":                "{{.Option}}: {{.Value}}",
+	"settings.back":                  "« Back",
+	"settings.updated":               "{{.Option}} updated: {{.Value}}.",
+	"settings.not_valid":             "Value not valid for '{{.Option}}': {{.Value}}.",
+	"settings.effects_hidden":        "The effects are hidden in this chat.",
+	"settings.option.language":       "Language",
+	"settings.option.timezone":       "Timezone",
+	"settings.option.reset_time":     "Reset time",
+	"settings.option.reveal_effects": "Effects revealed",
+	"settings.value.true":            "yes",
+	"settings.value.false":           "no",
+
+	// Scheduled messages
+	"recap":           "Recap of the day {{.Date}}:\n\nActivated events: {{.ActivatedEventsNum}}/{{.EnabledEventsNum}}\nMissed events: {{len .MissedEvents}}{{if and .MissedEvents (le (len .MissedEvents) 10)}} ({{range $i, $e := .MissedEvents}}{{if $i}}, {{end}}{{$e}}{{end}}){{end}}\nEarned points: {{.EarnedPointsSum}}\n{{if .TopScorers}}\nTop scorers:\n{{range $i, $s := .TopScorers}}{{if lt $i 3}}{{inc $i}}] {{$s.UserName}}: {{$s.Points}} {{plural $s.Points \"point\" \"points\"}} ({{$s.Wins}} {{plural $s.Wins \"event\" \"events\"}})\n{{end}}{{end}}{{with .BiggestWin}}\nBiggest win: {{.UserName}} at {{.EventName}}, {{.Points}} {{plural .Points \"point\" \"points\"}}\n{{end}}{{with .FastestReaction}}Fastest reaction: {{.UserName}} at {{.EventName}}, +{{.Delay.Seconds}}s\n{{end}}{{if and .RevealEffects .NegativeVictims}}\nVictims of the negative multipliers:\n{{range .NegativeVictims}} | {{.UserName}} at {{.EventName}}: {{.Points}} {{plural .Points \"point\" \"points\"}}\n{{end}}{{end}}{{else}}\nNo event has been activated.\n{{end}}",
+	"reset.recap":     "The events have been reset.\nHere are some informations:\n\nSets: {{.EnabledSetsNum}}/{{.TotalSetsNum}}\nEvents: {{.EnabledEventsNum}}/{{.TotalEventsNum}}\nAvailable points: {{.EnabledPointsSum}}\n\nEnabled Sets ({{.EnabledSetsNum}}):\n{{range .EnabledSets}} | {{printf \"%q\" .}}\n{{end}}{{if .RevealEffects}}\nEnabled Effects ({{.EnabledEffectsNum}}):\n{{range $name, $num := .EnabledEffects}} | {{printf \"%q\" $name}} = {{$num}}\n{{end}}{{end}}\nGood luck!",
+	"shutdown.notice": "The bot is going offline, see you soon!",
+
+	// Jackpot
+	"jackpot.won": "{{.User}} won the jackpot of {{.Jackpot}} {{plural .Jackpot \"point\" \"points\"}} with the {{.Event}} event!",
+
+	// Achievements
+	"achievement.unlocked": "{{.User}} unlocked the achievement \"{{.Name}}\": {{.Description}}.{{if .Reward}}\nReward: {{.Reward}}.{{end}}",
+	"achievements":         "Achievements of {{.UserName}} ({{.Unlocked}}/{{len .Achievements}}):\n\n{{range .Achievements}}{{if .Unlocked}}[x]{{else}}[ ]{{end}} {{.Name}}: {{.Description}}{{if .RewardName}} (reward: {{.RewardName}}){{end}}{{if .Unlocked}}, unlocked on {{.UnlockedAt}}{{end}}\n{{end}}",
+	"achievements.none":    "There are no achievements.",
+
+	// History
+	"history.day":       "{{template \"recap\" .}}{{if .Events}}\nActivated events:\n{{range .Events}} | {{.Name}} {{.Winner}}: {{.Points}} {{plural .Points \"point\" \"points\"}}{{if and $.RevealEffects .Effects}} [{{.Effects}}]{{end}}, {{.Partecipants}} {{plural .Partecipants \"partecipant\" \"partecipants\"}}\n{{end}}{{end}}",
+	"history.not_found": "There is no day {{.Date}} in the history of the chat.",
+	"history.empty":     "{{.UserName}} hasn't partecipated in any event yet.",
+	"history.claims":    "Last partecipations of {{.UserName}}:\n\n{{range .Claims}} | {{.Date}} {{.EventKey}} {{if .Won}}win{{else}}partecipation{{end}}: {{.Points}} {{plural .Points \"point\" \"points\"}}{{if and $.RevealEffects .Effects}} [{{join .Effects \", \"}}]{{end}}, +{{.Delay.Seconds}}s\n{{end}}",
+}
+
+// Create the catalog of the bot messages, falling back on the default language
+func NewCatalog(defaultLanguage string) *i18n.Catalog {
+	catalog := i18n.New(defaultLanguage)
+	if err := catalog.Add("it", i18n.OneOther, messagesIT); err != nil {
+		panic(err)
+	}
+	if err := catalog.Add("en", i18n.OneOther, messagesEN); err != nil {
+		panic(err)
+	}
+	return catalog
+}
+
+// Get the language used with the user in the chat: the one chosen by the user (in their private chat), then the chat one
+func Language(chatID, userID int64, utils types.Utils) string {
+	if chat, ok := Chats[userID]; ok && userID != 0 && chat.Settings.Language != nil {
+		return *chat.Settings.Language
+	}
+	return GetSettings(chatID, utils).Language
+}
+
+// Get the text of the message in the language used with the user in the chat (userID 0 for the chat language)
+func Translate(chatID, userID int64, key string, data any, utils types.Utils) string {
+	return utils.Catalog.Text(Language(chatID, userID, utils), key, data)
+}
+
+// Get the text of the message in the language used with the sender of the update
+func TranslateUpdate(update tgbotapi.Update, key string, data any, utils types.Utils) string {
+	return Translate(update.Message.Chat.ID, update.Message.From.ID, key, data, utils)
+}
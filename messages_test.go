@@ -0,0 +1,130 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MoraGames/clockyuwu/config"
+	"github.com/MoraGames/clockyuwu/events"
+	"github.com/MoraGames/clockyuwu/pkg/types"
+	"github.com/MoraGames/clockyuwu/structs"
+)
+
+func Test_Catalog_Complete(t *testing.T) {
+	catalog := NewCatalog("it")
+	for _, lang := range catalog.Languages() {
+		for _, other := range catalog.Languages() {
+			for _, key := range catalog.Keys(lang) {
+				if !contains(catalog.Keys(other), key) {
+					t.Errorf("Message %q of %q is missing in %q", key, lang, other)
+				}
+			}
+		}
+	}
+}
+
+func Test_Catalog_Plurals(t *testing.T) {
+	catalog := NewCatalog("it")
+	tests := []struct {
+		lang     string
+		points   int
+		expected string
+	}{
+		{"it", -2, "Accidenti alice! -2 punti per te"},
+		{"it", -1, "Accidenti alice! -1 punto per te"},
+		{"it", 0, "Peccato alice! 0 punti per te"},
+		{"it", 1, "Complimenti alice! 1 punto per te"},
+		{"en", 1, "Congratulations alice! 1 point for you"},
+		{"en", 3, "Congratulations alice! 3 points for you"},
+	}
+	for _, test := range tests {
+		text := catalog.Text(test.lang, "claim.activated", map[string]any{"User": "alice", "Points": test.points, "Delay": 1.5})
+		if !strings.HasPrefix(text, test.expected) {
+			t.Errorf("Message for %v points in %q should start with %q, got %q", test.points, test.lang, test.expected, text)
+		}
+	}
+}
+
+func Test_RecapText(t *testing.T) {
+	utils := types.Utils{Catalog: NewCatalog("it")}
+	recap := events.DayRecap{
+		Date:               "2024-03-30",
+		EnabledEventsNum:   3,
+		ActivatedEventsNum: 2,
+		MissedEvents:       []string{"13:31"},
+		EarnedPointsSum:    -1,
+		TopScorers:         []events.ScorerRecap{{UserName: "alice", Wins: 1, Points: 2}, {UserName: "bob", Wins: 1, Points: -3}},
+		BiggestWin:         &events.EventRecap{EventName: "01:23", UserName: "alice", Points: 2},
+		FastestReaction:    &events.EventRecap{EventName: "12:34", UserName: "bob", Points: -3, Delay: 1500 * time.Millisecond},
+		NegativeVictims:    []events.EventRecap{{EventName: "12:34", UserName: "bob", Points: -3}},
+	}
+
+	text := RecapText(recap, types.Settings{Language: "en", RevealEffects: true}, utils)
+	for _, expected := range []string{"Recap of the day 2024-03-30", "Missed events: 1 (13:31)", "1] alice: 2 points (1 event)", "Biggest win: alice at 01:23", "Fastest reaction: bob at 12:34, +1.5s", "bob at 12:34: -3 points"} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("The recap should contain %q, got %q", expected, text)
+		}
+	}
+
+	// The victims of the negative multipliers reveal the effects
+	if text := RecapText(recap, types.Settings{Language: "it", RevealEffects: false}, utils); strings.Contains(text, "Vittime") || !strings.Contains(text, "Vittoria più grande: alice alle 01:23, 2 punti") {
+		t.Errorf("Unexpected recap with hidden effects: %q", text)
+	}
+}
+
+func Test_StatsHandicaps(t *testing.T) {
+	catalog := NewCatalog("it")
+	data := StatsData(&structs.User{UserName: "bob", TotalPoints: 5, TotalEventPartecipations: 2}, types.Utils{Config: &config.Config{}})
+	data["Handicaps"] = []AppliedHandicap{
+		{config.Handicap{Name: "Comeback 3", Condition: "leader_gap", Key: "+", Value: 3}, 85},
+		{config.Handicap{Name: "Welcome Back", Condition: "days_absent", Key: "*", Value: 2, Cap: 3}, 1},
+	}
+
+	expected := "\nHandicap:\n | Comeback 3 (+3): 85 punti dal primo\n | Welcome Back (*2, al massimo 3 punti): 1 giorno di assenza"
+	if text := catalog.Text("it", "stats", data); !strings.Contains(text, expected) {
+		t.Errorf("The stats should explain the handicaps with %q, got %q", expected, text)
+	}
+}
+
+func Test_Language(t *testing.T) {
+	inTempDir(t)
+	utils := testUtils(&config.Config{Settings: config.Settings{Timezone: "UTC", Language: "it"}}, time.Now())
+	resetState(utils)
+	chatID := int64(-1)
+
+	// The default language is used until a language is chosen
+	if lang := Language(chatID, testAlice.ID, utils); lang != "it" {
+		t.Errorf("The default language should be used, got %q", lang)
+	}
+
+	// The language of the user is used everywhere, the chat keeps its own
+	if err := SetSetting(testAlice.ID, testAlice.UserName, "language", "en", utils); err != nil {
+		t.Fatal(err)
+	}
+	for userID, expected := range map[int64]string{testAlice.ID: "en", testBob.ID: "it", 0: "it"} {
+		if lang := Language(chatID, userID, utils); lang != expected {
+			t.Errorf("The language of user %v should be %q, got %q", userID, expected, lang)
+		}
+	}
+
+	// The language of the chat is used by the users without one
+	if err := SetSetting(chatID, "Test Group", "language", "en", utils); err != nil {
+		t.Fatal(err)
+	}
+	if lang := Language(chatID, testBob.ID, utils); lang != "en" {
+		t.Errorf("The chat language should be used, got %q", lang)
+	}
+	if err := SetSetting(chatID, "Test Group", "language", "xx", utils); err == nil {
+		t.Error("An unknown language should not be set")
+	}
+}
+
+func contains(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
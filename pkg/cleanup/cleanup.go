@@ -0,0 +1,199 @@
+package cleanup
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/MoraGames/clockyuwu/pkg/clock"
+	"github.com/MoraGames/clockyuwu/pkg/types"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	// Options of the cleanup service
+	Options struct {
+		After  time.Duration // how long the transient messages are kept
+		Claims bool          // delete also the claim messages of the users
+		File   string        // file where the messages to delete are saved, to survive restarts
+	}
+
+	// Message is a message to delete
+	Message struct {
+		ChatID    int64
+		MessageID int
+		DeleteAt  time.Time
+	}
+
+	// Service deletes the transient messages (the bot replies and, optionally, the users claims) after a while.
+	// The messages are tracked on file, and deleted by the periodic calls of Sweep.
+	// All the methods can be called on a nil service, that does nothing.
+	Service struct {
+		sender  types.Sender
+		clock   clock.Clock
+		options Options
+		logger  *logrus.Logger
+
+		mutex    sync.Mutex
+		messages []Message
+	}
+
+	// transientSender tracks the messages sent through it
+	transientSender struct {
+		types.Sender
+		service *Service
+	}
+)
+
+// New creates the cleanup service, reloading the messages to delete from the file
+func New(sender types.Sender, c clock.Clock, options Options, logger *logrus.Logger) *Service {
+	s := &Service{sender: sender, clock: c, options: options, logger: logger, messages: make([]Message, 0)}
+
+	file, err := os.ReadFile(options.File)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		logger.WithFields(logrus.Fields{
+			"file": options.File,
+			"err":  err,
+		}).Error("Error while reading file")
+	case len(file) != 0:
+		if err := json.Unmarshal(file, &s.messages); err != nil {
+			logger.WithFields(logrus.Fields{
+				"file": options.File,
+				"err":  err,
+			}).Error("Error while unmarshalling data")
+		}
+	}
+	return s
+}
+
+// Transient returns a sender whose sent messages are deleted after a while
+func (s *Service) Transient(sender types.Sender) types.Sender {
+	if s == nil {
+		return sender
+	}
+	return transientSender{Sender: Persistent(sender), service: s}
+}
+
+// Persistent returns a sender whose messages are kept (the sender itself, if it isn't a transient one)
+func Persistent(sender types.Sender) types.Sender {
+	if transient, ok := sender.(transientSender); ok {
+		return transient.Sender
+	}
+	return sender
+}
+
+func (ts transientSender) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	// The messages sent in the background are tracked once they are sent
+	if async, ok := ts.Sender.(types.AsyncSender); ok {
+		async.SendAsync(c, func(message tgbotapi.Message, err error) { ts.trackSent(c, message, err) })
+		return tgbotapi.Message{}, nil
+	}
+	message, err := ts.Sender.Send(c)
+	ts.trackSent(c, message, err)
+	return message, err
+}
+
+// trackSent tracks the message sent by the request, if it's a message to delete
+func (ts transientSender) trackSent(c tgbotapi.Chattable, message tgbotapi.Message, err error) {
+	if err == nil && message.Chat != nil {
+		switch c.(type) {
+		case tgbotapi.MessageConfig, tgbotapi.DocumentConfig:
+			ts.service.track(message.Chat.ID, message.MessageID)
+		}
+	}
+}
+
+// TrackClaim deletes the claim message of a user after a while, if the claims cleanup is enabled
+func (s *Service) TrackClaim(chatID int64, messageID int) {
+	if s == nil || !s.options.Claims {
+		return
+	}
+	s.track(chatID, messageID)
+}
+
+func (s *Service) track(chatID int64, messageID int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.messages = append(s.messages, Message{ChatID: chatID, MessageID: messageID, DeleteAt: s.clock.Now().Add(s.options.After)})
+	s.save()
+}
+
+// Pending returns the number of messages waiting to be deleted
+func (s *Service) Pending() int {
+	if s == nil {
+		return 0
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.messages)
+}
+
+// Sweep deletes the expired messages and returns how many have been deleted.
+// The messages that can't be deleted (e.g. already deleted by an admin) are forgotten too.
+func (s *Service) Sweep() int {
+	if s == nil {
+		return 0
+	}
+
+	// Take the expired messages, the deletions are made without holding the lock
+	s.mutex.Lock()
+	now := s.clock.Now()
+	expired, kept := make([]Message, 0), make([]Message, 0, len(s.messages))
+	for _, message := range s.messages {
+		if message.DeleteAt.After(now) {
+			kept = append(kept, message)
+		} else {
+			expired = append(expired, message)
+		}
+	}
+	s.messages = kept
+	if len(expired) != 0 {
+		s.save()
+	}
+	s.mutex.Unlock()
+
+	deleted := 0
+	for _, message := range expired {
+		if _, err := s.sender.Request(tgbotapi.NewDeleteMessage(message.ChatID, message.MessageID)); err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"err":  err,
+				"chat": message.ChatID,
+				"msg":  message.MessageID,
+			}).Warn("Error while deleting message")
+			continue
+		}
+		deleted++
+	}
+
+	if len(expired) != 0 {
+		s.logger.WithFields(logrus.Fields{
+			"deleted": deleted,
+			"failed":  len(expired) - deleted,
+			"pending": len(kept),
+		}).Debug("Messages cleaned up")
+	}
+	return deleted
+}
+
+// save writes the messages to delete on file (the lock must be held)
+func (s *Service) save() {
+	file, err := json.MarshalIndent(s.messages, "", " ")
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"err": err,
+		}).Error("Error while marshalling data")
+		return
+	}
+	if err := os.WriteFile(s.options.File, file, 0644); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"file": s.options.File,
+			"err":  err,
+		}).Error("Error while writing file")
+	}
+}
@@ -0,0 +1,75 @@
+package cleanup
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MoraGames/clockyuwu/pkg/clock"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+var testStart = time.Date(2024, time.March, 30, 12, 0, 0, 0, time.UTC)
+
+// recordingSender sends every message with a new ID and records the deleted ones
+type recordingSender struct {
+	nextMessageID int
+	deleted       []tgbotapi.DeleteMessageConfig
+}
+
+func (s *recordingSender) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	s.nextMessageID++
+	message := tgbotapi.Message{MessageID: s.nextMessageID}
+	if msg, ok := c.(tgbotapi.MessageConfig); ok {
+		message.Chat = &tgbotapi.Chat{ID: msg.ChatID}
+	}
+	return message, nil
+}
+
+func (s *recordingSender) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	if deletion, ok := c.(tgbotapi.DeleteMessageConfig); ok {
+		s.deleted = append(s.deleted, deletion)
+	}
+	return &tgbotapi.APIResponse{Ok: true}, nil
+}
+
+func Test_Service(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	options := Options{After: 10 * time.Minute, Claims: true, File: filepath.Join(t.TempDir(), "cleanup.json")}
+	v := clock.NewVirtual(testStart)
+	sender := &recordingSender{}
+	s := New(sender, v, options, logger)
+
+	// A transient reply to a claim, and a persistent message
+	s.TrackClaim(-1, 100)
+	transient := s.Transient(sender)
+	reply, _ := transient.Send(tgbotapi.NewMessage(-1, "Complimenti alice!"))
+	Persistent(transient).Send(tgbotapi.NewMessage(-1, "Gli eventi son stati resettati."))
+
+	if deleted := s.Sweep(); deleted != 0 {
+		t.Errorf("Nothing should be deleted before the time, deleted %v", deleted)
+	}
+
+	// The messages to delete survive a restart
+	v.Advance(10 * time.Minute)
+	s = New(sender, v, options, logger)
+	if s.Pending() != 2 {
+		t.Fatalf("The restarted service should have 2 pending messages, got %v", s.Pending())
+	}
+	if deleted := s.Sweep(); deleted != 2 || s.Pending() != 0 {
+		t.Errorf("2 messages should be deleted, deleted %v (%v pending)", deleted, s.Pending())
+	}
+	if len(sender.deleted) != 2 || sender.deleted[0].MessageID != 100 || sender.deleted[1].MessageID != reply.MessageID {
+		t.Errorf("The claim and the reply should be deleted, deleted %+v", sender.deleted)
+	}
+
+	// A nil service does nothing
+	var disabled *Service
+	if disabled.Transient(sender) != sender || disabled.Sweep() != 0 {
+		t.Error("A nil service should do nothing")
+	}
+	disabled.TrackClaim(-1, 101)
+}
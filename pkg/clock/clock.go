@@ -0,0 +1,188 @@
+package clock
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/go-co-op/gocron"
+)
+
+// Clock is the source of the time used by the game, the updates loop and the scheduler
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+	// AfterFunc has the same signature of time.AfterFunc (and of the gocron custom timer):
+	// the returned timer can be stopped to prevent f from running.
+	AfterFunc(d time.Duration, f func()) *time.Timer
+}
+
+// Real is the wall clock
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                                  { return time.Now() }
+func (realClock) Sleep(d time.Duration)                           { time.Sleep(d) }
+func (realClock) AfterFunc(d time.Duration, f func()) *time.Timer { return time.AfterFunc(d, f) }
+
+type (
+	// Virtual is a clock that moves only when it's advanced, firing the expired timers in order.
+	// It allows to simulate days (or a whole championship) in seconds, with exact timestamps.
+	Virtual struct {
+		mutex  sync.Mutex
+		now    time.Time
+		timers []*virtualTimer
+	}
+
+	virtualTimer struct {
+		deadline time.Time
+		// timer never fires by itself: it's only used to know if the timer has been stopped
+		timer *time.Timer
+		f     func()
+	}
+)
+
+func NewVirtual(start time.Time) *Virtual {
+	return &Virtual{now: start}
+}
+
+func (v *Virtual) Now() time.Time {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	return v.now
+}
+
+// Sleep blocks until the clock is advanced by at least d
+func (v *Virtual) Sleep(d time.Duration) {
+	wake := make(chan struct{})
+	v.AfterFunc(d, func() { close(wake) })
+	<-wake
+}
+
+func (v *Virtual) AfterFunc(d time.Duration, f func()) *time.Timer {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	timer := time.AfterFunc(time.Duration(math.MaxInt64), func() {})
+	v.timers = append(v.timers, &virtualTimer{deadline: v.now.Add(d), timer: timer, f: f})
+	return timer
+}
+
+// Advance moves the clock forward by d
+func (v *Virtual) Advance(d time.Duration) {
+	v.Set(v.Now().Add(d))
+}
+
+// Set moves the clock to t, running (in order, with the clock set to their deadlines) all the expired timers.
+// The timers created by the fired functions are fired too, if they expire before t.
+// Moving the clock backward doesn't fire anything.
+func (v *Virtual) Set(t time.Time) {
+	for {
+		v.mutex.Lock()
+		next := v.popExpired(t)
+		if next == nil {
+			v.now = t
+			v.mutex.Unlock()
+			return
+		}
+		if next.deadline.After(v.now) {
+			v.now = next.deadline
+		}
+		v.mutex.Unlock()
+
+		// Stop returns false if the timer has already been stopped by its owner
+		if next.timer.Stop() {
+			next.f()
+		}
+	}
+}
+
+// popExpired removes and returns the first timer expiring before t (nil if there are none)
+func (v *Virtual) popExpired(t time.Time) *virtualTimer {
+	first := -1
+	for i, timer := range v.timers {
+		if !timer.deadline.After(t) && (first == -1 || timer.deadline.Before(v.timers[first].deadline)) {
+			first = i
+		}
+	}
+	if first == -1 {
+		return nil
+	}
+
+	next := v.timers[first]
+	v.timers = append(v.timers[:first], v.timers[first+1:]...)
+	return next
+}
+
+// FastForward advances the clock by speed times the real time elapsed, every tick, until the context is canceled
+func (v *Virtual) FastForward(ctx context.Context, speed float64, tick time.Duration) {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	last := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			v.Advance(time.Duration(float64(now.Sub(last)) * speed))
+			last = now
+		}
+	}
+}
+
+// AdvanceScheduler moves the clock to t like Set, but it stops at every run of the scheduler jobs and waits for them to finish.
+// The jobs run asynchronously, so it's the only way to let them read the clock at their scheduled time.
+func (v *Virtual) AdvanceScheduler(scheduler *gocron.Scheduler, t time.Time) {
+	for {
+		var next time.Time
+		due := make(map[*gocron.Job]int)
+		for _, job := range scheduler.Jobs() {
+			switch run := job.NextRun(); {
+			case run.IsZero() || run.After(t) || (!next.IsZero() && run.After(next)):
+			case next.IsZero() || run.Before(next):
+				next = run
+				due = map[*gocron.Job]int{job: job.FinishedRunCount()}
+			default:
+				due[job] = job.FinishedRunCount()
+			}
+		}
+		if next.IsZero() {
+			v.Set(t)
+			return
+		}
+
+		v.Set(next)
+		for job, finished := range due {
+			for job.FinishedRunCount() <= finished {
+				time.Sleep(time.Millisecond)
+			}
+		}
+	}
+}
+
+// SchedulerTime adapts the clock to the gocron.TimeWrapper interface.
+// Use it with gocron.Scheduler.CustomTime and the clock AfterFunc with gocron.Scheduler.CustomTimer.
+func SchedulerTime(c Clock) gocron.TimeWrapper {
+	return schedulerTime{c}
+}
+
+type schedulerTime struct {
+	clock Clock
+}
+
+func (st schedulerTime) Now(location *time.Location) time.Time { return st.clock.Now().In(location) }
+func (st schedulerTime) Unix(sec int64, nsec int64) time.Time  { return time.Unix(sec, nsec) }
+func (st schedulerTime) Sleep(d time.Duration)                 { st.clock.Sleep(d) }
+
+// NewScheduler creates a gocron scheduler driven by the clock
+func NewScheduler(c Clock, location *time.Location) *gocron.Scheduler {
+	scheduler := gocron.NewScheduler(location)
+	if c != Real {
+		scheduler.CustomTime(SchedulerTime(c))
+		scheduler.CustomTimer(c.AfterFunc)
+	}
+	return scheduler
+}
@@ -0,0 +1,97 @@
+package clock
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+var testStart = time.Date(2024, time.March, 30, 12, 0, 0, 0, time.UTC)
+
+func Test_Virtual_AfterFunc(t *testing.T) {
+	v := NewVirtual(testStart)
+	fired := make([]time.Time, 0)
+
+	v.AfterFunc(2*time.Hour, func() { fired = append(fired, v.Now()) })
+	v.AfterFunc(time.Hour, func() {
+		fired = append(fired, v.Now())
+		// A timer created while firing is fired too if it expires in the advanced interval
+		v.AfterFunc(30*time.Minute, func() { fired = append(fired, v.Now()) })
+	})
+	stopped := v.AfterFunc(90*time.Minute, func() { t.Error("Stopped timer should not fire") })
+	stopped.Stop()
+
+	v.Advance(3 * time.Hour)
+
+	expected := []time.Time{testStart.Add(time.Hour), testStart.Add(90 * time.Minute), testStart.Add(2 * time.Hour)}
+	if len(fired) != len(expected) {
+		t.Fatalf("Expected %v fired timers, got %v", len(expected), len(fired))
+	}
+	for i := range expected {
+		if !fired[i].Equal(expected[i]) {
+			t.Errorf("Timer %v should fire at %v, fired at %v", i, expected[i], fired[i])
+		}
+	}
+	if !v.Now().Equal(testStart.Add(3 * time.Hour)) {
+		t.Errorf("Clock should be at %v, got %v", testStart.Add(3*time.Hour), v.Now())
+	}
+}
+
+func Test_Virtual_Sleep(t *testing.T) {
+	v := NewVirtual(testStart)
+	woken := make(chan time.Time)
+
+	go func() {
+		v.Sleep(time.Minute)
+		woken <- v.Now()
+	}()
+
+	// Wait for the sleeper to register its timer
+	for {
+		v.mutex.Lock()
+		registered := len(v.timers) == 1
+		v.mutex.Unlock()
+		if registered {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	v.Advance(time.Minute)
+	if at := <-woken; at.Before(testStart.Add(time.Minute)) {
+		t.Errorf("Sleep should end after %v, ended at %v", testStart.Add(time.Minute), at)
+	}
+}
+
+func Test_NewScheduler_DailyJob(t *testing.T) {
+	v := NewVirtual(testStart)
+	scheduler := NewScheduler(v, time.UTC)
+
+	var mutex sync.Mutex
+	runs := make([]time.Time, 0)
+	_, err := scheduler.Every(1).Day().At("23:58").Do(func() {
+		mutex.Lock()
+		runs = append(runs, v.Now())
+		mutex.Unlock()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheduler.StartAsync()
+	defer scheduler.Stop()
+
+	// Simulate a week
+	v.AdvanceScheduler(scheduler, testStart.Add(7*24*time.Hour))
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(runs) != 7 {
+		t.Fatalf("Job should run 7 times, got %v", len(runs))
+	}
+	for i, run := range runs {
+		expected := time.Date(2024, time.March, 30+i, 23, 58, 0, 0, time.UTC)
+		if !run.Equal(expected) {
+			t.Errorf("Run %v should be at %v, got %v", i, expected, run)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package console
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+type (
+	// Identity is a fake user or chat used by the console
+	Identity struct {
+		ID   int64
+		Name string
+	}
+
+	// Console is a terminal front end: every input line is a message sent by the current user in the current chat
+	// and every bot response is printed on the output. It implements types.Sender.
+	Console struct {
+		Updates tgbotapi.UpdatesChannel
+		Now     func() time.Time
+		// Advance moves the (virtual) clock forward, nil if the clock can't be moved
+		Advance func(d time.Duration)
+
+		out           io.Writer
+		mutex         sync.Mutex
+		updates       chan tgbotapi.Update
+		users         []Identity
+		chats         []Identity
+		user          int
+		chat          int
+		nextUpdateID  int
+		nextMessageID int
+	}
+)
+
+const help = `Every line is sent as a message by the current user in the current chat (e.g. "12:34" or "/ranking").
+  @<user> <text>  send a single message as another user
+  !user <user>    change the current user (a new one is created if it doesn't exist)
+  !chat <chat>    change the current chat (a new one is created if it doesn't exist)
+  !whoami         show the current user and chat
+  !time           show the current time
+  !advance <d>    advance the virtual clock (e.g. "!advance 1h30m")
+  !help           show this help`
+
+func New(users, chats []Identity, out io.Writer) *Console {
+	if len(users) == 0 {
+		users = []Identity{{ID: 1, Name: "player"}}
+	}
+	if len(chats) == 0 {
+		chats = []Identity{{ID: -1, Name: "console"}}
+	}
+
+	c := &Console{
+		Now:           time.Now,
+		out:           out,
+		updates:       make(chan tgbotapi.Update, 100),
+		users:         users,
+		chats:         chats,
+		nextUpdateID:  1,
+		nextMessageID: 1,
+	}
+	c.Updates = c.updates
+	return c
+}
+
+// DefaultChatID returns the ID of the first chat
+func (c *Console) DefaultChatID() int64 {
+	return c.chats[0].ID
+}
+
+// Run reads the input lines until EOF (or until the context is canceled), then closes the Updates channel
+func (c *Console) Run(ctx context.Context, in io.Reader) {
+	defer close(c.updates)
+
+	c.printf("Console mode: type !help for the list of commands.\n")
+
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if update, ok := c.parseLine(line); ok {
+			select {
+			case c.updates <- update:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// parseLine executes the console commands or returns the update with the message to send
+func (c *Console) parseLine(line string) (tgbotapi.Update, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case trimmed == "":
+		return tgbotapi.Update{}, false
+	case trimmed == "!help":
+		fmt.Fprintln(c.out, help)
+		return tgbotapi.Update{}, false
+	case trimmed == "!whoami":
+		fmt.Fprintf(c.out, "User %q (%v) in chat %q (%v)\n", c.users[c.user].Name, c.users[c.user].ID, c.chats[c.chat].Name, c.chats[c.chat].ID)
+		return tgbotapi.Update{}, false
+	case trimmed == "!time":
+		fmt.Fprintln(c.out, c.Now().Format("2006-01-02 15:04:05.000 MST"))
+		return tgbotapi.Update{}, false
+	case strings.HasPrefix(trimmed, "!advance "):
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(trimmed, "!advance ")))
+		switch {
+		case c.Advance == nil:
+			fmt.Fprintln(c.out, "The clock can be advanced only if it's virtual (see the clock configurations).")
+		case err != nil || d < 0:
+			fmt.Fprintln(c.out, "The duration is not valid (e.g. \"!advance 1h30m\").")
+		default:
+			// The clock is advanced without holding the mutex, the fired jobs could send messages
+			c.mutex.Unlock()
+			c.Advance(d)
+			c.mutex.Lock()
+			fmt.Fprintln(c.out, c.Now().Format("2006-01-02 15:04:05.000 MST"))
+		}
+		return tgbotapi.Update{}, false
+	case strings.HasPrefix(trimmed, "!user "):
+		c.user = c.find(&c.users, strings.TrimSpace(strings.TrimPrefix(trimmed, "!user ")), 1)
+		return tgbotapi.Update{}, false
+	case strings.HasPrefix(trimmed, "!chat "):
+		c.chat = c.find(&c.chats, strings.TrimSpace(strings.TrimPrefix(trimmed, "!chat ")), -1)
+		return tgbotapi.Update{}, false
+	case strings.HasPrefix(trimmed, "!"):
+		fmt.Fprintln(c.out, "Unknown console command, type !help for the list of commands.")
+		return tgbotapi.Update{}, false
+	}
+
+	user := c.user
+	if strings.HasPrefix(line, "@") {
+		name, text, _ := strings.Cut(strings.TrimPrefix(line, "@"), " ")
+		user = c.find(&c.users, name, 1)
+		line = text
+	}
+
+	return c.newMessageUpdate(c.users[user], c.chats[c.chat], line), true
+}
+
+// find returns the index of the identity with the name, creating it (with IDs growing by step) if it doesn't exist
+func (c *Console) find(identities *[]Identity, name string, step int64) int {
+	for i, identity := range *identities {
+		if identity.Name == name {
+			return i
+		}
+	}
+
+	last := (*identities)[len(*identities)-1]
+	*identities = append(*identities, Identity{ID: last.ID + step, Name: name})
+	return len(*identities) - 1
+}
+
+func (c *Console) newMessageUpdate(user, chat Identity, text string) tgbotapi.Update {
+	chatType := "supergroup"
+	if chat.ID > 0 {
+		chatType = "private"
+	}
+
+	message := &tgbotapi.Message{
+		MessageID: c.nextMessageID,
+		From:      &tgbotapi.User{ID: user.ID, UserName: user.Name, FirstName: user.Name},
+		Chat:      &tgbotapi.Chat{ID: chat.ID, Type: chatType, Title: chat.Name},
+		Date:      int(c.Now().Unix()),
+		Text:      text,
+	}
+	c.nextMessageID++
+
+	// Mark the commands as Telegram does
+	if strings.HasPrefix(text, "/") {
+		length := strings.IndexByte(text, ' ')
+		if length == -1 {
+			length = len(text)
+		}
+		message.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: length}}
+	}
+
+	update := tgbotapi.Update{UpdateID: c.nextUpdateID, Message: message}
+	c.nextUpdateID++
+	return update
+}
+
+// Send prints the response on the output
+func (c *Console) Send(chattable tgbotapi.Chattable) (tgbotapi.Message, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	message := tgbotapi.Message{MessageID: c.nextMessageID, Date: int(c.Now().Unix())}
+	c.nextMessageID++
+
+	switch config := chattable.(type) {
+	case tgbotapi.MessageConfig:
+		message.Chat = &tgbotapi.Chat{ID: config.ChatID}
+		message.Text = config.Text
+		fmt.Fprintf(c.out, "%v\n%v\n", c.header(config.ChatID, config.ReplyToMessageID), indent(config.Text))
+	case tgbotapi.DocumentConfig:
+		message.Chat = &tgbotapi.Chat{ID: config.ChatID}
+		message.Caption = config.Caption
+		name, size := "document", 0
+		if config.File != nil && config.File.NeedsUpload() {
+			if fileName, reader, err := config.File.UploadData(); err == nil {
+				bytes, _ := io.ReadAll(reader)
+				name, size = fileName, len(bytes)
+			}
+		}
+		fmt.Fprintf(c.out, "%v\n  [document %q, %v bytes]\n%v\n", c.header(config.ChatID, config.ReplyToMessageID), name, size, indent(config.Caption))
+	case tgbotapi.EditMessageTextConfig:
+		message.MessageID = config.MessageID
+		message.Chat = &tgbotapi.Chat{ID: config.ChatID}
+		message.Text = config.Text
+		fmt.Fprintf(c.out, "bot @ %v (edit of #%v) >\n%v\n", c.chatName(config.ChatID), config.MessageID, indent(config.Text))
+	default:
+		fmt.Fprintf(c.out, "bot > %T\n", chattable)
+	}
+
+	return message, nil
+}
+
+// Request prints the request on the output
+func (c *Console) Request(chattable tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// The players of the console are the administrators of its chats
+	if config, ok := chattable.(tgbotapi.GetChatMemberConfig); ok {
+		result, _ := json.Marshal(tgbotapi.ChatMember{User: &tgbotapi.User{ID: config.UserID}, Status: "administrator"})
+		return &tgbotapi.APIResponse{Ok: true, Result: result}, nil
+	}
+
+	fmt.Fprintf(c.out, "bot > %T\n", chattable)
+	result, _ := json.Marshal(true)
+	return &tgbotapi.APIResponse{Ok: true, Result: result}, nil
+}
+
+func (c *Console) header(chatID int64, replyTo int) string {
+	if replyTo > 0 {
+		return fmt.Sprintf("bot @ %v (reply to #%v) >", c.chatName(chatID), replyTo)
+	}
+	return fmt.Sprintf("bot @ %v >", c.chatName(chatID))
+}
+
+func (c *Console) chatName(chatID int64) string {
+	for _, chat := range c.chats {
+		if chat.ID == chatID {
+			return chat.Name
+		}
+	}
+	return fmt.Sprint(chatID)
+}
+
+func (c *Console) printf(format string, args ...any) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	fmt.Fprintf(c.out, format, args...)
+}
+
+func indent(text string) string {
+	return "  " + strings.ReplaceAll(text, "\n", "\n  ")
+}
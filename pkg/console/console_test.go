@@ -0,0 +1,59 @@
+package console
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func Test_Run(t *testing.T) {
+	out := &bytes.Buffer{}
+	c := New([]Identity{{ID: 1, Name: "alice"}}, []Identity{{ID: -1, Name: "group"}}, out)
+
+	in := strings.NewReader("12:34\n!help\n@bob /ranking now\n!user carl\n\n!chat other\nhello\n")
+	go c.Run(context.Background(), in)
+
+	updates := make([]tgbotapi.Update, 0)
+	for update := range c.Updates {
+		updates = append(updates, update)
+	}
+
+	if len(updates) != 3 {
+		t.Fatalf("Should receive 3 updates, got %v", len(updates))
+	}
+	if m := updates[0].Message; m.Text != "12:34" || m.From.UserName != "alice" || m.Chat.ID != -1 || m.IsCommand() {
+		t.Errorf("Unexpected first message: %+v", m)
+	}
+	if m := updates[1].Message; m.From.UserName != "bob" || !m.IsCommand() || m.Command() != "ranking" || m.CommandArguments() != "now" {
+		t.Errorf("Unexpected second message: %+v", m)
+	}
+	if m := updates[2].Message; m.Text != "hello" || m.From.UserName != "carl" || m.Chat.Title != "other" || m.Chat.ID != -2 {
+		t.Errorf("Unexpected third message: %+v", m)
+	}
+	if !strings.Contains(out.String(), "!user <user>") {
+		t.Error("Help should be printed")
+	}
+}
+
+func Test_Send(t *testing.T) {
+	out := &bytes.Buffer{}
+	c := New(nil, []Identity{{ID: -1, Name: "group"}}, out)
+
+	msg := tgbotapi.NewMessage(-1, "Complimenti alice!\nHai impiegato +1s")
+	msg.ReplyToMessageID = 7
+	message, err := c.Send(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if message.Text != msg.Text || message.Chat.ID != -1 {
+		t.Errorf("Unexpected sent message: %+v", message)
+	}
+	expected := "bot @ group (reply to #7) >\n  Complimenti alice!\n  Hai impiegato +1s\n"
+	if out.String() != expected {
+		t.Errorf("Output should be %q, got %q", expected, out.String())
+	}
+}
@@ -0,0 +1,353 @@
+package fakebot
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+type (
+	// Server is a fake Telegram Bot API server: it serves scripted updates and records the calls made by the bot
+	Server struct {
+		URL      string
+		Endpoint string
+		Self     tgbotapi.User
+
+		server        *httptest.Server
+		closed        chan struct{}
+		mutex         sync.Mutex
+		newUpdate     chan struct{}
+		updates       []tgbotapi.Update
+		nextUpdateID  int
+		nextMessageID int
+		calls         []Call
+		failures      map[string][]Failure
+		members       map[[2]int64]string
+	}
+
+	// Call is a request received by the server
+	Call struct {
+		Method string
+		Params url.Values
+		Files  map[string]File
+		At     time.Time
+	}
+
+	// Failure is an error returned instead of the result of a call (e.g. a 429 flood error with its retry_after)
+	Failure struct {
+		Code        int
+		Description string
+		RetryAfter  int
+	}
+
+	// File is a file uploaded with a request (e.g. by sendDocument)
+	File struct {
+		Name  string
+		Bytes []byte
+	}
+)
+
+// NewServer starts a new fake Bot API server.
+// The bot must be created with tgbotapi.NewBotAPIWithAPIEndpoint(token, server.Endpoint).
+func NewServer() *Server {
+	s := &Server{
+		Self:          tgbotapi.User{ID: 1, IsBot: true, FirstName: "Clocky", UserName: "clockyuwu_test_bot"},
+		closed:        make(chan struct{}),
+		newUpdate:     make(chan struct{}),
+		nextUpdateID:  1,
+		nextMessageID: 1,
+		failures:      make(map[string][]Failure),
+		members:       make(map[[2]int64]string),
+	}
+	s.server = httptest.NewServer(s)
+	s.URL = s.server.URL
+	s.Endpoint = s.server.URL + "/bot%s/%s"
+	return s
+}
+
+// Close stops the server, interrupting the getUpdates requests in progress
+func (s *Server) Close() {
+	close(s.closed)
+	s.server.Close()
+}
+
+// PushUpdate adds an update to the ones returned by getUpdates (the UpdateID is assigned by the server)
+func (s *Server) PushUpdate(update tgbotapi.Update) int {
+	s.mutex.Lock()
+	update.UpdateID = s.nextUpdateID
+	s.nextUpdateID++
+	s.updates = append(s.updates, update)
+	close(s.newUpdate)
+	s.newUpdate = make(chan struct{})
+	s.mutex.Unlock()
+	return update.UpdateID
+}
+
+// PushMessage adds an update with a text message sent by the user in the chat at the given time
+func (s *Server) PushMessage(chat tgbotapi.Chat, from tgbotapi.User, text string, at time.Time) *tgbotapi.Message {
+	s.mutex.Lock()
+	message := &tgbotapi.Message{
+		MessageID: s.nextMessageID,
+		From:      &from,
+		Chat:      &chat,
+		Date:      int(at.Unix()),
+		Text:      text,
+	}
+	s.nextMessageID++
+	s.mutex.Unlock()
+
+	// Mark the commands as Telegram does
+	if strings.HasPrefix(text, "/") {
+		length := strings.IndexByte(text, ' ')
+		if length == -1 {
+			length = len(text)
+		}
+		message.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: length}}
+	}
+
+	s.PushUpdate(tgbotapi.Update{Message: message})
+	return message
+}
+
+// PushCallbackQuery adds an update with a press of the user on an inline button of the message, with the callback data
+func (s *Server) PushCallbackQuery(message *tgbotapi.Message, from tgbotapi.User, data string) string {
+	s.mutex.Lock()
+	id := strconv.Itoa(s.nextUpdateID)
+	s.mutex.Unlock()
+
+	s.PushUpdate(tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{ID: id, From: &from, Message: message, Data: data}})
+	return id
+}
+
+// SetChatMember sets the status ("creator", "administrator", "member", ...) of the user in the chat, returned by getChatMember.
+// The users without a status are members.
+func (s *Server) SetChatMember(chatID, userID int64, status string) {
+	s.mutex.Lock()
+	s.members[[2]int64{chatID, userID}] = status
+	s.mutex.Unlock()
+}
+
+// Calls returns the recorded calls to the method (all the calls if the method is empty)
+func (s *Server) Calls(method string) []Call {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	calls := make([]Call, 0)
+	for _, call := range s.calls {
+		if method == "" || call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// WaitForCalls waits until at least n calls to the method are recorded (or the timeout expires) and returns them
+func (s *Server) WaitForCalls(method string, n int, timeout time.Duration) []Call {
+	deadline := time.Now().Add(timeout)
+	for {
+		calls := s.Calls(method)
+		if len(calls) >= n || time.Now().After(deadline) {
+			return calls
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// ResetCalls forgets all the recorded calls
+func (s *Server) ResetCalls() {
+	s.mutex.Lock()
+	s.calls = nil
+	s.mutex.Unlock()
+}
+
+// Fail makes the next calls to the method fail, one for each failure.
+// The failed calls aren't recorded, as they had no effect.
+func (s *Server) Fail(method string, failures ...Failure) {
+	s.mutex.Lock()
+	s.failures[method] = append(s.failures[method], failures...)
+	s.mutex.Unlock()
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// The path is /bot<token>/<method>
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if len(parts) != 2 || !strings.HasPrefix(parts[0], "bot") {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	method := parts[1]
+
+	call := Call{Method: method, Params: url.Values{}, Files: make(map[string]File), At: time.Now()}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		call.Params = url.Values(r.MultipartForm.Value)
+		for field, headers := range r.MultipartForm.File {
+			file, err := headers[0].Open()
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			bytes, _ := io.ReadAll(file)
+			file.Close()
+			call.Files[field] = File{Name: headers[0].Filename, Bytes: bytes}
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		call.Params = r.PostForm
+	}
+
+	if failure, ok := s.nextFailure(method); ok {
+		var parameters *tgbotapi.ResponseParameters
+		if failure.RetryAfter != 0 {
+			parameters = &tgbotapi.ResponseParameters{RetryAfter: failure.RetryAfter}
+		}
+		writeResponse(w, failure.Code, tgbotapi.APIResponse{Ok: false, ErrorCode: failure.Code, Description: failure.Description, Parameters: parameters})
+		return
+	}
+
+	switch method {
+	case "getMe":
+		writeResult(w, s.Self)
+	case "getUpdates":
+		writeResult(w, s.getUpdates(call.Params))
+	case "sendMessage", "sendDocument":
+		s.record(call)
+		writeResult(w, s.newSentMessage(call))
+	case "editMessageText":
+		s.record(call)
+		writeResult(w, s.editedMessage(call))
+	case "getChatMember":
+		s.record(call)
+		writeResult(w, s.chatMember(call))
+	default:
+		s.record(call)
+		writeResult(w, true)
+	}
+}
+
+func (s *Server) record(call Call) {
+	s.mutex.Lock()
+	s.calls = append(s.calls, call)
+	s.mutex.Unlock()
+}
+
+// nextFailure pops the next scripted failure of the method
+func (s *Server) nextFailure(method string) (Failure, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	failures := s.failures[method]
+	if len(failures) == 0 {
+		return Failure{}, false
+	}
+	s.failures[method] = failures[1:]
+	return failures[0], true
+}
+
+// getUpdates returns the updates with ID >= offset, waiting (at most timeout seconds) if there are none
+func (s *Server) getUpdates(params url.Values) []tgbotapi.Update {
+	offset, _ := strconv.Atoi(params.Get("offset"))
+	timeout, _ := strconv.Atoi(params.Get("timeout"))
+	deadline := time.After(time.Duration(timeout) * time.Second)
+
+	for {
+		s.mutex.Lock()
+		updates := make([]tgbotapi.Update, 0)
+		for _, update := range s.updates {
+			if update.UpdateID >= offset {
+				updates = append(updates, update)
+			}
+		}
+		newUpdate := s.newUpdate
+		s.mutex.Unlock()
+
+		if len(updates) != 0 || timeout == 0 {
+			return updates
+		}
+
+		select {
+		case <-newUpdate:
+		case <-deadline:
+			return updates
+		case <-s.closed:
+			return updates
+		}
+	}
+}
+
+func (s *Server) newSentMessage(call Call) tgbotapi.Message {
+	chatID, _ := strconv.ParseInt(call.Params.Get("chat_id"), 10, 64)
+
+	s.mutex.Lock()
+	message := tgbotapi.Message{
+		MessageID: s.nextMessageID,
+		From:      &s.Self,
+		Chat:      &tgbotapi.Chat{ID: chatID},
+		Date:      int(call.At.Unix()),
+		Text:      call.Params.Get("text"),
+		Caption:   call.Params.Get("caption"),
+	}
+	s.nextMessageID++
+	s.mutex.Unlock()
+
+	return message
+}
+
+func (s *Server) editedMessage(call Call) tgbotapi.Message {
+	chatID, _ := strconv.ParseInt(call.Params.Get("chat_id"), 10, 64)
+	messageID, _ := strconv.Atoi(call.Params.Get("message_id"))
+
+	return tgbotapi.Message{
+		MessageID: messageID,
+		From:      &s.Self,
+		Chat:      &tgbotapi.Chat{ID: chatID},
+		EditDate:  int(call.At.Unix()),
+		Text:      call.Params.Get("text"),
+	}
+}
+
+func (s *Server) chatMember(call Call) tgbotapi.ChatMember {
+	chatID, _ := strconv.ParseInt(call.Params.Get("chat_id"), 10, 64)
+	userID, _ := strconv.ParseInt(call.Params.Get("user_id"), 10, 64)
+
+	s.mutex.Lock()
+	status, ok := s.members[[2]int64{chatID, userID}]
+	s.mutex.Unlock()
+	if !ok {
+		status = "member"
+	}
+	return tgbotapi.ChatMember{User: &tgbotapi.User{ID: userID}, Status: status}
+}
+
+func writeResult(w http.ResponseWriter, result any) {
+	raw, err := json.Marshal(result)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeResponse(w, http.StatusOK, tgbotapi.APIResponse{Ok: true, Result: raw})
+}
+
+func writeError(w http.ResponseWriter, code int, description string) {
+	writeResponse(w, code, tgbotapi.APIResponse{Ok: false, ErrorCode: code, Description: description})
+}
+
+func writeResponse(w http.ResponseWriter, code int, response tgbotapi.APIResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
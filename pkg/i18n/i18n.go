@@ -0,0 +1,134 @@
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+type (
+	// PluralRule returns the index of the plural form to use for the quantity n
+	PluralRule func(n int) int
+
+	// Catalog contains the messages of the bot in every language, as text/template templates.
+	// Besides the standard functions, the templates can use:
+	//   - plural n "form" "forms"... : the plural form for n, chosen by the rule of the language
+	//   - inc n                       : n+1 (useful for positions in ranges)
+	//   - join list "sep"             : the strings of the list separated by sep
+	// The messages of a language can include each other with {{template "key" .}}.
+	Catalog struct {
+		fallback  string
+		languages map[string]*language
+	}
+
+	language struct {
+		plural PluralRule
+		root   *template.Template
+		keys   []string
+	}
+)
+
+// OneOther is the plural rule of languages like Italian and English: a form for ±1, another one for the rest
+func OneOther(n int) int {
+	if n == 1 || n == -1 {
+		return 0
+	}
+	return 1
+}
+
+// New creates an empty catalog. The texts missing in a language are taken from the fallback one.
+func New(fallback string) *Catalog {
+	return &Catalog{fallback: fallback, languages: make(map[string]*language)}
+}
+
+// Add parses and adds the messages of a language
+func (c *Catalog) Add(lang string, plural PluralRule, messages map[string]string) error {
+	funcs := template.FuncMap{
+		"plural": func(n int, forms ...string) string {
+			if len(forms) == 0 {
+				return ""
+			}
+			form := plural(n)
+			if form < 0 || form >= len(forms) {
+				form = len(forms) - 1
+			}
+			return forms[form]
+		},
+		"inc":  func(n int) int { return n + 1 },
+		"join": strings.Join,
+	}
+
+	// All the messages are associated to the same root, so they can include each other
+	l := &language{plural: plural, root: template.New(lang).Funcs(funcs)}
+	for key, text := range messages {
+		if _, err := l.root.New(key).Parse(text); err != nil {
+			return fmt.Errorf("message %q of language %q: %w", key, lang, err)
+		}
+		l.keys = append(l.keys, key)
+	}
+	sort.Strings(l.keys)
+
+	c.languages[lang] = l
+	return nil
+}
+
+// Has reports if the language is in the catalog
+func (c *Catalog) Has(lang string) bool {
+	_, ok := c.languages[lang]
+	return ok
+}
+
+// Languages returns the languages of the catalog, sorted
+func (c *Catalog) Languages() []string {
+	languages := make([]string, 0, len(c.languages))
+	for lang := range c.languages {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// Keys returns the keys of the messages of the language, sorted
+func (c *Catalog) Keys(lang string) []string {
+	l, ok := c.languages[lang]
+	if !ok {
+		return nil
+	}
+	return append([]string(nil), l.keys...)
+}
+
+// Text executes the template of the message with the data, in the language (or in the fallback one if it's missing).
+// If the message doesn't exist or can't be executed the key itself is returned, so the error is visible but not fatal.
+func (c *Catalog) Text(lang, key string, data any) string {
+	text, err := c.Execute(lang, key, data)
+	if err != nil {
+		return key
+	}
+	return text
+}
+
+// Execute is like Text, but it returns the errors
+func (c *Catalog) Execute(lang, key string, data any) (string, error) {
+	tmpl := c.template(lang, key)
+	if tmpl == nil {
+		return "", fmt.Errorf("message %q not found in language %q nor in %q", key, lang, c.fallback)
+	}
+
+	var text strings.Builder
+	if err := tmpl.Execute(&text, data); err != nil {
+		return "", err
+	}
+	return text.String(), nil
+}
+
+func (c *Catalog) template(lang, key string) *template.Template {
+	for _, l := range []string{lang, c.fallback} {
+		if language, ok := c.languages[l]; ok {
+			if tmpl := language.root.Lookup(key); tmpl != nil {
+				return tmpl
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package i18n
+
+import (
+	"testing"
+)
+
+func Test_Catalog_Text(t *testing.T) {
+	c := New("it")
+	if err := c.Add("it", OneOther, map[string]string{
+		"points":  "{{.Points}} {{plural .Points \"punto\" \"punti\"}} per {{.User}}",
+		"ranking": "{{range $i, $name := .}}{{inc $i}}] {{$name}}\n{{end}}",
+		"only_it": "solo in italiano",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Add("en", OneOther, map[string]string{
+		"points":  "{{.Points}} {{plural .Points \"point\" \"points\"}} for {{.User}}",
+		"ranking": "{{range $i, $name := .}}#{{inc $i}} {{$name}}\n{{end}}",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		lang, key string
+		data      any
+		expected  string
+	}{
+		{"it", "points", map[string]any{"Points": 1, "User": "alice"}, "1 punto per alice"},
+		{"it", "points", map[string]any{"Points": -1, "User": "alice"}, "-1 punto per alice"},
+		{"it", "points", map[string]any{"Points": 0, "User": "alice"}, "0 punti per alice"},
+		{"en", "points", map[string]any{"Points": 3, "User": "bob"}, "3 points for bob"},
+		{"en", "ranking", []string{"alice", "bob"}, "#1 alice\n#2 bob\n"},
+		// Missing messages and languages fall back on the fallback language
+		{"en", "only_it", nil, "solo in italiano"},
+		{"de", "points", map[string]any{"Points": 2, "User": "carl"}, "2 punti per carl"},
+		// Unknown messages are shown as their key
+		{"it", "missing", nil, "missing"},
+	}
+	for _, test := range tests {
+		if text := c.Text(test.lang, test.key, test.data); text != test.expected {
+			t.Errorf("Text(%q, %q) should be %q, got %q", test.lang, test.key, test.expected, text)
+		}
+	}
+
+	if languages := c.Languages(); len(languages) != 2 || languages[0] != "en" || languages[1] != "it" {
+		t.Errorf("Unexpected languages: %v", languages)
+	}
+}
+
+func Test_Catalog_Add_InvalidTemplate(t *testing.T) {
+	c := New("it")
+	if err := c.Add("it", OneOther, map[string]string{"broken": "{{if .Points}}"}); err == nil {
+		t.Error("An invalid template should not be added")
+	}
+}
@@ -0,0 +1,398 @@
+package outbox
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/MoraGames/clockyuwu/pkg/clock"
+	"github.com/MoraGames/clockyuwu/pkg/types"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrDropped is returned (wrapped) for the messages that the outbox gave up sending
+var ErrDropped = errors.New("message dropped by the outbox")
+
+type (
+	// Options are the limits of the outbox. A zero rate disables the limit.
+	Options struct {
+		GlobalRate  float64 // requests per second to all the chats
+		GlobalBurst int
+		ChatRate    float64 // requests per second to a single chat
+		ChatBurst   int
+		MaxRetries  int
+		Backoff     time.Duration // delay before the first retry of a transient error, doubled at every retry
+		MaxDelay    time.Duration // a request that can't be sent within this delay is dropped (zero means no limit)
+	}
+
+	// Metrics counts what happened to the requests passed through the outbox
+	Metrics struct {
+		Sent       int
+		Delayed    int // sent after waiting for the rate limits or for a retry
+		Retried    int // retries made (a request can be retried more times)
+		Dropped    int
+		TotalDelay time.Duration
+		MaxDelay   time.Duration
+	}
+
+	// Outbox is a types.AsyncSender that sends the requests through another sender respecting the Telegram flood limits:
+	// it waits for the per-chat and global rate limits, honours the retry_after of the 429 errors and retries the transient errors with backoff.
+	// The requests to a chat are queued and sent in order by a worker of the chat, so Send and Request return at once and a busy chat doesn't slow down the others.
+	// The requests that aren't sent to a chat (e.g. getChatMember) are sent right away, as their response is needed.
+	Outbox struct {
+		sender  types.Sender
+		clock   clock.Clock
+		options Options
+		logger  *logrus.Logger
+
+		mutex   sync.Mutex
+		global  *bucket
+		chats   map[int64]*bucket
+		queues  map[int64][]*request
+		pending sync.WaitGroup
+		metrics Metrics
+	}
+
+	// request is a request queued to a chat, with the function called with its outcome (if any)
+	request struct {
+		chattable tgbotapi.Chattable
+		queuedAt  time.Time
+		call      func() (tgbotapi.Message, error)
+		done      func(tgbotapi.Message, error)
+	}
+
+	// bucket is a token bucket, that can also be paused until a time (after a 429 error)
+	bucket struct {
+		rate        float64
+		burst       float64
+		tokens      float64
+		last        time.Time
+		pausedUntil time.Time
+	}
+)
+
+// New creates an outbox that sends through the sender.
+// The clock should be the real one: the flood limits are measured by Telegram on the wall clock.
+func New(sender types.Sender, c clock.Clock, options Options, logger *logrus.Logger) *Outbox {
+	return &Outbox{
+		sender:  sender,
+		clock:   c,
+		options: options,
+		logger:  logger,
+		global:  newBucket(options.GlobalRate, options.GlobalBurst),
+		chats:   make(map[int64]*bucket),
+		queues:  make(map[int64][]*request),
+	}
+}
+
+// Send queues the request to its chat and returns at once, with an empty message (the failures are logged, use SendAsync to get the sent message).
+// The requests that aren't sent to a chat are sent right away.
+func (o *Outbox) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	if ChatID(c) == 0 {
+		var message tgbotapi.Message
+		err := o.do(c, o.clock.Now(), func() error {
+			var err error
+			message, err = o.sender.Send(c)
+			return err
+		})
+		return message, err
+	}
+	o.SendAsync(c, nil)
+	return tgbotapi.Message{}, nil
+}
+
+// Request queues the request to its chat and returns at once, with an ok response (the failures are logged).
+// The requests that aren't sent to a chat and the deletions (whose failures are counted by the cleanup) are sent right away.
+func (o *Outbox) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	if _, deletion := c.(tgbotapi.DeleteMessageConfig); deletion || ChatID(c) == 0 {
+		var response *tgbotapi.APIResponse
+		err := o.do(c, o.clock.Now(), func() error {
+			var err error
+			response, err = o.sender.Request(c)
+			return err
+		})
+		return response, err
+	}
+	o.enqueue(&request{chattable: c, call: func() (tgbotapi.Message, error) {
+		_, err := o.sender.Request(c)
+		return tgbotapi.Message{}, err
+	}})
+	return &tgbotapi.APIResponse{Ok: true}, nil
+}
+
+// SendAsync queues the request to its chat, and calls done (if not nil) with the sent message or the error once the request is sent or dropped.
+// done is called from the worker of the chat, after the previous requests to the chat.
+func (o *Outbox) SendAsync(c tgbotapi.Chattable, done func(tgbotapi.Message, error)) {
+	o.enqueue(&request{chattable: c, call: func() (tgbotapi.Message, error) { return o.sender.Send(c) }, done: done})
+}
+
+// Flush waits until all the queued requests are sent or dropped.
+// It must not be called holding a lock taken by the done functions of the queued requests.
+func (o *Outbox) Flush() {
+	o.pending.Wait()
+}
+
+// Metrics returns a copy of the current metrics
+func (o *Outbox) Metrics() Metrics {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return o.metrics
+}
+
+// enqueue adds the request to the queue of its chat, starting the worker of the chat if it isn't running
+func (o *Outbox) enqueue(r *request) {
+	chatID := ChatID(r.chattable)
+
+	r.queuedAt = o.clock.Now()
+	o.mutex.Lock()
+	o.pending.Add(1)
+	queue := append(o.queues[chatID], r)
+	o.queues[chatID] = queue
+	o.mutex.Unlock()
+
+	// A worker runs while the queue of its chat isn't empty
+	if len(queue) == 1 {
+		go o.work(chatID)
+	}
+}
+
+// work sends the queued requests of the chat in order, until its queue is empty
+func (o *Outbox) work(chatID int64) {
+	o.mutex.Lock()
+	for len(o.queues[chatID]) != 0 {
+		r := o.queues[chatID][0]
+		o.mutex.Unlock()
+
+		var message tgbotapi.Message
+		err := o.do(r.chattable, r.queuedAt, func() error {
+			var err error
+			message, err = r.call()
+			return err
+		})
+		if r.done != nil {
+			r.done(message, err)
+		}
+		o.pending.Done()
+
+		o.mutex.Lock()
+		o.queues[chatID] = o.queues[chatID][1:]
+	}
+	delete(o.queues, chatID)
+	o.mutex.Unlock()
+}
+
+// do runs the call of the request queued at the instant when the rate limits allow it, retrying it until it succeeds or the request is dropped
+func (o *Outbox) do(c tgbotapi.Chattable, queuedAt time.Time, call func() error) error {
+	chatID := ChatID(c)
+
+	for attempt := 0; ; attempt++ {
+		// Wait for the rate limits
+		wait, ok := o.reserve(chatID, queuedAt)
+		if !ok {
+			return o.drop(chatID, queuedAt, attempt, fmt.Errorf("%w: rate limit exceeded for more than %v", ErrDropped, o.options.MaxDelay))
+		}
+		if wait > 0 {
+			o.clock.Sleep(wait)
+		}
+
+		err := call()
+		if err == nil {
+			o.sent(chatID, queuedAt, attempt)
+			return nil
+		}
+
+		// Check if (and when) the request can be retried
+		retryAfter, transient := o.retryDelay(chatID, err, attempt)
+		if !transient {
+			return o.drop(chatID, queuedAt, attempt, err)
+		}
+		if attempt >= o.options.MaxRetries {
+			return o.drop(chatID, queuedAt, attempt, fmt.Errorf("%w after %v retries: %w", ErrDropped, attempt, err))
+		}
+		if o.options.MaxDelay != 0 && o.clock.Now().Add(retryAfter).Sub(queuedAt) > o.options.MaxDelay {
+			return o.drop(chatID, queuedAt, attempt, fmt.Errorf("%w: retry after %v exceeds the max delay: %w", ErrDropped, retryAfter, err))
+		}
+
+		o.mutex.Lock()
+		o.metrics.Retried++
+		o.mutex.Unlock()
+		o.logger.WithFields(logrus.Fields{
+			"err":     err,
+			"chat":    chatID,
+			"attempt": attempt + 1,
+			"retryIn": retryAfter,
+		}).Warn("Request failed, retrying")
+		o.clock.Sleep(retryAfter)
+	}
+}
+
+// reserve takes a token from the chat and the global buckets and returns how much to wait before using it.
+// If the wait would exceed the max delay nothing is taken and false is returned.
+func (o *Outbox) reserve(chatID int64, queuedAt time.Time) (time.Duration, bool) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	now := o.clock.Now()
+	buckets := []*bucket{o.global}
+	if chatID != 0 {
+		buckets = append(buckets, o.chat(chatID))
+	}
+
+	at := now
+	for _, b := range buckets {
+		if available := b.availableAt(now); available.After(at) {
+			at = available
+		}
+	}
+	if o.options.MaxDelay != 0 && at.Sub(queuedAt) > o.options.MaxDelay {
+		return 0, false
+	}
+
+	for _, b := range buckets {
+		b.take()
+	}
+	return at.Sub(now), true
+}
+
+// retryDelay returns how much to wait before retrying the failed request to the chat, and false if the error isn't transient
+func (o *Outbox) retryDelay(chatID int64, err error, attempt int) (time.Duration, bool) {
+	backoff := o.options.Backoff << attempt
+
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) {
+		// Network errors
+		return backoff, true
+	}
+
+	switch {
+	case apiErr.Code == 429:
+		// Flood limit: nothing can be sent to that chat (or at all, for the requests not sent to a chat) until retry_after
+		retryAfter := time.Duration(apiErr.RetryAfter) * time.Second
+		if retryAfter == 0 {
+			retryAfter = backoff
+		}
+		o.pause(chatID, retryAfter)
+		return retryAfter, true
+	case apiErr.Code >= 500:
+		return backoff, true
+	default:
+		return 0, false
+	}
+}
+
+// pause stops the requests to the chat for d (all the requests, if the chat is 0)
+func (o *Outbox) pause(chatID int64, d time.Duration) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	b := o.global
+	if chatID != 0 {
+		b = o.chat(chatID)
+	}
+	until := o.clock.Now().Add(d)
+	if until.After(b.pausedUntil) {
+		b.pausedUntil = until
+	}
+}
+
+// chat returns the bucket of the chat, creating it the first time (the lock must be held)
+func (o *Outbox) chat(chatID int64) *bucket {
+	b, ok := o.chats[chatID]
+	if !ok {
+		b = newBucket(o.options.ChatRate, o.options.ChatBurst)
+		o.chats[chatID] = b
+	}
+	return b
+}
+
+func (o *Outbox) sent(chatID int64, queuedAt time.Time, attempt int) {
+	delay := o.clock.Now().Sub(queuedAt)
+
+	o.mutex.Lock()
+	o.metrics.Sent++
+	if delay > 0 {
+		o.metrics.Delayed++
+		o.metrics.TotalDelay += delay
+		if delay > o.metrics.MaxDelay {
+			o.metrics.MaxDelay = delay
+		}
+	}
+	o.mutex.Unlock()
+
+	if delay > 0 {
+		o.logger.WithFields(logrus.Fields{
+			"chat":    chatID,
+			"delay":   delay,
+			"retries": attempt,
+		}).Debug("Request delayed")
+	}
+}
+
+func (o *Outbox) drop(chatID int64, queuedAt time.Time, attempt int, err error) error {
+	o.mutex.Lock()
+	o.metrics.Dropped++
+	o.mutex.Unlock()
+
+	o.logger.WithFields(logrus.Fields{
+		"err":     err,
+		"chat":    chatID,
+		"retries": attempt,
+		"waited":  o.clock.Now().Sub(queuedAt),
+	}).Error("Request dropped")
+	return err
+}
+
+func newBucket(rate float64, burst int) *bucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &bucket{rate: rate, burst: float64(burst), tokens: float64(burst)}
+}
+
+// availableAt refills the bucket and returns when the next token will be available
+func (b *bucket) availableAt(now time.Time) time.Time {
+	at := now
+	if b.rate != 0 {
+		if !b.last.IsZero() && now.After(b.last) {
+			b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		}
+		b.last = now
+		if b.tokens < 1 {
+			at = now.Add(time.Duration((1 - b.tokens) / b.rate * float64(time.Second)))
+		}
+	}
+	if b.pausedUntil.After(at) {
+		at = b.pausedUntil
+	}
+	return at
+}
+
+func (b *bucket) take() {
+	if b.rate != 0 {
+		b.tokens--
+	}
+}
+
+// ChatID returns the chat of the request (0 if the request isn't sent to a chat)
+func ChatID(c tgbotapi.Chattable) int64 {
+	switch c := c.(type) {
+	case tgbotapi.MessageConfig:
+		return c.ChatID
+	case tgbotapi.DocumentConfig:
+		return c.ChatID
+	case tgbotapi.PhotoConfig:
+		return c.ChatID
+	case tgbotapi.EditMessageTextConfig:
+		return c.ChatID
+	case tgbotapi.EditMessageReplyMarkupConfig:
+		return c.ChatID
+	case tgbotapi.DeleteMessageConfig:
+		return c.ChatID
+	case tgbotapi.PinChatMessageConfig:
+		return c.ChatID
+	}
+	return 0
+}
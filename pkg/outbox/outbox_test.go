@@ -0,0 +1,244 @@
+package outbox
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MoraGames/clockyuwu/pkg/clock"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+var testStart = time.Date(2024, time.March, 30, 12, 0, 0, 0, time.UTC)
+
+// instantClock is a clock whose Sleep advances the time instantly
+type instantClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func (c *instantClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *instantClock) Sleep(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func (c *instantClock) AfterFunc(d time.Duration, f func()) *time.Timer { return time.AfterFunc(d, f) }
+
+// scriptedSender records the time of the sent requests and fails with the scripted errors.
+// Holding its mutex holds the sends.
+type scriptedSender struct {
+	mutex  sync.Mutex
+	clock  clock.Clock
+	errors []error
+	sent   []time.Time
+}
+
+func (s *scriptedSender) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(s.errors) != 0 {
+		err := s.errors[0]
+		s.errors = s.errors[1:]
+		if err != nil {
+			return tgbotapi.Message{}, err
+		}
+	}
+	s.sent = append(s.sent, s.clock.Now())
+	return tgbotapi.Message{MessageID: len(s.sent)}, nil
+}
+
+func (s *scriptedSender) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	_, err := s.Send(c)
+	return &tgbotapi.APIResponse{Ok: err == nil}, err
+}
+
+// Sent returns a copy of the times of the sent requests
+func (s *scriptedSender) Sent() []time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]time.Time(nil), s.sent...)
+}
+
+func newTestOutbox(options Options, errs ...error) (*Outbox, *scriptedSender) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	c := &instantClock{now: testStart}
+	sender := &scriptedSender{clock: c, errors: errs}
+	return New(sender, c, options, logger), sender
+}
+
+// sendAndWait queues the message and waits until it's sent or dropped, returning the outcome
+func sendAndWait(o *Outbox, c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	var message tgbotapi.Message
+	var err error
+	o.SendAsync(c, func(m tgbotapi.Message, e error) { message, err = m, e })
+	o.Flush()
+	return message, err
+}
+
+func Test_Outbox_RateLimits(t *testing.T) {
+	o, sender := newTestOutbox(Options{GlobalRate: 30, GlobalBurst: 30, ChatRate: 1, ChatBurst: 2})
+
+	// A burst of replies in the same chat, all queued before the first one is sent, then a message to another chat
+	sender.mutex.Lock()
+	for i := 0; i < 4; i++ {
+		if _, err := o.Send(tgbotapi.NewMessage(-1, "reply")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sender.mutex.Unlock()
+	o.Flush()
+	if _, err := sendAndWait(o, tgbotapi.NewMessage(-2, "other")); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []time.Duration{0, 0, time.Second, 2 * time.Second, 2 * time.Second}
+	sent := sender.Sent()
+	if len(sent) != len(expected) {
+		t.Fatalf("Expected %v sent messages, got %v", len(expected), len(sent))
+	}
+	for i := range expected {
+		if at := sent[i].Sub(testStart); at != expected[i] {
+			t.Errorf("Message %v should be sent after %v, sent after %v", i, expected[i], at)
+		}
+	}
+	// The delays count the time spent in the queue
+	if m := o.Metrics(); m.Sent != 5 || m.Delayed != 2 || m.Dropped != 0 || m.MaxDelay != 2*time.Second {
+		t.Errorf("Unexpected metrics: %+v", m)
+	}
+}
+
+func Test_Outbox_RetryAfter(t *testing.T) {
+	flood := &tgbotapi.Error{Code: 429, Message: "Too Many Requests: retry after 5", ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 5}}
+	o, sender := newTestOutbox(Options{MaxRetries: 3, Backoff: time.Second}, flood)
+
+	message, err := sendAndWait(o, tgbotapi.NewMessage(-1, "reply"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent := sender.Sent(); message.MessageID != 1 || len(sent) != 1 || sent[0].Sub(testStart) != 5*time.Second {
+		t.Errorf("The message should be sent after the retry_after, sent at %v", sent)
+	}
+
+	if m := o.Metrics(); m.Retried != 1 || m.Delayed != 1 {
+		t.Errorf("Unexpected metrics: %+v", m)
+	}
+}
+
+func Test_Outbox_Backoff(t *testing.T) {
+	network := errors.New("connection reset by peer")
+	server := &tgbotapi.Error{Code: 502, Message: "Bad Gateway"}
+	o, sender := newTestOutbox(Options{MaxRetries: 3, Backoff: time.Second}, network, server)
+
+	if _, err := sendAndWait(o, tgbotapi.NewMessage(-1, "reply")); err != nil {
+		t.Fatal(err)
+	}
+	// 1s after the first failure, 2s after the second one
+	if sent := sender.Sent(); len(sent) != 1 || sent[0].Sub(testStart) != 3*time.Second {
+		t.Errorf("The message should be sent after 3s, sent at %v", sent)
+	}
+}
+
+func Test_Outbox_Drop(t *testing.T) {
+	network := errors.New("connection reset by peer")
+	forbidden := &tgbotapi.Error{Code: 403, Message: "Forbidden: bot was kicked from the group chat"}
+
+	// Permanent errors aren't retried
+	o, _ := newTestOutbox(Options{MaxRetries: 3, Backoff: time.Second}, forbidden)
+	if _, err := sendAndWait(o, tgbotapi.NewMessage(-1, "reply")); err == nil || errors.Is(err, ErrDropped) {
+		t.Errorf("The permanent error should be returned as is, got %v", err)
+	}
+
+	// Transient errors are retried at most MaxRetries times
+	o, sender := newTestOutbox(Options{MaxRetries: 2, Backoff: time.Second}, network, network, network, nil)
+	if _, err := sendAndWait(o, tgbotapi.NewMessage(-1, "reply")); !errors.Is(err, ErrDropped) {
+		t.Errorf("The message should be dropped, got %v", err)
+	}
+	if sent := sender.Sent(); len(sent) != 0 {
+		t.Errorf("Nothing should be sent, got %v", sent)
+	}
+
+	// Messages that would wait too much for a retry_after are dropped, without pausing the other chats
+	flood := &tgbotapi.Error{Code: 429, Message: "Too Many Requests: retry after 10", ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 10}}
+	o, sender = newTestOutbox(Options{MaxRetries: 3, MaxDelay: 5 * time.Second}, flood)
+	if _, err := sendAndWait(o, tgbotapi.NewMessage(-1, "reply")); !errors.Is(err, ErrDropped) {
+		t.Errorf("The message should be dropped, got %v", err)
+	}
+	if _, err := sendAndWait(o, tgbotapi.NewMessage(-2, "other")); err != nil {
+		t.Errorf("The message to the other chat should be sent, got %v", err)
+	}
+	if m := o.Metrics(); m.Sent != 1 || m.Dropped != 1 || len(sender.Sent()) != 1 {
+		t.Errorf("Unexpected metrics: %+v", m)
+	}
+}
+
+func Test_Outbox_Deletions(t *testing.T) {
+	notFound := &tgbotapi.Error{Code: 400, Message: "Bad Request: message to delete not found"}
+	o, sender := newTestOutbox(Options{MaxRetries: 3, Backoff: time.Second}, notFound)
+
+	// The deletions are made right away, returning their outcome
+	if _, err := o.Request(tgbotapi.NewDeleteMessage(-1, 1)); err == nil {
+		t.Error("The failed deletion should return the error")
+	}
+	if _, err := o.Request(tgbotapi.NewDeleteMessage(-1, 2)); err != nil {
+		t.Errorf("The deletion should succeed, got %v", err)
+	}
+	if sent := sender.Sent(); len(sent) != 1 {
+		t.Errorf("The deletion should be sent, got %v", sent)
+	}
+}
+
+func Test_Outbox_ChatQueues(t *testing.T) {
+	flood := &tgbotapi.Error{Code: 429, Message: "Too Many Requests: retry after 10", ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 10}}
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	v := clock.NewVirtual(testStart)
+	sender := &scriptedSender{clock: v, errors: []error{flood}}
+	o := New(sender, v, Options{MaxRetries: 3}, logger)
+
+	// Send returns at once, while the flood limit holds the chat
+	if _, err := o.Send(tgbotapi.NewMessage(-1, "reply")); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return o.Metrics().Retried == 1 })
+
+	// The other chats aren't paused
+	done := make(chan error)
+	o.SendAsync(tgbotapi.NewMessage(-2, "other"), func(_ tgbotapi.Message, err error) { done <- err })
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if sent := sender.Sent(); len(sent) != 1 || !sent[0].Equal(testStart) {
+		t.Fatalf("Only the message to the other chat should be sent at once, sent at %v", sent)
+	}
+
+	// The chat is sent once the retry_after has passed
+	waitFor(t, func() bool {
+		v.Advance(time.Second)
+		return len(sender.Sent()) == 2
+	})
+	o.Flush()
+	if sent := sender.Sent(); sent[1].Sub(testStart) < 10*time.Second {
+		t.Errorf("The message should be sent after the retry_after, sent at %v", sent)
+	}
+}
+
+// waitFor polls the condition until it holds, failing the test after a second
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); !condition(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("Condition not met in time")
+		}
+	}
+}
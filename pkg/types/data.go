@@ -4,14 +4,28 @@ import (
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
+// Sender is the part of the Bot API used to respond (implemented by *tgbotapi.BotAPI and by the other front ends)
+type Sender interface {
+	Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
+	Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error)
+}
+
+// AsyncSender is a Sender that sends in the background (implemented by the outbox).
+// SendAsync returns at once, then calls done (if not nil) with the sent message or the error.
+type AsyncSender interface {
+	Sender
+	SendAsync(c tgbotapi.Chattable, done func(tgbotapi.Message, error))
+}
+
 type Data struct {
-	Bot     *tgbotapi.BotAPI
+	Bot     Sender
 	Updates tgbotapi.UpdatesChannel
 }
 
 type WriteMessageData struct {
-	Bot            *tgbotapi.BotAPI
+	Bot            Sender
 	ChatID         int64
 	ReplyMessageID int
 	Text           string
+	Settings       Settings
 }
@@ -0,0 +1,22 @@
+package types
+
+import (
+	"time"
+)
+
+// Settings are the settings of a chat, with the default ones (from the configuration) in place of the ones not changed
+type Settings struct {
+	Language      string
+	Timezone      string
+	ResetTime     string
+	RevealEffects bool
+}
+
+// Location returns the location of the timezone of the settings (the local one if it isn't valid)
+func (s Settings) Location() *time.Location {
+	location, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return location
+}
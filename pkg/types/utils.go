@@ -2,11 +2,15 @@ package types
 
 import (
 	"github.com/MoraGames/clockyuwu/config"
+	"github.com/MoraGames/clockyuwu/pkg/clock"
+	"github.com/MoraGames/clockyuwu/pkg/i18n"
 	"github.com/sirupsen/logrus"
 )
 
 type Utils struct {
 	Config     *config.Config
 	Logger     *logrus.Logger
+	Clock      clock.Clock
+	Catalog    *i18n.Catalog
 	TimeFormat string
 }
@@ -0,0 +1,143 @@
+package webhook
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// SecretTokenHeader is the header used by Telegram to send the secret token set with setWebhook
+const SecretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+type (
+	// Options are the settings of the webhook HTTP(S) listener
+	Options struct {
+		Listen      string
+		Path        string
+		SecretToken string
+		CertFile    string
+		KeyFile     string
+		Buffer      int
+	}
+
+	// Server receives the updates sent by Telegram and forwards them on the Updates channel
+	Server struct {
+		Updates tgbotapi.UpdatesChannel
+
+		options  Options
+		server   *http.Server
+		updates  chan tgbotapi.Update
+		closing  chan struct{}
+		stopOnce sync.Once
+	}
+)
+
+func NewServer(options Options) *Server {
+	if options.Path == "" {
+		options.Path = "/"
+	}
+	if options.Buffer <= 0 {
+		options.Buffer = 100
+	}
+
+	s := &Server{
+		options: options,
+		updates: make(chan tgbotapi.Update, options.Buffer),
+		closing: make(chan struct{}),
+	}
+	s.Updates = s.updates
+
+	mux := http.NewServeMux()
+	mux.Handle(options.Path, s)
+	s.server = &http.Server{Addr: options.Listen, Handler: mux}
+
+	return s
+}
+
+// ServeHTTP validates the secret token, decodes the update and forwards it on the Updates channel
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.options.SecretToken != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get(SecretTokenHeader)), []byte(s.options.SecretToken)) != 1 {
+		http.Error(w, "invalid secret token", http.StatusUnauthorized)
+		return
+	}
+
+	var update tgbotapi.Update
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, "invalid update: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Telegram will send the update again once the bot is back online
+	select {
+	case <-s.closing:
+		http.Error(w, "shutting down", http.StatusServiceUnavailable)
+		return
+	default:
+	}
+
+	select {
+	case s.updates <- update:
+		w.WriteHeader(http.StatusOK)
+	case <-s.closing:
+		http.Error(w, "shutting down", http.StatusServiceUnavailable)
+	}
+}
+
+// ListenAndServe starts the listener (with TLS if the certificate and key files are set) and blocks until Stop is called
+func (s *Server) ListenAndServe() error {
+	var err error
+	if s.options.CertFile != "" && s.options.KeyFile != "" {
+		err = s.server.ListenAndServeTLS(s.options.CertFile, s.options.KeyFile)
+	} else {
+		err = s.server.ListenAndServe()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Stop refuses the new updates, waits for the requests in progress and closes the Updates channel
+func (s *Server) Stop(ctx context.Context) error {
+	var err error
+	s.stopOnce.Do(func() {
+		close(s.closing)
+		err = s.server.Shutdown(ctx)
+		if err == nil {
+			// No handler can be still running, so nobody will send on the closed channel
+			close(s.updates)
+		}
+	})
+	return err
+}
+
+// Register sets the webhook on Telegram, uploading the certificate if it's self-signed
+func Register(bot *tgbotapi.BotAPI, url string, options Options, uploadCertificate bool) error {
+	params := tgbotapi.Params{"url": url}
+	params.AddNonEmpty("secret_token", options.SecretToken)
+
+	var err error
+	if uploadCertificate && options.CertFile != "" {
+		_, err = bot.UploadFiles("setWebhook", params, []tgbotapi.RequestFile{
+			{Name: "certificate", Data: tgbotapi.FilePath(options.CertFile)},
+		})
+	} else {
+		_, err = bot.MakeRequest("setWebhook", params)
+	}
+	return err
+}
+
+// Unregister removes the webhook from Telegram (the pending updates are kept)
+func Unregister(bot *tgbotapi.BotAPI) error {
+	_, err := bot.Request(tgbotapi.DeleteWebhookConfig{DropPendingUpdates: false})
+	return err
+}
@@ -0,0 +1,90 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func postUpdate(t *testing.T, s *Server, secret string) int {
+	body, err := os.ReadFile("testdata/update.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(body))
+	if secret != "" {
+		req.Header.Set(SecretTokenHeader, secret)
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func Test_ServeHTTP_ValidSecret(t *testing.T) {
+	s := NewServer(Options{Path: "/webhook", SecretToken: "secret"})
+
+	if code := postUpdate(t, s, "secret"); code != http.StatusOK {
+		t.Fatalf("Status should be %v, got %v", http.StatusOK, code)
+	}
+
+	update := <-s.Updates
+	if update.UpdateID != 1 || update.Message == nil || update.Message.Text != "12:34" {
+		t.Errorf("Update not decoded correctly: %+v", update)
+	}
+}
+
+func Test_ServeHTTP_WrongSecret(t *testing.T) {
+	s := NewServer(Options{Path: "/webhook", SecretToken: "secret"})
+
+	if code := postUpdate(t, s, "wrong"); code != http.StatusUnauthorized {
+		t.Errorf("Status should be %v, got %v", http.StatusUnauthorized, code)
+	}
+	if code := postUpdate(t, s, ""); code != http.StatusUnauthorized {
+		t.Errorf("Status should be %v, got %v", http.StatusUnauthorized, code)
+	}
+	if len(s.Updates) != 0 {
+		t.Errorf("No update should be forwarded, got %v", len(s.Updates))
+	}
+}
+
+func Test_ServeHTTP_WrongRequest(t *testing.T) {
+	s := NewServer(Options{Path: "/webhook"})
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/webhook", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Status should be %v, got %v", http.StatusMethodNotAllowed, rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader([]byte("not json"))))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Status should be %v, got %v", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func Test_Stop(t *testing.T) {
+	s := NewServer(Options{Path: "/webhook", Buffer: 1})
+
+	if code := postUpdate(t, s, ""); code != http.StatusOK {
+		t.Fatalf("Status should be %v, got %v", http.StatusOK, code)
+	}
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The buffered update is still delivered, then the channel is closed
+	if _, ok := <-s.Updates; !ok {
+		t.Error("Buffered update should be delivered")
+	}
+	if _, ok := <-s.Updates; ok {
+		t.Error("Updates channel should be closed")
+	}
+	if code := postUpdate(t, s, ""); code != http.StatusServiceUnavailable {
+		t.Errorf("Status should be %v, got %v", http.StatusServiceUnavailable, code)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/MoraGames/clockyuwu/history"
+	"github.com/MoraGames/clockyuwu/pkg/types"
+	"github.com/MoraGames/clockyuwu/ranking"
+	"github.com/sirupsen/logrus"
+)
+
+// Periods of the rankings, the first one is the default.
+// The season is the one of the users stats (until they are reset), the others are computed from the timeline of the claims.
+var Periods = []string{"season", "day", "week", "month", "all"}
+
+// Rankings contains the rankings already built (it's protected by stateMutex)
+var Rankings = ranking.NewCache()
+
+// PointsIndex keeps the users ordered by their season points, by Telegram ID (it's protected by stateMutex).
+// It must be updated whenever a user is added or their points change.
+var PointsIndex = ranking.NewIndex()
+
+// Get the ranking of the players by the metric in the period, that ends today in the location
+func RankingBoard(metric ranking.Metric, period string, location *time.Location, utils types.Utils) []ranking.Row {
+	from := PeriodStart(period, utils.Clock.Now().In(location))
+	return Rankings.Get(fmt.Sprintf("%v:%v:%v", metric, period, from), func() []ranking.Row {
+		return ranking.Build(RankingEntries(period, from, utils), metric)
+	})
+}
+
+// Get the first date of the period that contains the day ("" for the periods without a start)
+func PeriodStart(period string, day time.Time) string {
+	switch period {
+	case "day":
+		return day.Format(history.DateFormat)
+	case "week":
+		// The weeks start on monday
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7).Format(history.DateFormat)
+	case "month":
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location()).Format(history.DateFormat)
+	}
+	return ""
+}
+
+// Get the results of the players in the period, starting at the date (the delays of the season are the ones of all the timeline)
+func RankingEntries(period, from string, utils types.Utils) []ranking.Entry {
+	entries := make([]ranking.Entry, 0, len(Users))
+	for userID, u := range Users {
+		if u == nil {
+			continue
+		}
+		entry := ranking.Entry{UserID: userID, UserName: u.UserName}
+		if period == "season" {
+			entry.Points, entry.Wins, entry.Partecipations = u.TotalPoints, u.TotalEventWins, u.TotalEventPartecipations
+		}
+
+		claims := make([]history.Claim, 0)
+		if Timeline != nil {
+			var err error
+			if claims, err = Timeline.Claims(userID); err != nil {
+				utils.Logger.WithFields(logrus.Fields{
+					"err":  err,
+					"user": userID,
+				}).Error("Error while reading the timeline")
+			}
+		}
+		for _, claim := range claims {
+			if claim.Date < from {
+				continue
+			}
+			entry.DelaySum += claim.Delay
+			entry.Delays++
+			if period == "season" {
+				continue
+			}
+			entry.Points += claim.Points
+			entry.Partecipations++
+			if claim.Won {
+				entry.Wins++
+			}
+		}
+
+		// The players without claims in the period aren't ranked
+		if period != "season" && entry.Partecipations == 0 {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// Update the user in the points index, after they are added or their points change
+func IndexUser(userID int64) {
+	if u, ok := Users[userID]; ok && u != nil {
+		PointsIndex.Set(userID, u.TotalPoints)
+	}
+}
+
+// Build the points index from all the users again (e.g. after they are reloaded or reset)
+func RebuildPointsIndex(utils types.Utils) {
+	PointsIndex = ranking.NewIndex()
+	for userID, u := range Users {
+		if u != nil {
+			PointsIndex.Set(userID, u.TotalPoints)
+		}
+	}
+}
+
+// Remove the rankings already built, after a change of the users stats or of the timeline
+func InvalidateRankings() {
+	Rankings.Invalidate()
+}
+
+// Get the text of the value of the metric (or of a gap, with its sign)
+func RankingValueText(metric ranking.Metric, value float64, gap bool) string {
+	text := ""
+	switch metric {
+	case ranking.WinRate:
+		text = fmt.Sprintf("%.2f", value)
+	case ranking.AverageDelay:
+		text = fmt.Sprintf("%.2fs", value)
+	default:
+		text = fmt.Sprint(int(value))
+	}
+	if !gap {
+		return text
+	}
+	if metric.Ascending() {
+		return "+" + text
+	}
+	return "-" + text
+}
@@ -0,0 +1,191 @@
+package ranking
+
+type (
+	// Index keeps the players ordered by points, updated incrementally when their points change.
+	// The lookups of the position and of the gap from the leader are O(log n) (it's a treap with the sizes of the subtrees).
+	// It's not safe for concurrent use.
+	Index struct {
+		root   *node
+		points map[int64]int
+	}
+
+	node struct {
+		userID      int64
+		points      int
+		priority    uint64
+		size        int
+		left, right *node
+	}
+)
+
+// NewIndex creates an empty index
+func NewIndex() *Index {
+	return &Index{points: make(map[int64]int)}
+}
+
+// Set the points of the player, adding them if they aren't in the index
+func (ix *Index) Set(userID int64, points int) {
+	if old, ok := ix.points[userID]; ok {
+		if old == points {
+			return
+		}
+		ix.root = remove(ix.root, userID, old)
+	}
+	ix.points[userID] = points
+
+	n := &node{userID: userID, points: points, priority: priority(userID), size: 1}
+	left, right := split(ix.root, userID, points)
+	ix.root = merge(merge(left, n), right)
+}
+
+// Remove the player from the index
+func (ix *Index) Remove(userID int64) {
+	if old, ok := ix.points[userID]; ok {
+		ix.root = remove(ix.root, userID, old)
+		delete(ix.points, userID)
+	}
+}
+
+// Len returns the number of players in the index
+func (ix *Index) Len() int {
+	return size(ix.root)
+}
+
+// Leader returns the player with the most points (the one with the lowest ID among the tied ones)
+func (ix *Index) Leader() (int64, int, bool) {
+	n := ix.root
+	if n == nil {
+		return 0, 0, false
+	}
+	for n.left != nil {
+		n = n.left
+	}
+	return n.userID, n.points, true
+}
+
+// Position returns the position of the player, shared with the players with the same points
+func (ix *Index) Position(userID int64) (int, bool) {
+	points, ok := ix.points[userID]
+	if !ok {
+		return 0, false
+	}
+
+	// The players with more points are the ones before the first with these points
+	position := 1
+	for n := ix.root; n != nil; {
+		if n.points > points {
+			position += size(n.left) + 1
+			n = n.right
+		} else {
+			n = n.left
+		}
+	}
+	return position, true
+}
+
+// Gap returns the points the player is behind the leader
+func (ix *Index) Gap(userID int64) (int, bool) {
+	points, ok := ix.points[userID]
+	if !ok {
+		return 0, false
+	}
+	_, leaderPoints, _ := ix.Leader()
+	return leaderPoints - points, true
+}
+
+// Above returns the points of the closest player ahead of the player (with more points), if any
+func (ix *Index) Above(userID int64) (int, bool) {
+	points, ok := ix.points[userID]
+	if !ok {
+		return 0, false
+	}
+
+	above, found := 0, false
+	for n := ix.root; n != nil; {
+		if n.points > points {
+			above, found = n.points, true
+			n = n.right
+		} else {
+			n = n.left
+		}
+	}
+	return above, found
+}
+
+// before reports if the player comes before the other one: more points first, then the lower IDs
+func before(userID int64, points int, otherID int64, otherPoints int) bool {
+	if points != otherPoints {
+		return points > otherPoints
+	}
+	return userID < otherID
+}
+
+// split the tree into the players before the given one and the others
+func split(n *node, userID int64, points int) (*node, *node) {
+	if n == nil {
+		return nil, nil
+	}
+	if before(n.userID, n.points, userID, points) {
+		left, right := split(n.right, userID, points)
+		n.right = left
+		n.update()
+		return n, right
+	}
+	left, right := split(n.left, userID, points)
+	n.left = right
+	n.update()
+	return left, n
+}
+
+// merge two trees, with all the players of the left one before the ones of the right one
+func merge(left, right *node) *node {
+	switch {
+	case left == nil:
+		return right
+	case right == nil:
+		return left
+	case left.priority > right.priority:
+		left.right = merge(left.right, right)
+		left.update()
+		return left
+	default:
+		right.left = merge(left, right.left)
+		right.update()
+		return right
+	}
+}
+
+func remove(n *node, userID int64, points int) *node {
+	if n == nil {
+		return nil
+	}
+	if n.userID == userID {
+		return merge(n.left, n.right)
+	}
+	if before(userID, points, n.userID, n.points) {
+		n.left = remove(n.left, userID, points)
+	} else {
+		n.right = remove(n.right, userID, points)
+	}
+	n.update()
+	return n
+}
+
+func (n *node) update() {
+	n.size = 1 + size(n.left) + size(n.right)
+}
+
+func size(n *node) int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+// priority of the player in the treap, pseudo-random but stable (splitmix64 of the ID)
+func priority(userID int64) uint64 {
+	z := uint64(userID) + 0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
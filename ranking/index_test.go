@@ -0,0 +1,96 @@
+package ranking
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func Test_Index(t *testing.T) {
+	ix := NewIndex()
+	if _, _, ok := ix.Leader(); ok {
+		t.Error("An empty index should have no leader")
+	}
+
+	ix.Set(1, 5)
+	ix.Set(2, 7)
+	ix.Set(3, 5)
+	ix.Set(4, 1)
+
+	tests := []struct {
+		userID   int64
+		position int
+		gap      int
+	}{
+		{2, 1, 0},
+		{1, 2, 2},
+		{3, 2, 2},
+		{4, 4, 6},
+	}
+	for _, test := range tests {
+		position, _ := ix.Position(test.userID)
+		gap, _ := ix.Gap(test.userID)
+		if position != test.position || gap != test.gap {
+			t.Errorf("User %v should be at %v (gap %v), got %v (gap %v)", test.userID, test.position, test.gap, position, gap)
+		}
+	}
+
+	if above, ok := ix.Above(4); !ok || above != 5 {
+		t.Errorf("The player above user 4 should have 5 points, got %v", above)
+	}
+	if _, ok := ix.Above(2); ok {
+		t.Error("No player should be above the leader")
+	}
+
+	// The points change, and the players can leave
+	ix.Set(4, 9)
+	ix.Remove(2)
+	if userID, points, _ := ix.Leader(); userID != 4 || points != 9 || ix.Len() != 3 {
+		t.Errorf("User 4 should lead with 9 points among 3 users, got %v with %v among %v", userID, points, ix.Len())
+	}
+	if _, ok := ix.Position(2); ok {
+		t.Error("A removed user should not have a position")
+	}
+}
+
+func Test_Index_Random(t *testing.T) {
+	// The index must agree with a full count, with thousands of players and updates
+	random := rand.New(rand.NewSource(1))
+	ix := NewIndex()
+	points := make(map[int64]int)
+	for i := 0; i < 20000; i++ {
+		userID := int64(random.Intn(5000))
+		if random.Intn(10) == 0 {
+			ix.Remove(userID)
+			delete(points, userID)
+			continue
+		}
+		points[userID] = random.Intn(200) - 50
+		ix.Set(userID, points[userID])
+	}
+
+	leaderPoints := -1 << 31
+	for _, p := range points {
+		leaderPoints = max(leaderPoints, p)
+	}
+	if ix.Len() != len(points) {
+		t.Fatalf("The index should have %v users, got %v", len(points), ix.Len())
+	}
+	checked := 0
+	for userID, p := range points {
+		if checked++; checked > 500 {
+			break
+		}
+		expected, above := 1, leaderPoints
+		for _, other := range points {
+			if other > p {
+				expected++
+				above = min(above, other)
+			}
+		}
+		position, _ := ix.Position(userID)
+		gap, _ := ix.Gap(userID)
+		if closest, ok := ix.Above(userID); position != expected || gap != leaderPoints-p || (ok && closest != above) || ok != (p != leaderPoints) {
+			t.Errorf("User %v should be at %v (gap %v, above %v), got %v (gap %v, above %v)", userID, expected, leaderPoints-p, above, position, gap, closest)
+		}
+	}
+}
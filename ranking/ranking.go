@@ -0,0 +1,128 @@
+package ranking
+
+import (
+	"sort"
+	"time"
+)
+
+type (
+	// Metric is a measure the players are ranked by
+	Metric string
+
+	// Entry contains the results of a player in a period
+	Entry struct {
+		UserID         int64
+		UserName       string
+		Points         int
+		Wins           int
+		Partecipations int
+		DelaySum       time.Duration
+		Delays         int
+	}
+
+	// Row is the placement of a player in a ranking, with the value of the metric and the gap from the leader
+	Row struct {
+		Position int
+		Entry    Entry
+		Value    float64
+		Gap      float64
+	}
+
+	// Cache keeps the rankings already built, until they are invalidated.
+	// It's not safe for concurrent use.
+	Cache struct {
+		boards map[string][]Row
+	}
+)
+
+const (
+	Points         Metric = "points"
+	Wins           Metric = "wins"
+	Partecipations Metric = "partecipations"
+	WinRate        Metric = "winrate"
+	AverageDelay   Metric = "avgdelay"
+)
+
+// Metrics are all the metrics, the first one is the default
+var Metrics = []Metric{Points, Wins, Partecipations, WinRate, AverageDelay}
+
+// Ascending reports if the lower values of the metric are the better ones
+func (m Metric) Ascending() bool {
+	return m == AverageDelay
+}
+
+// Value returns the value of the metric for the entry, and false if the player can't be ranked by it
+func (e Entry) Value(m Metric) (float64, bool) {
+	switch m {
+	case Points:
+		return float64(e.Points), true
+	case Wins:
+		return float64(e.Wins), true
+	case Partecipations:
+		return float64(e.Partecipations), true
+	case WinRate:
+		if e.Partecipations == 0 {
+			return 0, false
+		}
+		return float64(e.Wins) / float64(e.Partecipations), true
+	case AverageDelay:
+		if e.Delays == 0 {
+			return 0, false
+		}
+		return (e.DelaySum / time.Duration(e.Delays)).Seconds(), true
+	}
+	return 0, false
+}
+
+// Build ranks the entries by the metric. The players with the same value share the same position
+// (the next one skips the shared places, e.g. 1, 1, 3) and are listed by partecipations (the fewer first), then by name.
+func Build(entries []Entry, metric Metric) []Row {
+	rows := make([]Row, 0, len(entries))
+	for _, entry := range entries {
+		if value, ok := entry.Value(metric); ok {
+			rows = append(rows, Row{Entry: entry, Value: value})
+		}
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Value != rows[j].Value {
+			return (rows[i].Value < rows[j].Value) == metric.Ascending()
+		}
+		if rows[i].Entry.Partecipations != rows[j].Entry.Partecipations {
+			return rows[i].Entry.Partecipations < rows[j].Entry.Partecipations
+		}
+		return rows[i].Entry.UserName < rows[j].Entry.UserName
+	})
+
+	for i := range rows {
+		rows[i].Position = i + 1
+		if i > 0 && rows[i].Value == rows[i-1].Value {
+			rows[i].Position = rows[i-1].Position
+		}
+		rows[i].Gap = rows[i].Value - rows[0].Value
+		if !metric.Ascending() {
+			rows[i].Gap = -rows[i].Gap
+		}
+	}
+	return rows
+}
+
+// NewCache creates an empty cache
+func NewCache() *Cache {
+	return &Cache{boards: make(map[string][]Row)}
+}
+
+// Get returns the ranking with the key, building it the first time
+func (c *Cache) Get(key string, build func() []Row) []Row {
+	if rows, ok := c.boards[key]; ok {
+		return rows
+	}
+	rows := build()
+	c.boards[key] = rows
+	return rows
+}
+
+// Invalidate removes all the rankings, that will be built again (e.g. after the points of a player change)
+func (c *Cache) Invalidate() {
+	c.boards = make(map[string][]Row)
+}
@@ -0,0 +1,59 @@
+package ranking
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_Build(t *testing.T) {
+	entries := []Entry{
+		{UserID: 1, UserName: "alice", Points: 5, Wins: 2, Partecipations: 4, DelaySum: 8 * time.Second, Delays: 4},
+		{UserID: 2, UserName: "bob", Points: 7, Wins: 3, Partecipations: 3, DelaySum: 3 * time.Second, Delays: 3},
+		{UserID: 3, UserName: "carol", Points: 5, Wins: 1, Partecipations: 2, DelaySum: 4 * time.Second, Delays: 2},
+		{UserID: 4, UserName: "dave", Points: 1},
+	}
+
+	tests := []struct {
+		metric    Metric
+		users     []string
+		positions []int
+		gaps      []float64
+	}{
+		// The ties share the position, listed by partecipations
+		{Points, []string{"bob", "carol", "alice", "dave"}, []int{1, 2, 2, 4}, []float64{0, 2, 2, 6}},
+		{Wins, []string{"bob", "alice", "carol", "dave"}, []int{1, 2, 3, 4}, []float64{0, 1, 2, 3}},
+		// The players without partecipations or delays aren't ranked by the ratios
+		{WinRate, []string{"bob", "carol", "alice"}, []int{1, 2, 2}, []float64{0, 0.5, 0.5}},
+		{AverageDelay, []string{"bob", "carol", "alice"}, []int{1, 2, 2}, []float64{0, 1, 1}},
+	}
+	for _, test := range tests {
+		rows := Build(entries, test.metric)
+		if len(rows) != len(test.users) {
+			t.Errorf("%v: expected %v rows, got %+v", test.metric, len(test.users), rows)
+			continue
+		}
+		for i, row := range rows {
+			if row.Entry.UserName != test.users[i] || row.Position != test.positions[i] || row.Gap != test.gaps[i] {
+				t.Errorf("%v: row %v should be %v at %v (gap %v), got %+v", test.metric, i, test.users[i], test.positions[i], test.gaps[i], row)
+			}
+		}
+	}
+}
+
+func Test_Cache(t *testing.T) {
+	c := NewCache()
+	builds := 0
+	build := func() []Row {
+		builds++
+		return Build([]Entry{{UserName: "alice", Points: builds}}, Points)
+	}
+
+	c.Get("points", build)
+	if rows := c.Get("points", build); builds != 1 || rows[0].Value != 1 {
+		t.Errorf("The cached ranking should be used, got %v builds", builds)
+	}
+	c.Invalidate()
+	if rows := c.Get("points", build); builds != 2 || rows[0].Value != 2 {
+		t.Errorf("The ranking should be built again after the invalidation, got %v builds", builds)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MoraGames/clockyuwu/config"
+	"github.com/MoraGames/clockyuwu/history"
+	"github.com/MoraGames/clockyuwu/ranking"
+	"github.com/MoraGames/clockyuwu/structs"
+)
+
+func Test_PeriodStart(t *testing.T) {
+	// It's a sunday
+	day := time.Date(2024, 3, 31, 23, 0, 0, 0, time.UTC)
+	for period, expected := range map[string]string{"day": "2024-03-31", "week": "2024-03-25", "month": "2024-03-01", "season": "", "all": ""} {
+		if from := PeriodStart(period, day); from != expected {
+			t.Errorf("The %v should start at %q, got %q", period, expected, from)
+		}
+	}
+}
+
+func Test_RankingBoard(t *testing.T) {
+	utils := testUtils(&config.Config{Settings: config.Settings{Timezone: "UTC"}}, time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC))
+	resetState(utils)
+	var err error
+	if Timeline, err = history.NewTimeline(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { Timeline = nil }()
+
+	// Alice won an event today and one last month, Bob partecipated today
+	Users[testAlice.ID] = &structs.User{TelegramID: testAlice.ID, UserName: testAlice.UserName, TotalPoints: 3, TotalEventWins: 1, TotalEventPartecipations: 1}
+	Users[testBob.ID] = &structs.User{TelegramID: testBob.ID, UserName: testBob.UserName, TotalEventPartecipations: 1}
+	Timeline.Add(testAlice.ID, history.Claim{ChatID: -1, Date: "2024-02-10", EventKey: "12:34", Won: true, Points: 5, Delay: time.Second})
+	Timeline.Add(testAlice.ID, history.Claim{ChatID: -1, Date: "2024-03-31", EventKey: "11:11", Won: true, Points: 3, Delay: 1250 * time.Millisecond})
+	Timeline.Add(testBob.ID, history.Claim{ChatID: -1, Date: "2024-03-31", EventKey: "11:11", Delay: 2 * time.Second})
+
+	tests := []struct {
+		metric   ranking.Metric
+		period   string
+		expected string
+	}{
+		{ranking.Wins, "day", "1 alice 1 0, 2 bob 0 1"},
+		// The players with the same value share the position
+		{ranking.Partecipations, "season", "1 alice 1 0, 1 bob 1 0"},
+		{ranking.AverageDelay, "week", "1 alice 1.25s 0.00s, 2 bob 2.00s 0.75s"},
+		{ranking.Points, "month", "1 alice 3 0, 2 bob 0 3"},
+		{ranking.Points, "all", "1 alice 8 0, 2 bob 0 8"},
+		{ranking.AverageDelay, "season", "1 alice 1.12s 0.00s, 2 bob 2.00s 0.88s"},
+	}
+	for _, test := range tests {
+		rows := make([]string, 0)
+		for _, row := range RankingBoard(test.metric, test.period, time.UTC, utils) {
+			rows = append(rows, fmt.Sprintf("%v %v %v %v", row.Position, row.Entry.UserName, RankingValueText(test.metric, row.Value, false), RankingValueText(test.metric, row.Gap, false)))
+		}
+		if board := strings.Join(rows, ", "); board != test.expected {
+			t.Errorf("The ranking by %v in the %v should be %q, got %q", test.metric, test.period, test.expected, board)
+		}
+	}
+}
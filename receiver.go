@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"github.com/MoraGames/clockyuwu/pkg/types"
+	"github.com/MoraGames/clockyuwu/pkg/webhook"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// Start receiving the updates with the configured mode ("polling" by default, or "webhook").
+// It returns the updates channel and the function (safe to call more than once) used to stop receiving them.
+func StartUpdates(bot *tgbotapi.BotAPI, utils types.Utils) (tgbotapi.UpdatesChannel, func()) {
+	var once sync.Once
+
+	if utils.Config.Bot.Mode != "webhook" {
+		u := tgbotapi.NewUpdate(0)
+		u.Timeout = utils.Config.Bot.PollingTimeout
+
+		updates := bot.GetUpdatesChan(u)
+		utils.Logger.WithFields(logrus.Fields{
+			"debugMode": bot.Debug,
+			"timeout":   u.Timeout,
+		}).Debug("Update channel retreived (long polling)")
+
+		return updates, func() { once.Do(bot.StopReceivingUpdates) }
+	}
+
+	options := webhook.Options{
+		Listen:      utils.Config.Webhook.Listen,
+		Path:        utils.Config.Webhook.Path,
+		SecretToken: utils.Config.Webhook.SecretToken,
+		CertFile:    utils.Config.Webhook.CertFile,
+		KeyFile:     utils.Config.Webhook.KeyFile,
+		Buffer:      bot.Buffer,
+	}
+	if options.SecretToken == "" {
+		utils.Logger.WithFields(logrus.Fields{
+			"env": "WEBHOOK_SECRET_TOKEN",
+		}).Warn("Webhook secret token not set (requests will not be validated)")
+	}
+
+	server := webhook.NewServer(options)
+	stop := func() {
+		once.Do(func() {
+			ctx, cancel := context.WithTimeout(context.Background(), utils.Config.Shutdown.Timeout)
+			defer cancel()
+			if err := server.Stop(ctx); err != nil {
+				utils.Logger.WithFields(logrus.Fields{
+					"err": err,
+				}).Error("Error while stopping the webhook server")
+			}
+
+			if utils.Config.Webhook.URL != "" {
+				if err := webhook.Unregister(bot); err != nil {
+					utils.Logger.WithFields(logrus.Fields{
+						"err": err,
+					}).Error("Error while unregistering the webhook")
+				} else {
+					utils.Logger.Info("Webhook unregistered")
+				}
+			}
+		})
+	}
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil {
+			utils.Logger.WithFields(logrus.Fields{
+				"err":    err,
+				"listen": options.Listen,
+			}).Error("Webhook server failed")
+			stop()
+		}
+	}()
+
+	// Without an URL the listener is only reachable locally (e.g. posting sample updates with curl)
+	if utils.Config.Webhook.URL != "" {
+		if err := webhook.Register(bot, utils.Config.Webhook.URL, options, utils.Config.Webhook.UploadCertificate); err != nil {
+			utils.Logger.WithFields(logrus.Fields{
+				"err": err,
+				"url": utils.Config.Webhook.URL,
+			}).Panic("Error while registering the webhook")
+		}
+		utils.Logger.WithFields(logrus.Fields{
+			"url": utils.Config.Webhook.URL,
+		}).Info("Webhook registered")
+	}
+
+	utils.Logger.WithFields(logrus.Fields{
+		"debugMode": bot.Debug,
+		"listen":    options.Listen,
+		"path":      options.Path,
+	}).Debug("Update channel retreived (webhook)")
+
+	return server.Updates, stop
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/MoraGames/clockyuwu/events"
+	"github.com/MoraGames/clockyuwu/pkg/cleanup"
+	"github.com/MoraGames/clockyuwu/pkg/types"
+	"github.com/MoraGames/clockyuwu/structs"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	// EventResult is the single message with the result of an event in a chat, edited as the claims arrive
+	EventResult struct {
+		ChatID       int64
+		MessageID    int
+		EventName    string
+		Winner       string
+		Points       int
+		Effects      []*structs.Effect
+		Partecipants []ResultPartecipant
+		Final        bool
+		// stale reports if an edit was skipped because the message wasn't sent yet
+		stale bool
+	}
+
+	// ResultPartecipant is a user listed in the result message, with the delay of their claim
+	ResultPartecipant struct {
+		UserName string
+		Delay    time.Duration
+	}
+
+	resultKey struct {
+		ChatID    int64
+		EventName string
+	}
+)
+
+// Results contains the result messages of the events whose minute is not closed yet (they are protected by stateMutex)
+var Results = make(map[resultKey]*EventResult)
+
+// Send the result message of the event just activated by the claim (it's never cleaned up), and finalise it when the minute of the event closes
+func StartEventResult(claim Claim, event *events.Event, effects []*structs.Effect, delay time.Duration, utils types.Utils, data types.Data) {
+	result := &EventResult{
+		ChatID:       claim.ChatID,
+		EventName:    event.Name,
+		Winner:       claim.UserName,
+		Points:       event.Activation.EarnedPoints,
+		Effects:      effects,
+		Partecipants: []ResultPartecipant{{UserName: claim.UserName, Delay: delay}},
+	}
+
+	key := resultKey{ChatID: claim.ChatID, EventName: event.Name}
+	Results[key] = result
+
+	// The message can be sent in the background: the edits made before it's sent are applied once it is
+	msg := tgbotapi.NewMessage(claim.ChatID, result.Text(utils))
+	msg.ReplyToMessageID = claim.MessageID
+	SendThen(cleanup.Persistent(data.Bot), msg, func(message tgbotapi.Message, err error) {
+		if err != nil {
+			utils.Logger.WithFields(logrus.Fields{
+				"err": err,
+				"msg": message,
+			}).Error("Error while sending message")
+			// The partecipants get their own replies
+			if Results[key] == result {
+				delete(Results, key)
+			}
+			return
+		}
+		result.MessageID = message.MessageID
+		if result.stale {
+			result.Edit(utils, data)
+		}
+	})
+
+	// Finalise the result when the minute (or the second) of the event closes
+	start := event.Activation.ArrivedAt.Truncate(event.Precision())
+	utils.Clock.AfterFunc(start.Add(event.Precision()).Sub(utils.Clock.Now()), func() {
+		stateMutex.Lock()
+		defer stateMutex.Unlock()
+
+		delete(Results, key)
+		result.Final = true
+		result.Edit(utils, data)
+
+		utils.Logger.WithFields(logrus.Fields{
+			"evnt":  result.EventName,
+			"chat":  result.ChatID,
+			"parts": len(result.Partecipants),
+		}).Debug("Event result finalised")
+	})
+}
+
+// Add a partecipant to the result of the event in the chat (if it's still open), returning false if there is no result to update
+func AddResultPartecipant(chatID int64, eventName, userName string, delay time.Duration, utils types.Utils, data types.Data) bool {
+	result, ok := Results[resultKey{ChatID: chatID, EventName: eventName}]
+	if !ok {
+		return false
+	}
+
+	result.Partecipants = append(result.Partecipants, ResultPartecipant{UserName: userName, Delay: delay})
+	result.Edit(utils, data)
+	return true
+}
+
+// Send the message, then call done with the sent message (it must be called holding stateMutex).
+// With a sender that sends in the background (the outbox) done is called once the message is sent, holding stateMutex.
+func SendThen(sender types.Sender, c tgbotapi.Chattable, done func(tgbotapi.Message, error)) {
+	if async, ok := sender.(types.AsyncSender); ok {
+		async.SendAsync(c, func(message tgbotapi.Message, err error) {
+			stateMutex.Lock()
+			defer stateMutex.Unlock()
+			done(message, err)
+		})
+		return
+	}
+	done(sender.Send(c))
+}
+
+// Edit the result message with the current result (later, if the message isn't sent yet)
+func (r *EventResult) Edit(utils types.Utils, data types.Data) {
+	if r.MessageID == 0 {
+		r.stale = true
+		return
+	}
+	r.stale = false
+	message, err := data.Bot.Send(tgbotapi.NewEditMessageText(r.ChatID, r.MessageID, r.Text(utils)))
+	if err != nil {
+		utils.Logger.WithFields(logrus.Fields{
+			"err": err,
+			"msg": message,
+		}).Error("Error while editing message")
+	}
+}
+
+// Text of the result message, in the language of the chat
+func (r *EventResult) Text(utils types.Utils) string {
+	// The effects are shown only if they are revealed in the chat
+	effectNames := ""
+	if GetSettings(r.ChatID, utils).RevealEffects {
+		for i, effect := range r.Effects {
+			if i != 0 {
+				effectNames += ", "
+			}
+			effectNames += fmt.Sprintf("%q", effect.Name)
+		}
+	}
+
+	return Translate(r.ChatID, 0, "result", map[string]any{
+		"EventName":    r.EventName,
+		"Winner":       r.Winner,
+		"Points":       r.Points,
+		"Effects":      effectNames,
+		"Partecipants": r.Partecipants,
+		"Final":        r.Final,
+	}, utils)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MoraGames/clockyuwu/config"
+	"github.com/MoraGames/clockyuwu/events"
+	"github.com/MoraGames/clockyuwu/pkg/clock"
+	"github.com/MoraGames/clockyuwu/pkg/types"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func Test_EventResult_Aggregation(t *testing.T) {
+	at := time.Date(2024, 3, 31, 12, 34, 5, 0, time.UTC)
+	utils := testUtils(&config.Config{Settings: config.Settings{Timezone: "UTC"}}, at)
+	resetState(utils)
+	sender := &recordingSender{}
+	data := types.Data{Bot: sender}
+
+	ed := testEvents(-1, 3, "12:34")
+	event := ed.Map["12:34"]
+	event.Activation = &events.EventActivation{ArrivedAt: at, EarnedPoints: 3}
+
+	// The winner gets the result message
+	StartEventResult(testClaim(-1, testAlice, "12:34", at, 0), event, nil, 5250*time.Millisecond, utils, data)
+	result := Results[resultKey{ChatID: -1, EventName: "12:34"}]
+	if result == nil || result.MessageID != 1 || result.Winner != testAlice.UserName || result.Points != 3 {
+		t.Fatalf("Unexpected result: %+v", result)
+	}
+
+	// The other partecipants are added to the same message
+	if !AddResultPartecipant(-1, "12:34", testBob.UserName, 6500*time.Millisecond, utils, data) {
+		t.Fatal("The partecipant should be added to the open result")
+	}
+	expected := []ResultPartecipant{{testAlice.UserName, 5250 * time.Millisecond}, {testBob.UserName, 6500 * time.Millisecond}}
+	if len(result.Partecipants) != 2 || result.Partecipants[0] != expected[0] || result.Partecipants[1] != expected[1] {
+		t.Errorf("The partecipants should be %v, got %v", expected, result.Partecipants)
+	}
+
+	// The result is finalised and closed when the minute of the event closes
+	utils.Clock.(*clock.Virtual).Set(at.Add(time.Minute))
+	if !result.Final {
+		t.Error("The result should be final after its minute")
+	}
+	if AddResultPartecipant(-1, "12:34", testCarol.UserName, time.Minute, utils, data) {
+		t.Error("No partecipant should be added to a closed result")
+	}
+	if len(sender.sent) != 3 {
+		t.Fatalf("The result should be sent and edited twice, got %v requests", len(sender.sent))
+	}
+	for _, c := range sender.sent[1:] {
+		if edit, ok := c.(tgbotapi.EditMessageTextConfig); !ok || edit.ChatID != -1 || edit.MessageID != result.MessageID {
+			t.Errorf("The result message should be edited, got %+v", c)
+		}
+	}
+	if texts := sender.texts(); texts[2] != result.Text(utils) {
+		t.Errorf("The last edit should have the final result, got %q", texts[2])
+	}
+}
+
+func Test_EventResult_EditBeforeSent(t *testing.T) {
+	utils := testUtils(&config.Config{Settings: config.Settings{Timezone: "UTC"}}, time.Now())
+	resetState(utils)
+	sender := &recordingSender{}
+
+	// The edits made before the message is sent are applied once it is
+	result := &EventResult{ChatID: -1, EventName: "12:34", Winner: testAlice.UserName}
+	result.Edit(utils, types.Data{Bot: sender})
+	if len(sender.sent) != 0 || !result.stale {
+		t.Errorf("The edit should wait for the message, got %v requests", len(sender.sent))
+	}
+}
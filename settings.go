@@ -0,0 +1,270 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MoraGames/clockyuwu/pkg/types"
+	"github.com/MoraGames/clockyuwu/structs"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// SettingOption is a setting that the moderators of a chat can change
+type SettingOption struct {
+	Key string
+	// Choices are the values offered by the /settings menu (any valid value can be set with the command)
+	Choices func(utils types.Utils) []string
+	// Value of the option in the settings, as text
+	Value func(settings types.Settings) string
+	// Set the value in the chat settings, returning an error if it isn't valid
+	Set func(chatSettings *structs.ChatSettings, value string, utils types.Utils) error
+}
+
+// SettingOptions are the options of /settings, in the order of the menu
+var SettingOptions = []SettingOption{
+	{
+		Key:     "language",
+		Choices: func(utils types.Utils) []string { return utils.Catalog.Languages() },
+		Value:   func(settings types.Settings) string { return settings.Language },
+		Set: func(chatSettings *structs.ChatSettings, value string, utils types.Utils) error {
+			if !utils.Catalog.Has(value) {
+				return fmt.Errorf("language %q not available", value)
+			}
+			chatSettings.Language = &value
+			return nil
+		},
+	},
+	{
+		Key: "timezone",
+		Choices: func(utils types.Utils) []string {
+			return []string{"UTC", "Europe/London", "Europe/Rome", "Europe/Moscow", "America/New_York", "America/Los_Angeles", "Asia/Tokyo", "Australia/Sydney"}
+		},
+		Value: func(settings types.Settings) string { return settings.Timezone },
+		Set: func(chatSettings *structs.ChatSettings, value string, utils types.Utils) error {
+			if _, err := time.LoadLocation(value); err != nil {
+				return err
+			}
+			chatSettings.Timezone = &value
+			return nil
+		},
+	},
+	{
+		Key:     "reset_time",
+		Choices: func(utils types.Utils) []string { return []string{"00:00", "03:00", "06:00", "12:00", "23:58"} },
+		Value:   func(settings types.Settings) string { return settings.ResetTime },
+		Set: func(chatSettings *structs.ChatSettings, value string, utils types.Utils) error {
+			if _, err := time.Parse("15:04", value); err != nil {
+				return err
+			}
+			chatSettings.ResetTime = &value
+			return nil
+		},
+	},
+	{
+		Key:     "reveal_effects",
+		Choices: func(utils types.Utils) []string { return []string{"true", "false"} },
+		Value:   func(settings types.Settings) string { return strconv.FormatBool(settings.RevealEffects) },
+		Set: func(chatSettings *structs.ChatSettings, value string, utils types.Utils) error {
+			reveal, err := strconv.ParseBool(value)
+			if err != nil {
+				return err
+			}
+			chatSettings.RevealEffects = &reveal
+			return nil
+		},
+	},
+}
+
+// Get the default settings of the chats, from the configuration
+func DefaultSettings(utils types.Utils) types.Settings {
+	return types.Settings{
+		Language:      utils.Config.Settings.Language,
+		Timezone:      utils.Config.Settings.Timezone,
+		ResetTime:     utils.Config.Settings.ResetTime,
+		RevealEffects: utils.Config.Settings.RevealEffects,
+	}
+}
+
+// Get the settings of the chat: the ones changed in the chat, the default ones for the others.
+// Every component of the game must read the settings of a chat from here.
+func GetSettings(chatID int64, utils types.Utils) types.Settings {
+	settings := DefaultSettings(utils)
+	if chat, ok := Chats[chatID]; ok {
+		settings = chat.Settings.Apply(settings)
+	}
+	return settings
+}
+
+// Get the option of the settings with the key
+func GetSettingOption(key string) (SettingOption, bool) {
+	for _, option := range SettingOptions {
+		if option.Key == key {
+			return option, true
+		}
+	}
+	return SettingOption{}, false
+}
+
+// Change an option of the settings of the chat (or of the user, through their private chat) and save it
+func SetSetting(chatID int64, title, key, value string, utils types.Utils) error {
+	option, ok := GetSettingOption(key)
+	if !ok {
+		return fmt.Errorf("setting %q not found", key)
+	}
+
+	chat, ok := Chats[chatID]
+	if !ok {
+		chat = structs.NewChat(chatID, title)
+	}
+	if err := option.Set(&chat.Settings, value, utils); err != nil {
+		return err
+	}
+	Chats[chatID] = chat
+	SaveChats(utils)
+
+	// A new reset time starts the lifecycle of the chat again, so the phases already past for it don't run now
+	if key == "reset_time" || key == "timezone" {
+		RestartLifecycle(chatID, utils)
+	}
+
+	utils.Logger.WithFields(logrus.Fields{
+		"chat":  chatID,
+		"key":   key,
+		"value": value,
+	}).Info("Chat setting updated")
+	return nil
+}
+
+// Check if the user can change the settings of the chat: the bot-admin, the administrators of the group, anyone in a private chat
+func isChatModerator(chat *tgbotapi.Chat, user *tgbotapi.User, utils types.Utils, data types.Data) bool {
+	if chat.IsPrivate() || isAdmin(user, utils) {
+		return true
+	}
+
+	response, err := data.Bot.Request(tgbotapi.GetChatMemberConfig{ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatID: chat.ID, UserID: user.ID}})
+	if err != nil {
+		utils.Logger.WithFields(logrus.Fields{
+			"err":  err,
+			"chat": chat.ID,
+			"user": user.ID,
+		}).Error("Error while getting chat member")
+		return false
+	}
+	var member tgbotapi.ChatMember
+	if err := json.Unmarshal(response.Result, &member); err != nil {
+		utils.Logger.WithFields(logrus.Fields{
+			"err":  err,
+			"chat": chat.ID,
+			"user": user.ID,
+		}).Error("Error while unmarshalling chat member")
+		return false
+	}
+	return member.IsCreator() || member.IsAdministrator()
+}
+
+// Text of the value of the option, in the language used with the user in the chat
+func settingValueText(option SettingOption, value string, chatID, userID int64, utils types.Utils) string {
+	if option.Key == "reveal_effects" {
+		return Translate(chatID, userID, "settings.value."+value, nil, utils)
+	}
+	return value
+}
+
+// Compose the /settings menu: the main one (empty key), or the one with the choices of the option
+func SettingsMenu(chatID, userID int64, key string, utils types.Utils) (string, tgbotapi.InlineKeyboardMarkup) {
+	settings := GetSettings(chatID, utils)
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0)
+
+	option, ok := GetSettingOption(key)
+	if !ok {
+		// Main menu: a button for every option, with its current value
+		for _, option := range SettingOptions {
+			label := Translate(chatID, userID, "settings.button", map[string]any{
+				"Option": Translate(chatID, userID, "settings.option."+option.Key, nil, utils),
+				"Value":  settingValueText(option, option.Value(settings), chatID, userID, utils),
+			}, utils)
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(label, "settings:"+option.Key)))
+		}
+		text := Translate(chatID, userID, "settings.menu", map[string]any{
+			"Language":      settings.Language,
+			"Timezone":      settings.Timezone,
+			"ResetTime":     settings.ResetTime,
+			"RevealEffects": settings.RevealEffects,
+		}, utils)
+		return text, tgbotapi.NewInlineKeyboardMarkup(rows...)
+	}
+
+	// Menu of the option: a button for every choice, and one to go back
+	for _, choice := range option.Choices(utils) {
+		label := settingValueText(option, choice, chatID, userID, utils)
+		if choice == option.Value(settings) {
+			label = "• " + label
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(label, "settings:"+option.Key+":"+choice)))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(Translate(chatID, userID, "settings.back", nil, utils), "settings")))
+	text := Translate(chatID, userID, "settings.choose", map[string]any{
+		"Option": Translate(chatID, userID, "settings.option."+option.Key, nil, utils),
+		"Value":  settingValueText(option, option.Value(settings), chatID, userID, utils),
+	}, utils)
+	return text, tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// Manage a press on the /settings menu: the callback data is "settings", "settings:<option>" or "settings:<option>:<value>"
+func ManageSettingsCallback(query *tgbotapi.CallbackQuery, utils types.Utils, data types.Data) {
+	chat, user := query.Message.Chat, query.From
+	answer := ""
+
+	parts := strings.SplitN(query.Data, ":", 3)
+	key := ""
+	if len(parts) > 1 {
+		key = parts[1]
+	}
+
+	if len(parts) == 3 {
+		// Change the setting, if the user is a moderator of the chat
+		if !isChatModerator(chat, user, utils, data) {
+			AnswerCallback(query, Translate(chat.ID, user.ID, "unauthorized", nil, utils), utils, data)
+			utils.Logger.WithFields(logrus.Fields{
+				"usr":  user.UserName,
+				"chat": chat.ID,
+			}).Debug("Unauthorized user")
+			return
+		}
+		option, _ := GetSettingOption(key)
+		if err := SetSetting(chat.ID, chat.Title, key, parts[2], utils); err != nil {
+			AnswerCallback(query, Translate(chat.ID, user.ID, "settings.not_valid", map[string]any{"Option": key, "Value": parts[2]}, utils), utils, data)
+			return
+		}
+		answer = Translate(chat.ID, user.ID, "settings.updated", map[string]any{
+			"Option": Translate(chat.ID, user.ID, "settings.option."+key, nil, utils),
+			"Value":  settingValueText(option, option.Value(GetSettings(chat.ID, utils)), chat.ID, user.ID, utils),
+		}, utils)
+		// Go back to the main menu
+		key = ""
+	}
+
+	text, keyboard := SettingsMenu(chat.ID, user.ID, key, utils)
+	message, err := data.Bot.Send(tgbotapi.NewEditMessageTextAndMarkup(chat.ID, query.Message.MessageID, text, keyboard))
+	if err != nil {
+		utils.Logger.WithFields(logrus.Fields{
+			"err": err,
+			"msg": message,
+		}).Error("Error while editing message")
+	}
+	AnswerCallback(query, answer, utils, data)
+}
+
+// Answer the callback query, showing the text (if any) to the user
+func AnswerCallback(query *tgbotapi.CallbackQuery, text string, utils types.Utils, data types.Data) {
+	if _, err := data.Bot.Request(tgbotapi.NewCallback(query.ID, text)); err != nil {
+		utils.Logger.WithFields(logrus.Fields{
+			"err":   err,
+			"query": query.ID,
+		}).Error("Error while answering callback query")
+	}
+}
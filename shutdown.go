@@ -0,0 +1,128 @@
+package main
+
+import (
+	"slices"
+	"time"
+
+	"github.com/MoraGames/clockyuwu/events"
+	"github.com/MoraGames/clockyuwu/pkg/outbox"
+	"github.com/MoraGames/clockyuwu/pkg/types"
+	"github.com/go-co-op/gocron"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// ShutdownSummary collects the informations logged when the bot goes offline
+type ShutdownSummary struct {
+	Reason         string
+	StartedAt      time.Time
+	UpdatesManaged int
+}
+
+// Get the IDs of the chats that play (the ones with events) and of the default chat, in order
+func PlayingChats(defChatID int64) []int64 {
+	stateMutex.Lock()
+	defer stateMutex.Unlock()
+
+	chatIDs := make([]int64, 0, len(events.Events)+1)
+	for chatID := range events.Events {
+		chatIDs = append(chatIDs, chatID)
+	}
+	if _, ok := events.Events[defChatID]; !ok && defChatID != 0 {
+		chatIDs = append(chatIDs, defChatID)
+	}
+	slices.Sort(chatIDs)
+	return chatIDs
+}
+
+// Shutdown waits for the running scheduled jobs, flushes all the state on files and optionally notifies the chats.
+// The updates must already be stopped (run() returned) when it's called.
+func Shutdown(bot types.Sender, scheduler *gocron.Scheduler, chatIDs []int64, summary ShutdownSummary, utils types.Utils) {
+	utils.Logger.WithFields(logrus.Fields{
+		"reason":  summary.Reason,
+		"timeout": utils.Config.Shutdown.Timeout,
+	}).Info("Shutting down")
+
+	drained := make(chan struct{})
+	go func() {
+		// Wait for any running job (e.g. the events reset) to finish
+		scheduler.Stop()
+
+		// Flush the state on files
+		stateMutex.Lock()
+		SaveUsers(utils)
+		if events.Events != nil {
+			events.SaveOnFile(utils)
+		}
+		stateMutex.Unlock()
+
+		close(drained)
+	}()
+
+	flushed := true
+	select {
+	case <-drained:
+	case <-time.After(utils.Config.Shutdown.Timeout):
+		flushed = false
+		utils.Logger.WithFields(logrus.Fields{
+			"timeout": utils.Config.Shutdown.Timeout,
+		}).Error("Shutdown timeout exceeded, state may not be flushed")
+	}
+
+	// Notify the chats that the bot is going offline
+	notified := 0
+	if utils.Config.Shutdown.NotifyChats {
+		text := utils.Config.Shutdown.NotifyMessage
+		for _, chatID := range chatIDs {
+			if chatID == 0 {
+				continue
+			}
+			// Use the localized notice if no message is configured
+			if utils.Config.Shutdown.NotifyMessage == "" {
+				text = Translate(chatID, 0, "shutdown.notice", nil, utils)
+			}
+			message, err := bot.Send(tgbotapi.NewMessage(chatID, text))
+			if err != nil {
+				utils.Logger.WithFields(logrus.Fields{
+					"err":  err,
+					"msg":  message,
+					"chat": chatID,
+				}).Error("Error while sending message")
+				continue
+			}
+			notified++
+		}
+	}
+
+	// Wait for the messages still in the outbox (the notices included)
+	if ob, ok := bot.(*outbox.Outbox); ok {
+		sent := make(chan struct{})
+		go func() {
+			ob.Flush()
+			close(sent)
+		}()
+		select {
+		case <-sent:
+		case <-time.After(utils.Config.Shutdown.Timeout):
+			utils.Logger.WithFields(logrus.Fields{
+				"timeout": utils.Config.Shutdown.Timeout,
+			}).Error("Shutdown timeout exceeded, queued messages may not be sent")
+		}
+	}
+
+	fields := logrus.Fields{
+		"reason":   summary.Reason,
+		"uptime":   utils.Clock.Now().Sub(summary.StartedAt).Round(time.Second).String(),
+		"updates":  summary.UpdatesManaged,
+		"users":    len(Users),
+		"flushed":  flushed,
+		"notified": notified,
+	}
+	if ob, ok := bot.(*outbox.Outbox); ok {
+		metrics := ob.Metrics()
+		fields["sent"] = metrics.Sent
+		fields["delayed"] = metrics.Delayed
+		fields["dropped"] = metrics.Dropped
+	}
+	utils.Logger.WithFields(fields).Info("Shutdown completed")
+}
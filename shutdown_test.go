@@ -0,0 +1,21 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/MoraGames/clockyuwu/events"
+)
+
+func Test_PlayingChats(t *testing.T) {
+	events.Events = map[int64]*events.EventsData{-3: {}, -1: {}}
+	defer func() { events.Events = make(map[int64]*events.EventsData) }()
+
+	// Every chat with events is notified, with the default one even if it has never played
+	if chatIDs := PlayingChats(-2); !slices.Equal(chatIDs, []int64{-3, -2, -1}) {
+		t.Errorf("Unexpected chats: %v", chatIDs)
+	}
+	if chatIDs := PlayingChats(-1); !slices.Equal(chatIDs, []int64{-3, -1}) {
+		t.Errorf("The default chat should be listed once, got %v", chatIDs)
+	}
+}
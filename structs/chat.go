@@ -1,9 +1,44 @@
 package structs
 
-/*
-type Chat struct {
-	Type          string
-	Title         string
-	Championships []*Championship
+import (
+	"github.com/MoraGames/clockyuwu/pkg/types"
+)
+
+type (
+	// Chat contains the preferences of a chat (a group, or the private chat of a user, whose preferences apply to the user everywhere)
+	Chat struct {
+		TelegramID int64
+		Title      string
+		Settings   ChatSettings
+	}
+
+	// ChatSettings are the settings changed in a chat (the nil ones use the default value)
+	ChatSettings struct {
+		Language      *string `json:",omitempty"`
+		Timezone      *string `json:",omitempty"`
+		ResetTime     *string `json:",omitempty"`
+		RevealEffects *bool   `json:",omitempty"`
+	}
+)
+
+func NewChat(telegramID int64, title string) *Chat {
+	return &Chat{TelegramID: telegramID, Title: title}
+}
+
+// Apply returns the default settings with the ones changed in the chat
+func (cs ChatSettings) Apply(defaults types.Settings) types.Settings {
+	settings := defaults
+	if cs.Language != nil {
+		settings.Language = *cs.Language
+	}
+	if cs.Timezone != nil {
+		settings.Timezone = *cs.Timezone
+	}
+	if cs.ResetTime != nil {
+		settings.ResetTime = *cs.ResetTime
+	}
+	if cs.RevealEffects != nil {
+		settings.RevealEffects = *cs.RevealEffects
+	}
+	return settings
 }
-*/
@@ -0,0 +1,25 @@
+package structs
+
+import "testing"
+
+func Test_ApplyEffect(t *testing.T) {
+	tests := []struct {
+		effect   Effect
+		points   int
+		expected int
+	}{
+		{Effect{Key: "*", Value: -3}, 2, -6},
+		{Effect{Key: "+", Value: 2}, 2, 4},
+		{Effect{Key: "-", Value: 2}, 2, 0},
+		// The cap limits the points added or removed
+		{Effect{Key: "*", Value: 3, Cap: 2}, 2, 4},
+		{Effect{Key: "*", Value: -3, Cap: 5}, 2, -3},
+		{Effect{Key: "+", Value: 2, Cap: 5}, 2, 4},
+		{Effect{Key: "?", Value: 2}, 2, 2},
+	}
+	for _, test := range tests {
+		if points := test.effect.Apply(test.points); points != test.expected {
+			t.Errorf("%+v applied to %v should give %v, got %v", test.effect, test.points, test.expected, points)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package structs
 
 import (
 	"fmt"
+	"time"
 )
 
 type User struct {
@@ -13,10 +14,12 @@ type User struct {
 	TotalChampionshipPartecipations int
 	TotalChampionshipWins           int
 	Effects                         []*Effect
+	// Achievements unlocked by the user, with the instant of the unlock
+	Achievements map[string]time.Time `json:",omitempty"`
 }
 
 func NewUser(telegramID int64, username string) *User {
-	return &User{telegramID, username, 0, 0, 0, 0, 0, make([]*Effect, 0)}
+	return &User{telegramID, username, 0, 0, 0, 0, 0, make([]*Effect, 0), make(map[string]time.Time)}
 }
 
 func (u *User) AddEffect(effectToAdd *Effect) {
@@ -44,3 +47,15 @@ func (u *User) StringifyEffects() string {
 	}
 	return "[" + stringifiedEffects + "]"
 }
+
+// Unlock the achievement, returning false if the user had already unlocked it
+func (u *User) Unlock(achievement string, at time.Time) bool {
+	if _, ok := u.Achievements[achievement]; ok {
+		return false
+	}
+	if u.Achievements == nil {
+		u.Achievements = make(map[string]time.Time)
+	}
+	u.Achievements[achievement] = at
+	return true
+}
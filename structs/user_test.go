@@ -1,6 +1,9 @@
 package structs
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func hasEffect(user *User, effectName string) bool {
 	for _, effect := range user.Effects {
@@ -68,3 +71,19 @@ func Test_RemoveUserEffect_SameEffectMultipleTimes(t *testing.T) {
 	ensureHasEffects(t, &user, &testEffect1, &testEffect3)
 	ensureNotHasEffects(t, &user, &testEffect2)
 }
+
+func Test_UnlockAchievement(t *testing.T) {
+	// The users loaded from old files have no achievements map
+	user := User{}
+	at := time.Date(2024, 3, 31, 12, 12, 0, 0, time.UTC)
+
+	if !user.Unlock("streak_7", at) {
+		t.Error("The achievement should be unlocked the first time")
+	}
+	if user.Unlock("streak_7", at.Add(time.Hour)) {
+		t.Error("The achievement should not be unlocked again")
+	}
+	if !user.Achievements["streak_7"].Equal(at) {
+		t.Errorf("The achievement should keep the first unlock, got %v", user.Achievements["streak_7"])
+	}
+}
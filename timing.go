@@ -0,0 +1,147 @@
+package main
+
+import (
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/MoraGames/clockyuwu/config"
+	"github.com/MoraGames/clockyuwu/events"
+	"github.com/MoraGames/clockyuwu/history"
+	"github.com/MoraGames/clockyuwu/pkg/types"
+	"github.com/MoraGames/clockyuwu/structs"
+	"github.com/sirupsen/logrus"
+)
+
+// TimingCheck is a claim of an event checked against the conditions of the timing effects.
+// Delta is the time from the activation of the event (zero for the winning claim).
+type TimingCheck struct {
+	Claim    Claim
+	Location *time.Location
+	Event    *events.Event
+	Delta    time.Duration
+}
+
+// TimingCondition is a condition the timing effects of the configuration can use.
+// The late ones are checked on the claims that arrive after the winning one, and give the effect to the winner then.
+type TimingCondition struct {
+	Late  bool
+	Check func(check TimingCheck, effect config.TimingEffect) bool
+}
+
+// TimingConditions are the conditions the timing effects of the configuration can use, by name
+var TimingConditions = map[string]TimingCondition{
+	"second": {false, func(check TimingCheck, effect config.TimingEffect) bool {
+		// The events at exact seconds are claimed in their second, so only the ones of a minute are checked
+		if check.Event.Seconds {
+			return false
+		}
+		from, errFrom := strconv.Atoi(effect.From)
+		to, errTo := strconv.Atoi(effect.To)
+		second := check.Claim.SentAt.Second()
+		return errFrom == nil && errTo == nil && second >= from && second <= to
+	}},
+	"time": {false, func(check TimingCheck, effect config.TimingEffect) bool {
+		// The events at exact seconds are in the range of their minute.
+		// The range can cross midnight (e.g. from "23:00" to "01:59")
+		minute := check.Event.Time.Format("15:04")
+		if effect.From <= effect.To {
+			return minute >= effect.From && minute <= effect.To
+		}
+		return minute >= effect.From || minute <= effect.To
+	}},
+	"photo_finish": {true, func(check TimingCheck, effect config.TimingEffect) bool {
+		return check.Delta <= effect.Within
+	}},
+}
+
+// Get the timing effects of the configuration (the late ones or the others) whose conditions are satisfied by the claim.
+// The effects already given to the event are skipped.
+func TimingEffects(check TimingCheck, late bool, utils types.Utils) []*structs.Effect {
+	effects := make([]*structs.Effect, 0)
+	for _, timing := range utils.Config.TimingEffects {
+		condition, ok := TimingConditions[timing.Condition]
+		if !ok {
+			utils.Logger.WithFields(logrus.Fields{
+				"effect":    timing.Name,
+				"condition": timing.Condition,
+			}).Warn("Timing effect condition not found")
+			continue
+		}
+		if condition.Late != late || HasEffect(check.Event.Effects, timing.Name) || !condition.Check(check, timing) {
+			continue
+		}
+		effects = append(effects, TimingEffect(timing))
+	}
+	return effects
+}
+
+// Get the effect given by the timing effect
+func TimingEffect(timing config.TimingEffect) *structs.Effect {
+	return &structs.Effect{Name: timing.Name, Scope: "Event", Key: timing.Key, Value: timing.Value, Cap: timing.Cap}
+}
+
+// Check if one of the effects has the name
+func HasEffect(effects []*structs.Effect, name string) bool {
+	for _, effect := range effects {
+		if effect.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Give the late timing effects to the winner of the event, updating the points already earned (the jackpot won with the event is left out of the effects)
+func ApplyLateEffects(check TimingCheck, effects []*structs.Effect, utils types.Utils) {
+	if len(effects) == 0 {
+		return
+	}
+	activation := check.Event.Activation
+	earned := activation.EarnedPoints
+	points := earned - activation.Jackpot
+	for _, effect := range effects {
+		check.Event.AddEffect(effect)
+		points = effect.Apply(points)
+	}
+	activation.EarnedPoints = points + activation.Jackpot
+	diff := activation.EarnedPoints - earned
+
+	// The result message of the event, if it's still open, shows the late effects too (it's edited when the claim is added)
+	if result, ok := Results[resultKey{ChatID: check.Claim.ChatID, EventName: check.Event.Name}]; ok {
+		result.Points = activation.EarnedPoints
+		result.Effects = append(slices.Clone(result.Effects), effects...)
+	}
+
+	// The winner could have been removed in the meantime
+	if activation.ActivatedBy == nil {
+		return
+	}
+	winnerID := activation.ActivatedBy.TelegramID
+	if winner, ok := Users[winnerID]; ok {
+		winner.TotalPoints += diff
+		IndexUser(winnerID)
+	}
+
+	// The win in the timeline gets the effects too
+	InvalidateRankings()
+	if Timeline == nil {
+		return
+	}
+	date := activation.ArrivedAt.In(check.Location).Format(history.DateFormat)
+	err := Timeline.Amend(
+		winnerID,
+		func(c history.Claim) bool {
+			return c.Won && c.ChatID == check.Claim.ChatID && c.Date == date && c.EventKey == check.Event.Name
+		},
+		func(c *history.Claim) {
+			c.Points += diff
+			c.Effects = append(c.Effects, EffectNames(effects)...)
+		},
+	)
+	if err != nil {
+		utils.Logger.WithFields(logrus.Fields{
+			"err":  err,
+			"user": winnerID,
+		}).Error("Error while amending the claim")
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MoraGames/clockyuwu/config"
+	"github.com/MoraGames/clockyuwu/events"
+	"github.com/MoraGames/clockyuwu/history"
+	"github.com/MoraGames/clockyuwu/structs"
+)
+
+func Test_TimingConditions(t *testing.T) {
+	lastChance := config.TimingEffect{Name: "Last Chance", Condition: "second", From: "59", To: "59"}
+	nightOwl := config.TimingEffect{Name: "Night Owl", Condition: "time", From: "02:00", To: "04:59"}
+	lateNight := config.TimingEffect{Name: "Late Night", Condition: "time", From: "23:00", To: "01:59"}
+
+	event := func(hour, minute, second int, seconds bool) *events.Event {
+		eventTime := time.Date(0, time.January, 1, hour, minute, second, 0, time.UTC)
+		name := eventTime.Format("15:04")
+		if seconds {
+			name = eventTime.Format("15:04:05")
+		}
+		return &events.Event{Time: eventTime, Name: name, Seconds: seconds}
+	}
+	sentAt := func(second int) Claim {
+		return Claim{SentAt: time.Date(2024, 3, 31, 4, 59, second, 0, time.UTC)}
+	}
+
+	tests := []struct {
+		effect config.TimingEffect
+		check  TimingCheck
+		ok     bool
+	}{
+		{lastChance, TimingCheck{Claim: sentAt(59), Event: event(4, 59, 0, false)}, true},
+		{lastChance, TimingCheck{Claim: sentAt(58), Event: event(4, 59, 0, false)}, false},
+		// The events at exact seconds are always claimed in their second
+		{lastChance, TimingCheck{Claim: sentAt(59), Event: event(4, 59, 59, true)}, false},
+		{nightOwl, TimingCheck{Event: event(2, 0, 0, false)}, true},
+		{nightOwl, TimingCheck{Event: event(4, 59, 0, false)}, true},
+		{nightOwl, TimingCheck{Event: event(4, 59, 30, true)}, true},
+		{nightOwl, TimingCheck{Event: event(5, 0, 0, false)}, false},
+		{nightOwl, TimingCheck{Event: event(1, 59, 59, true)}, false},
+		{lateNight, TimingCheck{Event: event(23, 30, 0, false)}, true},
+		{lateNight, TimingCheck{Event: event(1, 59, 30, true)}, true},
+		{lateNight, TimingCheck{Event: event(12, 0, 0, false)}, false},
+	}
+	for _, test := range tests {
+		if ok := TimingConditions[test.effect.Condition].Check(test.check, test.effect); ok != test.ok {
+			t.Errorf("%v should be %v for the event %v claimed at %v, got %v", test.effect.Name, test.ok, test.check.Event.Name, test.check.Claim.SentAt.Format("15:04:05"), ok)
+		}
+	}
+}
+
+func Test_TimingEffects(t *testing.T) {
+	conf := &config.Config{TimingEffects: config.TimingEffects{
+		{Name: "Last Chance", Condition: "second", From: "59", To: "59", Key: "+", Value: 2},
+		{Name: "First Second", Condition: "second", From: "00", To: "00", Key: "+", Value: 1},
+		{Name: "Photo Finish", Condition: "photo_finish", Within: 100 * time.Millisecond, Key: "+", Value: 1},
+	}}
+	utils := testUtils(conf, time.Now())
+	at := time.Date(2024, 3, 31, 12, 34, 59, 0, time.UTC)
+	event := &events.Event{Time: time.Date(0, time.January, 1, 12, 34, 0, 0, time.UTC), Name: "12:34"}
+	names := func(effects []*structs.Effect) string { return strings.Join(EffectNames(effects), ",") }
+
+	// The winning claim gets the effects of its timing, the late ones come from the next claims
+	if effects := TimingEffects(TimingCheck{Claim: Claim{SentAt: at}, Event: event}, false, utils); names(effects) != "Last Chance" {
+		t.Errorf("The claim at the last second should get only the Last Chance, got %v", names(effects))
+	}
+	for delta, expected := range map[time.Duration]string{50 * time.Millisecond: "Photo Finish", 700 * time.Millisecond: ""} {
+		if effects := TimingEffects(TimingCheck{Claim: Claim{SentAt: at}, Event: event, Delta: delta}, true, utils); names(effects) != expected {
+			t.Errorf("The claim arriving after %v should give %q, got %q", delta, expected, names(effects))
+		}
+	}
+
+	// The effects already given to the event are given once
+	event.AddEffect(TimingEffect(conf.TimingEffects[2]))
+	if effects := TimingEffects(TimingCheck{Claim: Claim{SentAt: at}, Event: event, Delta: 0}, true, utils); len(effects) != 0 {
+		t.Errorf("The Photo Finish should be given once, got %v", names(effects))
+	}
+}
+
+func Test_ApplyLateEffects_Jackpot(t *testing.T) {
+	at := time.Date(2024, 3, 31, 12, 34, 1, 0, time.UTC)
+	utils := testUtils(&config.Config{Settings: config.Settings{Timezone: "UTC"}}, at)
+	resetState(utils)
+	var err error
+	if Timeline, err = history.NewTimeline(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { Timeline = nil }()
+
+	// Alice won the event worth 3 points, with a jackpot of 7
+	alice := &structs.User{TelegramID: testAlice.ID, UserName: testAlice.UserName, TotalPoints: 10}
+	Users[testAlice.ID] = alice
+	event := testEvents(-1, 3, "12:34").Map["12:34"]
+	event.Activation = &events.EventActivation{ActivatedBy: alice, ActivatedAt: at, ArrivedAt: at, EarnedPoints: 10, Jackpot: 7}
+	Timeline.Add(testAlice.ID, history.Claim{ChatID: -1, Date: "2024-03-31", EventKey: "12:34", Won: true, Points: 10})
+	result := &EventResult{ChatID: -1, EventName: "12:34", Points: 10}
+	Results[resultKey{ChatID: -1, EventName: "12:34"}] = result
+
+	// The late effect doesn't multiply the jackpot
+	photoFinish := TimingEffect(config.TimingEffect{Name: "Photo Finish", Key: "*", Value: 2})
+	ApplyLateEffects(TimingCheck{Claim: testClaim(-1, testBob, "12:34", at, 0), Location: time.UTC, Event: event}, []*structs.Effect{photoFinish}, utils)
+	if event.Activation.EarnedPoints != 13 || alice.TotalPoints != 13 || !HasEffect(event.Effects, "Photo Finish") {
+		t.Errorf("Alice should earn 13 points with the Photo Finish, got %v (%v total)", event.Activation.EarnedPoints, alice.TotalPoints)
+	}
+	if result.Points != 13 || !HasEffect(result.Effects, "Photo Finish") {
+		t.Errorf("The open result should show the late effect, got %+v", result)
+	}
+	if claims, _ := Timeline.Claims(testAlice.ID); len(claims) != 1 || claims[0].Points != 13 || strings.Join(claims[0].Effects, ",") != "Photo Finish" {
+		t.Errorf("The win in the timeline should get the late effect, got %+v", claims)
+	}
+}
@@ -1,13 +1,18 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
-	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/MoraGames/clockyuwu/events"
+	"github.com/MoraGames/clockyuwu/history"
+	"github.com/MoraGames/clockyuwu/pkg/cleanup"
+	"github.com/MoraGames/clockyuwu/pkg/clock"
 	"github.com/MoraGames/clockyuwu/pkg/types"
 	"github.com/MoraGames/clockyuwu/structs"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -19,246 +24,485 @@ var (
 	Users = make(map[int64]*structs.User)
 )
 
-// Run the core of the bot
-func run(utils types.Utils, data types.Data) {
-	// Loop over the updates
-	for update := range data.Updates {
-		// Save the time of the update reading (more precise than the time of the message)
-		curTime := time.Now()
-
-		// Get the update informations
-		updID := update.UpdateID
-		updAt := curTime.Format(utils.TimeFormat)
-		fields := logrus.Fields{
-			"updID": updID,
-			"updAt": updAt,
-		}
+// Chats is the data structure that contains the preferences of the chats (and of the users, through their private chats)
+var (
+	Chats = make(map[int64]*structs.Chat)
+)
 
-		// Get the update.Chat informations (if available)
-		updChat := update.FromChat()
-		if updChat != nil {
-			fields["updChatType"] = updChat.Type
-			fields["updChatID"] = updChat.ID
-			if updChat.Type == "private" {
-				fields["updChatName"] = updChat.UserName
-			} else {
-				fields["updChatTitl"] = updChat.Title
+// Cleanup deletes the transient messages after a while (nil if disabled)
+var Cleanup *cleanup.Service
+
+// stateMutex serializes the accesses to Users, Chats and events.Events between the updates loop and the scheduled jobs
+var stateMutex sync.Mutex
+
+// Run the core of the bot, until the context is canceled or the updates channel is closed.
+// The updates already received when the context is canceled are managed before returning: they are confirmed to Telegram, so they would be lost.
+// It returns the number of updates managed.
+func run(ctx context.Context, utils types.Utils, data types.Data) int {
+	// Stamp the updates as soon as they are received, so the time spent managing the previous ones
+	// (e.g. waiting for the flood limits to respond) doesn't delay the claims
+	received := stampUpdates(ctx, data.Updates, utils.Clock)
+
+	managed := 0
+	for update := range received {
+		// Manage the update without interferences from the scheduled jobs
+		stateMutex.Lock()
+		manageUpdate(update.Update, update.ReceivedAt, utils, data)
+		stateMutex.Unlock()
+		managed++
+	}
+	return managed
+}
+
+// receivedUpdate is an update with the time of its reading (more precise than the time of the message)
+type receivedUpdate struct {
+	tgbotapi.Update
+	ReceivedAt time.Time
+}
+
+// Forward the updates with the time they are received, until the context is canceled or the updates channel is closed.
+// When the context is canceled the updates already buffered in the channel are forwarded too, then the forwarded ones are closed.
+func stampUpdates(ctx context.Context, updates tgbotapi.UpdatesChannel, c clock.Clock) <-chan receivedUpdate {
+	received := make(chan receivedUpdate, 100)
+	go func() {
+		defer close(received)
+		for {
+			select {
+			case <-ctx.Done():
+				for {
+					select {
+					case update, ok := <-updates:
+						if !ok {
+							return
+						}
+						received <- receivedUpdate{Update: update, ReceivedAt: c.Now()}
+					default:
+						return
+					}
+				}
+			case update, ok := <-updates:
+				if !ok {
+					return
+				}
+				// The receiver reads until the channel is closed, so the update is never dropped
+				received <- receivedUpdate{Update: update, ReceivedAt: c.Now()}
 			}
 		}
+	}()
+	return received
+}
 
-		// Get the update.User informations (if available)
-		updUser := update.SentFrom()
-		if updUser != nil {
-			fields["updUserID"] = updUser.ID
-			if updUser.UserName != "" {
-				fields["updUserName"] = updUser.UserName
-			}
+// Manage a single update received from Telegram
+func manageUpdate(update tgbotapi.Update, curTime time.Time, utils types.Utils, data types.Data) {
+	// Get the update informations
+	updID := update.UpdateID
+	updAt := curTime.Format(utils.TimeFormat)
+	fields := logrus.Fields{
+		"updID": updID,
+		"updAt": updAt,
+	}
+
+	// Get the update.Chat informations (if available)
+	updChat := update.FromChat()
+	if updChat != nil {
+		fields["updChatType"] = updChat.Type
+		fields["updChatID"] = updChat.ID
+		if updChat.Type == "private" {
+			fields["updChatName"] = updChat.UserName
+		} else {
+			fields["updChatTitl"] = updChat.Title
+		}
+	}
+
+	// Get the update.User informations (if available)
+	updUser := update.SentFrom()
+	if updUser != nil {
+		fields["updUserID"] = updUser.ID
+		if updUser.UserName != "" {
+			fields["updUserName"] = updUser.UserName
 		}
+	}
+
+	//Log Update
+	utils.Logger.WithFields(fields).Debug("Update received")
 
-		//Log Update
-		utils.Logger.WithFields(fields).Debug("Update received")
+	// Check the type of the update
+	if update.CallbackQuery != nil {
+		utils.Logger.WithFields(logrus.Fields{
+			"usrFrom": update.CallbackQuery.From.UserName,
+			"cbkData": update.CallbackQuery.Data,
+		}).Info("CallbackQuery received")
 
-		// Check the type of the update
-		if update.CallbackQuery != nil {
-			utils.Logger.WithFields(logrus.Fields{}).Info("CallbackQuery received")
-			// TODO: Manage CallbackQuery
+		// The menus are sent with the replies to the commands, so they are transient too
+		transient := types.Data{Bot: Cleanup.Transient(data.Bot), Updates: data.Updates}
+
+		// Check which menu the callback query comes from (the ones of unknown or inaccessible messages are only answered)
+		switch query := update.CallbackQuery; {
+		case query.Message != nil && (query.Data == "settings" || strings.HasPrefix(query.Data, "settings:")):
+			ManageSettingsCallback(query, utils, transient)
+		default:
+			AnswerCallback(query, "", utils, transient)
 		}
-		if update.Message != nil {
-			// Log Message
-			utils.Logger.WithFields(logrus.Fields{
-				"usrFrom": update.Message.From.UserName,
-				"msgText": update.Message.Text,
-				"msgTime": update.Message.Time().Format(utils.TimeFormat),
-				"curTime": curTime.Format(utils.TimeFormat),
-			}).Info("Message received")
-
-			// TODO: Rework better this timing system
-			eventKey := update.Message.Time().Format("15:04")
-
-			// Check if the message is a command (and ignore other actions)
-			if update.Message.IsCommand() {
-				manageCommands(update, utils, data, curTime, eventKey)
-				continue
-			}
+	}
+	if update.Message != nil {
+		// Log Message
+		utils.Logger.WithFields(logrus.Fields{
+			"usrFrom": update.Message.From.UserName,
+			"msgText": update.Message.Text,
+			"msgTime": update.Message.Time().Format(utils.TimeFormat),
+			"curTime": curTime.Format(utils.TimeFormat),
+		}).Info("Message received")
+
+		// TODO: Rework better this timing system
+		eventKey := update.Message.Time().Format("15:04")
+
+		// The replies to the commands and the claims are transient (deleted after a while, if the cleanup is enabled)
+		transient := types.Data{Bot: Cleanup.Transient(data.Bot), Updates: data.Updates}
+
+		// Check if the message is a command (and ignore other actions)
+		if update.Message.IsCommand() {
+			manageCommands(update, utils, transient, curTime, eventKey)
+			return
+		}
+
+		// Check if the message claims an event
+		ManageClaim(
+			Claim{
+				ChatID:     update.Message.Chat.ID,
+				MessageID:  update.Message.MessageID,
+				UserID:     update.Message.From.ID,
+				UserName:   update.Message.From.UserName,
+				Text:       update.Message.Text,
+				SentAt:     update.Message.Time(),
+				ReceivedAt: curTime,
+			},
+			utils,
+			transient,
+		)
+	}
+}
+
+// Get the events data of the chat, creating the events of its day if the chat has never played
+func ChatEvents(chatID int64, utils types.Utils) *events.EventsData {
+	ed, ok := events.Events[chatID]
+	if !ok {
+		ed = events.NewEventsData(true, GetSettings(chatID, utils).Location(), utils.Clock.Now(), CustomEvents[chatID], utils)
+		events.Events[chatID] = ed
+		utils.Logger.WithFields(logrus.Fields{
+			"chat": chatID,
+		}).Info("Chat events created")
+	}
+	return ed
+}
 
-			// Check if the message is a valid event and if it is enabled
-			if event, ok := events.Events.Map[eventKey]; ok && string(eventKey) == update.Message.Text && event.Enabled {
-				// Log Event message
+// Claim is a message that could claim an event, independent from the front end (Telegram, console, ...) that received it
+type Claim struct {
+	ChatID     int64
+	MessageID  int
+	UserID     int64
+	UserName   string
+	Text       string
+	SentAt     time.Time
+	ReceivedAt time.Time
+}
+
+// Manage a claim: activate the event or register the partecipation, then respond to the user
+func ManageClaim(claim Claim, utils types.Utils, data types.Data) {
+	// The claims are normalised to the form of the events, the other messages are ignored
+	text, ok := NormaliseClaim(claim.Text, utils.Config.Claims)
+	if !ok {
+		return
+	}
+	claim.Text = text
+
+	// The claims are matched against the wall clock of the chat timezone
+	location := GetSettings(claim.ChatID, utils).Location()
+	layout := "15:04"
+	// In the hard mode the claims of the events at exact seconds are matched with the second too
+	if utils.Config.Game.HardMode && len(claim.Text) == len("15:04:05") {
+		layout = "15:04:05"
+	}
+	eventKey := claim.SentAt.In(location).Format(layout)
+
+	// The claims of the events just ended or about to start are near misses
+	if eventKey != claim.Text {
+		RejectNearMiss(claim, location, layout, utils, data)
+		return
+	}
+
+	// Check if the message is a valid event and if it is enabled
+	if event, ok := ChatEvents(claim.ChatID, utils).Map[eventKey]; ok && string(eventKey) == claim.Text && event.Enabled {
+		// Only the first occurrence of a minute repeated by a DST change counts
+		if !events.IsFirstOccurrence(claim.SentAt, location) {
+			msg := tgbotapi.NewMessage(claim.ChatID, Translate(claim.ChatID, claim.UserID, "claim.repeated_minute", map[string]any{"EventName": event.Name}, utils))
+			msg.ReplyToMessageID = claim.MessageID
+			if message, err := data.Bot.Send(msg); err != nil {
 				utils.Logger.WithFields(logrus.Fields{
-					"evnt": update.Message.Text,
-					"user": update.Message.From.UserName,
-				}).Debug("Event validated")
-
-				// Check if the user has already partecipated
-				if event.Activation == nil {
-					// Add the user to the data structure if they have never participated before
-					if _, ok := Users[update.Message.From.ID]; !ok {
-						Users[update.Message.From.ID] = structs.NewUser(update.Message.From.ID, update.Message.From.UserName)
-					}
+					"err": err,
+					"msg": message,
+				}).Error("Error while sending message")
+			}
+			utils.Logger.WithFields(logrus.Fields{
+				"evnt": claim.Text,
+				"user": claim.UserName,
+			}).Debug("Claim in a repeated minute ignored")
+			return
+		}
 
-					// Check (and eventually update) the user effects
-					UpdateUserEffects(update.Message.From.ID)
+		// The claims of the events at exact seconds count only if they are received in the window of the second
+		if late := claim.ReceivedAt.Sub(claim.SentAt.Truncate(time.Second)); event.Seconds && late > utils.Config.Game.HardModeWindow {
+			if utils.Config.Claims.NearMisses {
+				SendNearMiss(claim, "claim.too_late", map[string]any{"EventName": event.Name, "Late": (late - utils.Config.Game.HardModeWindow).Seconds()}, utils, data)
+				return
+			}
+			utils.Logger.WithFields(logrus.Fields{
+				"evnt": claim.Text,
+				"user": claim.UserName,
+				"rcvd": claim.ReceivedAt.Format(utils.TimeFormat),
+			}).Debug("Claim received out of the window ignored")
+			return
+		}
 
-					// Activate the event and calculate the delay from o' clock
-					event.Activate(Users[update.Message.From.ID], curTime, update.Message.Time(), event.Points)
-					delay := curTime.Sub(time.Date(event.Activation.ArrivedAt.Year(), event.Activation.ArrivedAt.Month(), event.Activation.ArrivedAt.Day(), event.Activation.ArrivedAt.Hour(), event.Activation.ArrivedAt.Minute(), 0, 0, event.Activation.ArrivedAt.Location()))
+		// Log Event message
+		utils.Logger.WithFields(logrus.Fields{
+			"evnt": claim.Text,
+			"user": claim.UserName,
+		}).Debug("Event validated")
 
-					if event.Activation.ArrivedAt.Second() == 59 {
-						event.AddEffect(structs.LastChanceBonus)
-					}
+		// Delete the claim after a while, if enabled
+		Cleanup.TrackClaim(claim.ChatID, claim.MessageID)
 
-					// Apply all effects
-					effectText := ""
-					curEffects := append(event.Effects, Users[update.Message.From.ID].Effects...)
-					if len(curEffects) != 0 {
-						effectText += " grazie agli effetti:\n"
-						for i := 0; i < len(curEffects); i++ {
-							if i != len(curEffects)-1 {
-								effectText += fmt.Sprintf("%q, ", curEffects[i].Name)
-							} else {
-								effectText += fmt.Sprintf("%q", curEffects[i].Name)
-							}
-
-							switch curEffects[i].Key {
-							case "*":
-								event.Activation.EarnedPoints *= curEffects[i].Value
-							case "+":
-								event.Activation.EarnedPoints += curEffects[i].Value
-							case "-":
-								event.Activation.EarnedPoints -= curEffects[i].Value
-							}
-						}
-					}
+		// Check if the user has already partecipated
+		if event.Activation == nil {
+			// Add the user to the data structure if they have never participated before
+			if _, ok := Users[claim.UserID]; !ok {
+				Users[claim.UserID] = structs.NewUser(claim.UserID, claim.UserName)
+				IndexUser(claim.UserID)
+			}
 
-					// Respond to the user with event activated informations
-					var msg tgbotapi.MessageConfig
-					switch {
-					case event.Activation.EarnedPoints < -1:
-						msg = tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("Accidenti %v! %v punti per te%v.\nHai impiegato +%vs", update.Message.From.UserName, event.Activation.EarnedPoints, effectText, delay.Seconds()))
-					case event.Activation.EarnedPoints == -1:
-						msg = tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("Accidenti %v! %v punto per te%v.\nHai impiegato +%vs", update.Message.From.UserName, event.Activation.EarnedPoints, effectText, delay.Seconds()))
-					case event.Activation.EarnedPoints == 0:
-						msg = tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("Peccato %v! %v punti per te%v.\nHai impiegato +%vs", update.Message.From.UserName, event.Activation.EarnedPoints, effectText, delay.Seconds()))
-					case event.Activation.EarnedPoints == 1:
-						msg = tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("Complimenti %v! %v punto per te%v.\nHai impiegato +%vs", update.Message.From.UserName, event.Activation.EarnedPoints, effectText, delay.Seconds()))
-					case event.Activation.EarnedPoints > 1:
-						msg = tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("Complimenti %v! %v punti per te%v.\nHai impiegato +%vs", update.Message.From.UserName, event.Activation.EarnedPoints, effectText, delay.Seconds()))
-					}
+			// Check (and eventually update) the user effects
+			UpdateUserEffects(claim.UserID, utils)
 
-					msg.ReplyToMessageID = update.Message.MessageID
-					data.Bot.Send(msg)
+			// Activate the event and calculate the delay from o' clock
+			event.Activate(Users[claim.UserID], claim.ReceivedAt, claim.SentAt, event.Points)
+			delay := claim.ReceivedAt.Sub(event.Activation.ArrivedAt.Truncate(event.Precision()))
 
-					// Log Event activated
-					utils.Logger.WithFields(logrus.Fields{
-						"actBy": update.Message.From.UserName,
-						"actAt": update.Message.Text,
-						"dfPts": event.Points,
-						"efPts": event.Activation.EarnedPoints,
-					}).Debug("Event activated")
-
-					// Add points to the user if they have never participated the event before
-					if !event.HasPartecipated(update.Message.From.ID) {
-						event.Partecipate(Users[update.Message.From.ID], curTime)
-						Users[update.Message.From.ID].TotalPoints += event.Activation.EarnedPoints
-						Users[update.Message.From.ID].TotalEventPartecipations++
-						Users[update.Message.From.ID].TotalEventWins++
-					}
+			// Give the timing effects of the winning claim to the event
+			for _, effect := range TimingEffects(TimingCheck{claim, location, event, 0}, false, utils) {
+				event.AddEffect(effect)
+			}
+
+			// Apply all effects
+			effectNames := ""
+			curEffects := append(event.Effects, Users[claim.UserID].Effects...)
+			for i := 0; i < len(curEffects); i++ {
+				if i != len(curEffects)-1 {
+					effectNames += fmt.Sprintf("%q, ", curEffects[i].Name)
 				} else {
-					// Calculate the delay from o' clock and winner user
-					delay := curTime.Sub(time.Date(event.Activation.ArrivedAt.Year(), event.Activation.ArrivedAt.Month(), event.Activation.ArrivedAt.Day(), event.Activation.ArrivedAt.Hour(), event.Activation.ArrivedAt.Minute(), 0, 0, event.Activation.ArrivedAt.Location()))
-					delta := curTime.Sub(event.Activation.ActivatedAt)
+					effectNames += fmt.Sprintf("%q", curEffects[i].Name)
+				}
+
+				event.Activation.EarnedPoints = curEffects[i].Apply(event.Activation.EarnedPoints)
+			}
 
-					// Respond to the user with event already activated informations
-					msg := tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("L'evento è già stato attivato da %v +%vs fa.\nHai impiegato +%vs.", event.Activation.ActivatedBy.UserName, delta.Seconds(), delay.Seconds()))
-					msg.ReplyToMessageID = update.Message.MessageID
-					data.Bot.Send(msg)
+			// The winner takes the jackpot of the chat, if it's in play
+			jackpot := TakeJackpot(claim.ChatID, event, utils)
 
-					// Log Event already activated
+			// The effects are shown only if they are revealed in the chat
+			if !GetSettings(claim.ChatID, utils).RevealEffects {
+				effectNames = ""
+			}
+
+			// Respond with the result message of the event, if enabled
+			if utils.Config.Game.AggregateResults {
+				StartEventResult(claim, event, curEffects, delay, utils, data)
+			} else {
+				// Respond to the user with event activated informations
+				msg := tgbotapi.NewMessage(claim.ChatID, Translate(claim.ChatID, claim.UserID, "claim.activated", map[string]any{
+					"EventName": event.DisplayName,
+					"User":      claim.UserName,
+					"Points":    event.Activation.EarnedPoints,
+					"Effects":   effectNames,
+					"Delay":     delay.Seconds(),
+				}, utils))
+				msg.ReplyToMessageID = claim.MessageID
+				if message, err := data.Bot.Send(msg); err != nil {
 					utils.Logger.WithFields(logrus.Fields{
-						"actBy": event.Activation.ActivatedBy,
-						"actAt": event.Activation.ActivatedAt.Format(utils.TimeFormat),
-						"delta": delta,
-						"delay": delay,
-					}).Debug("Event already activated")
-
-					// Add the user to the data structure if they have never participated before
-					if _, ok := Users[update.Message.From.ID]; !ok {
-						Users[update.Message.From.ID] = structs.NewUser(update.Message.From.ID, update.Message.From.UserName)
-					}
-					// Add partecipations to the user if they have never participated the event before
-					if !event.HasPartecipated(update.Message.From.ID) {
-						event.Partecipate(Users[update.Message.From.ID], curTime)
-						Users[update.Message.From.ID].TotalEventPartecipations++
-					}
+						"err": err,
+						"msg": message,
+					}).Error("Error while sending message")
 				}
+			}
 
-				// Save the users file with updated Users data structure
-				file, err := json.MarshalIndent(Users, "", " ")
-				if err != nil {
-					utils.Logger.WithFields(logrus.Fields{
-						"err":  err,
-						"note": "preoccupati",
-					}).Error("Error while marshalling data")
-					utils.Logger.Error(Users)
+			if jackpot != 0 {
+				AnnounceJackpot(claim, jackpot, utils, data)
+			}
+
+			// Log Event activated
+			utils.Logger.WithFields(logrus.Fields{
+				"actBy": claim.UserName,
+				"actAt": claim.Text,
+				"dfPts": event.Points,
+				"efPts": event.Activation.EarnedPoints,
+			}).Debug("Event activated")
+
+			// Add points to the user if they have never participated the event before
+			if !event.HasPartecipated(claim.UserID) {
+				event.Partecipate(Users[claim.UserID], claim.ReceivedAt)
+				Users[claim.UserID].TotalPoints += event.Activation.EarnedPoints
+				IndexUser(claim.UserID)
+				Users[claim.UserID].TotalEventPartecipations++
+				Users[claim.UserID].TotalEventWins++
+				RecordClaim(claim, location, history.Claim{EventKey: event.Name, Won: true, Points: event.Activation.EarnedPoints, Effects: EffectNames(curEffects), Delay: delay}, utils)
+				CheckAchievements(AchievementCheck{claim, location, ChatEvents(claim.ChatID, utils), event, true, curEffects}, utils, data)
+			}
+		} else {
+			// Calculate the delay from o' clock and winner user
+			delay := claim.ReceivedAt.Sub(event.Activation.ArrivedAt.Truncate(event.Precision()))
+			delta := claim.ReceivedAt.Sub(event.Activation.ActivatedAt)
+
+			// The claims that arrive right after the winning one can give the late timing effects to the winner
+			lateEffects := make([]*structs.Effect, 0)
+			if !event.HasPartecipated(claim.UserID) {
+				check := TimingCheck{claim, location, event, delta}
+				lateEffects = TimingEffects(check, true, utils)
+				ApplyLateEffects(check, lateEffects, utils)
+			}
+			lateEffectNames := ""
+			if GetSettings(claim.ChatID, utils).RevealEffects {
+				for i, effect := range lateEffects {
+					if i != 0 {
+						lateEffectNames += ", "
+					}
+					lateEffectNames += fmt.Sprintf("%q", effect.Name)
 				}
-				err = os.WriteFile("files/users.json", file, 0644)
-				if err != nil {
+			}
+
+			// Add the user to the result message of the event (if it's still open) or respond to the user with event already activated informations
+			if !utils.Config.Game.AggregateResults || (!event.HasPartecipated(claim.UserID) && !AddResultPartecipant(claim.ChatID, event.Name, claim.UserName, delay, utils, data)) {
+				msg := tgbotapi.NewMessage(claim.ChatID, Translate(claim.ChatID, claim.UserID, "claim.already_activated", map[string]any{
+					"Winner":  event.Activation.ActivatedBy.UserName,
+					"Delta":   delta.Seconds(),
+					"Delay":   delay.Seconds(),
+					"Effects": lateEffectNames,
+				}, utils))
+				msg.ReplyToMessageID = claim.MessageID
+				if message, err := data.Bot.Send(msg); err != nil {
 					utils.Logger.WithFields(logrus.Fields{
-						"err":  err,
-						"note": "preoccupati tanto",
-					}).Error("Error while writing data")
-					utils.Logger.Error(Users)
+						"err": err,
+						"msg": message,
+					}).Error("Error while sending message")
 				}
 			}
+
+			// Log Event already activated
+			utils.Logger.WithFields(logrus.Fields{
+				"actBy": event.Activation.ActivatedBy,
+				"actAt": event.Activation.ActivatedAt.Format(utils.TimeFormat),
+				"delta": delta,
+				"delay": delay,
+			}).Debug("Event already activated")
+
+			// Add the user to the data structure if they have never participated before
+			if _, ok := Users[claim.UserID]; !ok {
+				Users[claim.UserID] = structs.NewUser(claim.UserID, claim.UserName)
+				IndexUser(claim.UserID)
+			}
+			// Add partecipations to the user if they have never participated the event before
+			if !event.HasPartecipated(claim.UserID) {
+				event.Partecipate(Users[claim.UserID], claim.ReceivedAt)
+				Users[claim.UserID].TotalEventPartecipations++
+				RecordClaim(claim, location, history.Claim{EventKey: event.Name, Delay: delay}, utils)
+				CheckAchievements(AchievementCheck{claim, location, ChatEvents(claim.ChatID, utils), event, false, nil}, utils, data)
+			}
 		}
+
+		// Save the users file with updated Users data structure
+		SaveUsers(utils)
 	}
 }
 
-func UpdateUserEffects(userID int64) {
-	// Generate the ranking
-	ranking := make([]Rank, 0)
-	for _, u := range Users {
-		if u != nil {
-			ranking = append(ranking, Rank{u.UserName, u.TotalPoints, u.TotalEventPartecipations})
-		}
+// Timeline contains the claims of the users (it's protected by stateMutex, and nil if the timeline can't be kept)
+var Timeline *history.Timeline
+
+// Add the claim (a win or a partecipation) to the timeline of the user, dated in the timezone of the chat
+func RecordClaim(claim Claim, location *time.Location, record history.Claim, utils types.Utils) {
+	if Timeline == nil {
+		return
 	}
+	record.ChatID = claim.ChatID
+	record.Date = claim.SentAt.In(location).Format(history.DateFormat)
+	InvalidateRankings()
+	if err := Timeline.Add(claim.UserID, record); err != nil {
+		utils.Logger.WithFields(logrus.Fields{
+			"err":  err,
+			"user": claim.UserID,
+		}).Error("Error while recording the claim")
+	}
+}
 
-	// Sort the ranking by points (and partecipations if points are equal)
-	sort.Slice(
-		ranking,
-		func(i, j int) bool {
-			if ranking[i].Points == ranking[j].Points {
-				return ranking[i].Partecipations < ranking[j].Partecipations
-			}
-			return ranking[i].Points > ranking[j].Points
-		},
-	)
-
-	// Get interval from ranking leader
-	leaderPoints := ranking[0].Points
-	userPoints := 0
-	for _, rank := range ranking {
-		if rank.Username == Users[userID].UserName {
-			userPoints = rank.Points
-		}
+// Get the names of the effects
+func EffectNames(effects []*structs.Effect) []string {
+	names := make([]string, 0, len(effects))
+	for _, effect := range effects {
+		names = append(names, effect.Name)
+	}
+	return names
+}
+
+// Update the handicap effects of the user, evaluating the rules of the configuration
+func UpdateUserEffects(userID int64, utils types.Utils) {
+	// The users who have never participated have no effects
+	user, ok := Users[userID]
+	if !ok {
+		return
+	}
+
+	// Remove the previous handicaps and give the current ones
+	for _, handicap := range utils.Config.Handicaps {
+		user.RemoveEffect(&structs.Effect{Name: handicap.Name})
+	}
+	for _, handicap := range UserHandicaps(userID, utils) {
+		user.AddEffect(HandicapEffect(handicap.Handicap))
+	}
+}
+
+// Save the Chats data structure on files/chats.json
+func SaveChats(utils types.Utils) {
+	file, err := json.MarshalIndent(Chats, "", " ")
+	if err != nil {
+		utils.Logger.WithFields(logrus.Fields{
+			"err": err,
+		}).Error("Error while marshalling data")
+		return
+	}
+	err = os.WriteFile("files/chats.json", file, 0644)
+	if err != nil {
+		utils.Logger.WithFields(logrus.Fields{
+			"err": err,
+		}).Error("Error while writing data")
+	}
+}
+
+// Save the Users data structure on files/users.json
+func SaveUsers(utils types.Utils) {
+	// The rankings change with the users stats
+	InvalidateRankings()
+
+	file, err := json.MarshalIndent(Users, "", " ")
+	if err != nil {
+		utils.Logger.WithFields(logrus.Fields{
+			"err":  err,
+			"note": "preoccupati",
+		}).Error("Error while marshalling data")
+		utils.Logger.Error(Users)
 	}
-	interval := leaderPoints - userPoints
-
-	//Remove the Comeback effect
-	user := Users[userID]
-	user.RemoveEffect(structs.ComebackBonus1)
-	user.RemoveEffect(structs.ComebackBonus2)
-	user.RemoveEffect(structs.ComebackBonus3)
-	switch {
-	case interval >= 20 && interval < 50:
-		//Add the +1 Comeback effect
-		user.AddEffect(structs.ComebackBonus1)
-	case interval >= 50 && interval < 80:
-		//Add the +2 Comeback effect
-		user.AddEffect(structs.ComebackBonus2)
-	case interval >= 80:
-		//Add the +3 Comeback effect
-		user.AddEffect(structs.ComebackBonus3)
+	err = os.WriteFile("files/users.json", file, 0644)
+	if err != nil {
+		utils.Logger.WithFields(logrus.Fields{
+			"err":  err,
+			"note": "preoccupati tanto",
+		}).Error("Error while writing data")
+		utils.Logger.Error(Users)
 	}
-	Users[userID] = user
 }
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/MoraGames/clockyuwu/config"
+	"github.com/MoraGames/clockyuwu/history"
+	"github.com/MoraGames/clockyuwu/pkg/clock"
+	"github.com/MoraGames/clockyuwu/pkg/types"
+	"github.com/MoraGames/clockyuwu/structs"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+func Test_UpdateUserEffects(t *testing.T) {
+	conf := &config.Config{Handicaps: config.Handicaps{
+		{Name: "Comeback 1", Condition: "leader_gap", Min: 20, Max: 50, Key: "+", Value: 1},
+		{Name: "Comeback 3", Condition: "leader_gap", Min: 80, Key: "+", Value: 3},
+		{Name: "Chaser", Condition: "next_gap", Min: 10, Key: "+", Value: 1},
+		{Name: "Last Ones", Condition: "percentile", Min: 50, Key: "*", Value: 2, Cap: 2},
+		{Name: "Welcome Back", Condition: "days_absent", Min: 7, Key: "+", Value: 2},
+	}}
+	utils := types.Utils{Config: conf, Logger: logrus.New(), Clock: clock.NewVirtual(time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC))}
+
+	var err error
+	if Timeline, err = history.NewTimeline(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	Timeline.Add(3, history.Claim{Date: "2024-03-20"})
+	Timeline.Add(4, history.Claim{Date: "2024-03-30"})
+
+	// Two users have the same name, the handicaps depend only on the user
+	Users = map[int64]*structs.User{
+		1: {TelegramID: 1, UserName: "alice", TotalPoints: 90},
+		2: {TelegramID: 2, UserName: "bob", TotalPoints: 60},
+		3: {TelegramID: 3, UserName: "bob", TotalPoints: 5, Effects: []*structs.Effect{{Name: "Comeback 1"}, structs.TrophyBonus}},
+		4: {TelegramID: 4, UserName: "carol", TotalPoints: 60},
+	}
+	RebuildPointsIndex(utils)
+	defer func() {
+		Users = make(map[int64]*structs.User)
+		RebuildPointsIndex(utils)
+		Timeline = nil
+	}()
+
+	tests := []struct {
+		userID  int64
+		effects []string
+	}{
+		{1, []string{}},
+		{2, []string{"Comeback 1", "Chaser"}},
+		// The handicaps replace the previous ones, the other effects are kept
+		{3, []string{"Trophy", "Comeback 3", "Chaser", "Last Ones", "Welcome Back"}},
+		{4, []string{"Comeback 1", "Chaser"}},
+	}
+	for _, test := range tests {
+		UpdateUserEffects(test.userID, utils)
+		effects := Users[test.userID].Effects
+		if len(effects) != len(test.effects) {
+			t.Errorf("User %v should have the effects %v, got %v", test.userID, test.effects, effects)
+			continue
+		}
+		for i, effect := range effects {
+			if effect.Name != test.effects[i] {
+				t.Errorf("User %v should have the effects %v, got %v", test.userID, test.effects, effects)
+			}
+		}
+	}
+
+	// The handicaps follow the points
+	Users[3].TotalPoints = 50
+	IndexUser(3)
+	UpdateUserEffects(3, utils)
+	if effects := Users[3].Effects; len(effects) != 5 || effects[1].Name != "Comeback 1" {
+		t.Errorf("User 3 should have Comeback 1 instead of Comeback 3, got %v", effects)
+	}
+}
+
+func Test_Run_Shutdown(t *testing.T) {
+	utils := testUtils(&config.Config{}, time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC))
+
+	// The updates already received when the bot is stopped are managed anyway
+	updates := make(chan tgbotapi.Update, 3)
+	for i := 1; i <= 3; i++ {
+		updates <- tgbotapi.Update{UpdateID: i}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if managed := run(ctx, utils, types.Data{Updates: updates}); managed != 3 {
+		t.Errorf("The 3 received updates should be managed, got %v", managed)
+	}
+}
+
+func Test_RecordClaim_Timeline(t *testing.T) {
+	inTempDir(t)
+	at := time.Date(2024, 3, 31, 12, 34, 1, 0, time.UTC)
+	utils := testUtils(&config.Config{Settings: config.Settings{Timezone: "UTC"}}, at)
+	resetState(utils)
+	var err error
+	if Timeline, err = history.NewTimeline(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { Timeline = nil }()
+	testEvents(-1, 3, "12:34").Map["12:34"].AddEffect(structs.DoublePositivePoints)
+	data := types.Data{Bot: &recordingSender{}}
+
+	// Alice wins the event and Bob partecipates: both claims are recorded
+	ManageClaim(testClaim(-1, testAlice, "12:34", at, 250*time.Millisecond), utils, data)
+	ManageClaim(testClaim(-1, testBob, "12:34", at, time.Second), utils, data)
+	expected := map[int64]history.Claim{
+		testAlice.ID: {ChatID: -1, Date: "2024-03-31", EventKey: "12:34", Won: true, Points: 6, Effects: []string{"Mul +2"}, Delay: 1250 * time.Millisecond},
+		testBob.ID:   {ChatID: -1, Date: "2024-03-31", EventKey: "12:34", Delay: 2 * time.Second},
+	}
+	for userID, claim := range expected {
+		if claims, err := Timeline.Claims(userID); err != nil || len(claims) != 1 || !reflect.DeepEqual(claims[0], claim) {
+			t.Errorf("The claims of user %v should be [%+v], got %+v (%v)", userID, claim, claims, err)
+		}
+	}
+
+	// The stats include the metrics derived from the timeline
+	stats := StatsData(Users[testAlice.ID], utils)["Activity"]
+	if activity, ok := stats.(*history.ClaimsStats); !ok || *activity != (history.ClaimsStats{BestDay: "2024-03-31", BestDayPoints: 6, FavouriteMinute: "12:34", FavouriteMinuteClaims: 1, AverageDelay: 1250 * time.Millisecond, BestDelay: 1250 * time.Millisecond}) {
+		t.Errorf("Unexpected activity stats: %+v", stats)
+	}
+}